@@ -0,0 +1,18 @@
+// The service broker pushed by ServiceBroker.Push. It starts with an empty
+// catalog; ServiceBroker.Configure scripts it through PUT /config.
+package main
+
+import (
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/cloudfoundry/capi-bara-tests/helpers/broker"
+)
+
+func main() {
+	b := broker.New(os.Getenv("BROKER_USERNAME"), os.Getenv("BROKER_PASSWORD"))
+
+	log.Printf("Service broker listening on port %s", os.Getenv("PORT"))
+	log.Fatal(http.ListenAndServe(":"+os.Getenv("PORT"), b))
+}
@@ -0,0 +1,80 @@
+package bara_suite_helpers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	. "github.com/cloudfoundry/capi-bara-tests/helpers/config"
+	. "github.com/onsi/ginkgo/v2"
+)
+
+const (
+	DeploymentsGroup     = "deployments"
+	DropletsGroup        = "droplets"
+	EventsGroup          = "events"
+	ManifestGroup        = "manifest"
+	NginxGroup           = "nginx"
+	ProcessCommandsGroup = "process_commands"
+	QuotasGroup          = "quotas"
+	RevisionsGroup       = "revisions"
+	RoutingGroup         = "routing"
+	ServicesGroup        = "services"
+	SidecarsGroup        = "sidecars"
+	TasksGroup           = "tasks"
+	ZeroDowntimeGroup    = "zero_downtime"
+)
+
+// groups maps each test group to the include_* toggle that enables it.
+var groups = map[string]func(BaraConfig) bool{
+	DeploymentsGroup:     BaraConfig.GetIncludeDeployments,
+	DropletsGroup:        BaraConfig.GetIncludeDroplets,
+	EventsGroup:          BaraConfig.GetIncludeEvents,
+	ManifestGroup:        BaraConfig.GetIncludeManifest,
+	NginxGroup:           BaraConfig.GetIncludeNginx,
+	ProcessCommandsGroup: BaraConfig.GetIncludeProcessCommands,
+	QuotasGroup:          BaraConfig.GetIncludeQuotas,
+	RevisionsGroup:       BaraConfig.GetIncludeRevisions,
+	RoutingGroup:         BaraConfig.GetIncludeRouting,
+	ServicesGroup:        BaraConfig.GetIncludeServices,
+	SidecarsGroup:        BaraConfig.GetIncludeSidecars,
+	TasksGroup:           BaraConfig.GetIncludeTasks,
+	ZeroDowntimeGroup:    BaraConfig.GetIncludeZeroDowntime,
+}
+
+// BaraDescribe is a top-level Describe labelled with its test group, so that
+// the whole group is skipped when its include_* toggle is false.
+func BaraDescribe(group, description string, args ...interface{}) bool {
+	if _, ok := groups[group]; !ok {
+		panic(fmt.Sprintf("unknown BARA test group '%s'", group))
+	}
+	return Describe(fmt.Sprintf("[%s] %s", group, description), append([]interface{}{Label(group)}, args...)...)
+}
+
+// GroupIncluded reports whether the group's include_* toggle is enabled.
+func GroupIncluded(config BaraConfig, group string) bool {
+	included, ok := groups[group]
+	return ok && included(config)
+}
+
+// DisabledGroupsLabelFilter narrows labelFilter to exclude every group
+// disabled in config. Filtering by label skips the specs before any
+// BeforeEach runs, unlike calling Skip from within the group.
+func DisabledGroupsLabelFilter(config BaraConfig, labelFilter string) string {
+	var disabled []string
+	for group, included := range groups {
+		if !included(config) {
+			disabled = append(disabled, "!"+group)
+		}
+	}
+	if len(disabled) == 0 {
+		return labelFilter
+	}
+	sort.Strings(disabled)
+
+	filter := strings.Join(disabled, " && ")
+	if labelFilter != "" {
+		filter = "(" + labelFilter + ") && " + filter
+	}
+	return filter
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -15,8 +16,13 @@ import (
 
 	_ "github.com/cloudfoundry/capi-bara-tests/baras"
 
+	"github.com/cloudfoundry/capi-bara-tests/helpers/cleanup"
 	. "github.com/cloudfoundry/capi-bara-tests/helpers/cli_version_check"
 	"github.com/cloudfoundry/capi-bara-tests/helpers/config"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/diagnostics"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/reporters"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/trace"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/v3_helpers"
 	"github.com/cloudfoundry/cf-test-helpers/v2/helpers"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -71,6 +77,16 @@ func TestBARA(t *testing.T) {
 		Expect(err).NotTo(HaveOccurred())
 		Eventually(session, 30*time.Second).Should(gexec.Exit(0))
 
+		buildCmd = exec.Command("go", "build", "-o", "assets/service-broker/service-broker", "./assets/service-broker")
+		buildCmd.Env = append(os.Environ(),
+			"GOOS=linux",
+			"GOARCH=amd64",
+		)
+
+		session, err = gexec.Start(buildCmd, GinkgoWriter, GinkgoWriter)
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(session, 30*time.Second).Should(gexec.Exit(0))
+
 		assetPaths := assets.NewAssets()
 		ZipAsset(assetPaths.Dora, assetPaths.DoraZip)
 		ZipAsset(assetPaths.BadDora, assetPaths.BadDoraZip)
@@ -89,17 +105,86 @@ func TestBARA(t *testing.T) {
 		return []byte{}
 	}, func([]byte) {})
 
+	var (
+		specTracePath     string
+		keepSpecResources bool
+	)
+
+	// Registered with DeferCleanup, so both run after every AfterEach, and
+	// in reverse order: tracked resources are deleted before the spec's org
+	// is torn down, as org quotas and brokers outlive it.
+	deleteTrackedResources := func(setup *workflowhelpers.ReproducibleTestSuiteSetup) {
+		keepSpecResources = Config.GetKeepResourcesOnFailure() && CurrentSpecReport().Failed()
+		if keepSpecResources {
+			var kept []string
+			for _, resource := range cleanup.Tracked() {
+				kept = append(kept, resource.String())
+			}
+			space := setup.RegularUserContext().TestSpace
+			kept = append(kept, fmt.Sprintf("org %s with space %s", space.OrganizationName(), space.SpaceName()))
+			AddReportEntry("Kept resources", strings.Join(kept, "\n"), ReportEntryVisibilityFailureOrVerbose)
+			return
+		}
+
+		workflowhelpers.AsUser(setup.AdminUserContext(), Config.DefaultTimeoutDuration(), func() {
+			Expect(v3_helpers.DeleteTrackedResources()).To(Succeed())
+		})
+	}
+
+	tearDown := func(setup *workflowhelpers.ReproducibleTestSuiteSetup, originalCfHome string) {
+		if !keepSpecResources {
+			setup.Teardown()
+			return
+		}
+
+		// Log out as Teardown does, but leave the org, space and user behind.
+		specCfHome := os.Getenv("CF_HOME")
+		setup.RegularUserContext().Logout()
+		setup.RegularUserContext().UnsetCfHomeDir(originalCfHome, specCfHome)
+	}
+
 	BeforeEach(func() {
 		SetDefaultEventuallyTimeout(Config.DefaultTimeoutDuration())
 		SetDefaultEventuallyPollingInterval(1 * time.Second)
 
+		specTracePath = ""
+		keepSpecResources = false
+		diagnostics.ResetTrackedApps()
+		cleanup.Reset()
+		if Config.GetArtifactsDirectory() != "" {
+			specTracePath = trace.SpecTraceFile(Config.GetArtifactsDirectory(), "BARA", GinkgoParallelProcess(), CurrentSpecReport().FullText())
+			DeferCleanup(os.Setenv, "CF_TRACE", os.Getenv("CF_TRACE"))
+			DeferCleanup(trace.RedactFile, specTracePath)
+			Expect(os.MkdirAll(filepath.Dir(specTracePath), 0755)).To(Succeed())
+			Expect(os.Setenv("CF_TRACE", specTracePath)).To(Succeed())
+		}
+
 		TestSetup = workflowhelpers.NewTestSuiteSetup(Config)
+		DeferCleanup(tearDown, TestSetup, os.Getenv("CF_HOME"))
+		DeferCleanup(deleteTrackedResources, TestSetup)
 		TestSetup.Setup()
 	})
 
+	// Runs before the deferred cleanup so that its API calls do not crowd
+	// out the exchanges that led to the failure, and so that the failed
+	// spec's apps still exist when diagnostics are collected.
 	AfterEach(func() {
-		if TestSetup != nil {
-			TestSetup.Teardown()
+		if !CurrentSpecReport().Failed() || specTracePath == "" {
+			return
+		}
+
+		if Config.GetFailureTraceExchanges() > 0 {
+			exchanges, err := trace.LastExchanges(specTracePath, Config.GetFailureTraceExchanges())
+			if err == nil && exchanges != "" {
+				AddReportEntry(fmt.Sprintf("Last %d CF API exchanges (full trace: %s)", Config.GetFailureTraceExchanges(), specTracePath), exchanges, ReportEntryVisibilityFailureOrVerbose)
+			}
+		}
+
+		bundle, err := v3_helpers.CollectDiagnostics(CurrentSpecReport().FullText())
+		if err != nil {
+			AddReportEntry("Failed to collect diagnostics", err.Error(), ReportEntryVisibilityFailureOrVerbose)
+		} else {
+			AddReportEntry("Diagnostics", bundle, ReportEntryVisibilityFailureOrVerbose)
 		}
 	})
 
@@ -112,14 +197,32 @@ func TestBARA(t *testing.T) {
 		os.Remove(assets.NewAssets().SleepySidecarBuildpackZip)
 	})
 
-	_, rc := GinkgoConfiguration()
+	sc, rc := GinkgoConfiguration()
 
 	if validationError == nil {
+		sc.LabelFilter = DisabledGroupsLabelFilter(Config, sc.LabelFilter)
+
 		if Config.GetArtifactsDirectory() != "" {
 			helpers.EnableCFTrace(Config, "BARA")
 			rc.JUnitReport = filepath.Join(Config.GetArtifactsDirectory(), fmt.Sprintf("junit-%s-%d.xml", "BARA", GinkgoParallelProcess()))
 		}
+
+		sink, err := reporters.NewSinkFromConfig(Config, GinkgoParallelProcess())
+		if err != nil {
+			fmt.Println("Failed to set up the spec event reporter:", err)
+			t.FailNow()
+		}
+		if sink != nil {
+			specReporter := reporters.NewSpecReporter(sink, os.Getenv("RUN_ID"), Config.GetReporterConfig().CustomTags)
+			defer specReporter.Close()
+
+			ReportAfterEach(func(report SpecReport) {
+				if err := specReporter.ReportSpec(report); err != nil {
+					fmt.Fprintln(os.Stderr, "Failed to report spec event:", err)
+				}
+			})
+		}
 	}
 
-	RunSpecs(t, "BARA", rc)
+	RunSpecs(t, "BARA", sc, rc)
 }
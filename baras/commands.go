@@ -13,7 +13,7 @@ import (
 	. "github.com/onsi/gomega/gexec"
 )
 
-var _ = Describe("setting_process_commands", func() {
+var _ = BaraDescribe(ProcessCommandsGroup, "setting_process_commands", func() {
 	var (
 		appName             string
 		appGUID             string
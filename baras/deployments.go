@@ -10,12 +10,13 @@ import (
 	"github.com/cloudfoundry/capi-bara-tests/helpers/random_name"
 	. "github.com/cloudfoundry/capi-bara-tests/helpers/v3_helpers"
 	"github.com/cloudfoundry/cf-test-helpers/v2/cf"
+	"github.com/cloudfoundry/cf-test-helpers/v2/helpers"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	. "github.com/onsi/gomega/gexec"
 )
 
-var _ = Describe("deployments", func() {
+var _ = BaraDescribe(DeploymentsGroup, "deployments", func() {
 	var (
 		appName        string
 		appGUID        string
@@ -162,6 +163,106 @@ var _ = Describe("deployments", func() {
 		})
 	})
 
+	Describe("Rolling out the current droplet", func() {
+		It("keeps every instance running while it replaces them", func() {
+			deployment := Deploy(appGUID, DeploymentOptions{Strategy: "rolling"})
+			Expect(deployment.Strategy).To(Equal("rolling"))
+			Expect(deployment.DropletGUID).To(Equal(dropletGuid))
+			Expect(deployment.PreviousDropletGUID).To(Equal(dropletGuid))
+
+			rollout := ObserveRollout(appGUID, deployment.GUID, time.Second).Wait()
+			Expect(rollout.MinRunning()).To(BeNumerically(">=", 4), "rollout:\n%s", rollout)
+			last := rollout[len(rollout)-1]
+			Expect([]int{last.Old, last.New}).To(Equal([]int{0, 4}), "rollout:\n%s", rollout)
+
+			deployment = GetDeployment(deployment.GUID)
+			Expect(deployment.StatusReason).To(Equal("DEPLOYED"))
+			Expect(GetProcessGuidsForType(appGUID, "web")).To(Equal(deployment.NewProcessGUIDs))
+		})
+	})
+
+	Describe("Limiting instances in flight", func() {
+		var catnipDropletGUID string
+
+		BeforeEach(func() {
+			catnipDropletGUID = CreateAndAssociateNewDroplet(appGUID, assets.NewAssets().CatnipZip, Config.GetGoBuildpackName())
+			ScaleApp(appGUID, 6)
+
+			By("waiting until all instances are running")
+			Eventually(func() int {
+				return GetRunningInstancesStats(GetProcessGuidsForType(appGUID, "web")[0])
+			}).Should(Equal(6))
+		})
+
+		It("starts no more than max_in_flight new instances at once", func() {
+			deployment := Deploy(appGUID, DeploymentOptions{DropletGUID: catnipDropletGUID, MaxInFlight: 2})
+			Expect(deployment.MaxInFlight).To(Equal(2))
+
+			Expect(MaxInstancesInFlight(deployment.GUID)).To(BeNumerically("<=", 2))
+			WaitUntilDeploymentReachesStatus(deployment.GUID, "FINALIZED", "DEPLOYED")
+			Expect(GetRunningInstancesStats(deployment.NewProcessGUIDs[0])).To(Equal(6))
+			Expect(helpers.CurlAppRoot(Config, appName)).To(Equal("Catnip?"))
+		})
+	})
+
+	Describe("Canary deployments", func() {
+		// instanceIDs counts the requests served by each instance of the app.
+		instanceIDs := func(requests int) map[string]int {
+			ids := map[string]int{}
+			for i := 0; i < requests; i++ {
+				ids[helpers.CurlApp(Config, appName, "/id")]++
+			}
+			return ids
+		}
+
+		It("routes traffic to exactly one canary instance until continued", func() {
+			oldIDs := instanceIDs(40)
+			Expect(oldIDs).To(HaveLen(4))
+
+			deploymentGUID := CreateCanaryDeployment(appGUID)
+			WaitUntilDeploymentIsPaused(deploymentGUID)
+
+			deployment := GetDeployment(deploymentGUID)
+			Expect(deployment.Strategy).To(Equal("canary"))
+			Expect(deployment.NewProcessGUIDs).To(HaveLen(1))
+			Expect(GetRunningInstancesStats(deployment.NewProcessGUIDs[0])).To(Equal(1))
+
+			canaryIDs := map[string]int{}
+			for id, hits := range instanceIDs(50) {
+				if _, old := oldIDs[id]; !old {
+					canaryIDs[id] = hits
+				}
+			}
+			Expect(canaryIDs).To(HaveLen(1), "requests served by new instances: %v", canaryIDs)
+
+			ContinueDeployment(deploymentGUID)
+			WaitUntilDeploymentReachesStatus(deploymentGUID, "FINALIZED", "DEPLOYED")
+			Expect(GetProcessGuidsForType(appGUID, "web")).To(Equal(deployment.NewProcessGUIDs))
+		})
+
+		It("restores the old droplet and revision when canceled while paused", func() {
+			originalWeb := GetProcessByGuid(GetProcessGuidsForType(appGUID, "web")[0])
+			originalRevisionGUID := originalWeb.Relationships.Revision.Data.Guid
+			Expect(originalRevisionGUID).NotTo(BeEmpty())
+
+			By("Staging a new droplet")
+			buildGUID := StagePackage(packageGUID, Config.Lifecycle(), Config.GetRubyBuildpackName())
+			WaitForBuildToStage(buildGUID)
+			newDropletGuid = GetDropletFromBuild(buildGUID)
+
+			deployment := Deploy(appGUID, DeploymentOptions{DropletGUID: newDropletGuid, Strategy: "canary"})
+			Expect(deployment.PreviousDropletGUID).To(Equal(dropletGuid))
+			WaitUntilDeploymentIsPaused(deployment.GUID)
+
+			CancelDeployment(deployment.GUID)
+			WaitUntilDeploymentReachesStatus(deployment.GUID, "FINALIZED", "CANCELED")
+
+			Expect(GetDropletFromApp(appGUID)).To(Equal(dropletGuid))
+			Expect(GetProcessGuidsForType(appGUID, "web")).To(Equal([]string{originalWeb.Guid}))
+			Expect(GetProcessByGuid(originalWeb.Guid).Relationships.Revision.Data.Guid).To(Equal(originalRevisionGUID))
+		})
+	})
+
 	Describe("Health check timeout is set on the app", func() {
 		BeforeEach(func() {
 			ScaleApp(appGUID, 2)
@@ -15,7 +15,7 @@ import (
 	"os"
 )
 
-var _ = Describe("Droplet upload and download", func() {
+var _ = BaraDescribe(DropletsGroup, "Droplet upload and download", func() {
 	var (
 		appGUID string
 		appName string
@@ -54,7 +54,7 @@ type app struct {
 	route       string
 }
 
-var _ = Describe("apply_manifest", func() {
+var _ = BaraDescribe(ManifestGroup, "apply_manifest", func() {
 	var (
 		apps             []app
 		broker           ServiceBroker
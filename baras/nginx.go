@@ -1,6 +1,7 @@
 package baras
 
 import (
+	. "github.com/cloudfoundry/capi-bara-tests/bara_suite_helpers"
 	"github.com/cloudfoundry/cf-test-helpers/v2/cf"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -8,7 +9,7 @@ import (
 	"regexp"
 )
 
-var _ = Describe("nginx config logic", func() {
+var _ = BaraDescribe(NginxGroup, "nginx config logic", func() {
 	Describe("hitting /v3/packages/:guid/upload with invalid parameters", func() {
 		It("returns 422 Unprocessable Entity", func() {
 			session := cf.Cf("curl", "-X", "POST", "/v3/packages/literally-any-guid/upload?bits_path='some/path'", "-i")
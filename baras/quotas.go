@@ -16,7 +16,7 @@ import (
 	. "github.com/onsi/gomega/gexec"
 )
 
-var _ = Describe("Quotas", func() {
+var _ = BaraDescribe(QuotasGroup, "Quotas", func() {
 	var (
 		orgName    string
 		spaceName  string
@@ -12,7 +12,7 @@ import (
 	. "github.com/onsi/gomega"
 )
 
-var _ = Describe("revisions", func() {
+var _ = BaraDescribe(RevisionsGroup, "revisions", func() {
 	var (
 		appName              string
 		appGUID              string
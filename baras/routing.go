@@ -0,0 +1,122 @@
+package baras
+
+import (
+	"path/filepath"
+	"strings"
+
+	. "github.com/cloudfoundry/capi-bara-tests/bara_suite_helpers"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/assets"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/prober"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/random_name"
+	. "github.com/cloudfoundry/capi-bara-tests/helpers/v3_helpers"
+	"github.com/cloudfoundry/cf-test-helpers/v2/cf"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gexec"
+)
+
+const (
+	// weightedRequests is how many requests are sent through a weighted
+	// route before comparing their split with the weights.
+	weightedRequests = 400
+	// weightTolerance is how many standard deviations the split may be
+	// off the weights before the spec fails, making flakes very unlikely.
+	weightTolerance = 4
+)
+
+var _ = BaraDescribe(RoutingGroup, "weighted routing", func() {
+	var (
+		spaceGUID  string
+		domainGUID string
+		routeHost  string
+		routeGUID  string
+	)
+
+	waitForRoute := func() {
+		Eventually(func() int {
+			return SendRequests(routeHost, "/", 1).Errors()
+		}, Config.DefaultTimeoutDuration(), "1s").Should(BeZero())
+	}
+
+	BeforeEach(func() {
+		spaceGUID = GetSpaceGuidFromName(TestSetup.RegularUserContext().Space)
+		domainGUID = GetDomainGUIDFromName(Config.GetAppsDomain())
+		routeHost = random_name.BARARandomName("ROUTE")
+		routeGUID = CreateRoute(spaceGUID, domainGUID, routeHost)
+	})
+
+	Context("when a route has destinations in two apps", func() {
+		var appNames []string
+
+		BeforeEach(func() {
+			appNames = []string{random_name.BARARandomName("APP"), random_name.BARARandomName("APP")}
+			for _, appName := range appNames {
+				Expect(cf.Cf("push",
+					appName,
+					"-b", Config.GetGoBuildpackName(),
+					"-p", assets.NewAssets().Catnip,
+				).Wait(Config.CfPushTimeoutDuration())).To(Exit(0))
+			}
+		})
+
+		It("splits traffic between the apps by weight", func() {
+			appsByInstance := map[string]string{}
+			for _, appName := range appNames {
+				report := SendRequests(appName, "/id", 1)
+				Expect(report.Errors()).To(BeZero(), report.String())
+				appsByInstance[report.Results[0].Body] = appName
+			}
+
+			ReplaceDestinations(routeGUID, []Destination{
+				{App: App{GUID: GetAppGUID(appNames[0])}, Weight: 25},
+				{App: App{GUID: GetAppGUID(appNames[1])}, Weight: 75},
+			})
+			waitForRoute()
+
+			report := SendRequests(routeHost, "/id", weightedRequests)
+			Expect(report.Errors()).To(BeZero(), report.String())
+
+			observed := report.DistributionBy(func(instance string) string { return appsByInstance[instance] })
+			Expect(prober.CheckWeights(observed, map[string]int{appNames[0]: 25, appNames[1]: 75}, weightTolerance)).To(Succeed())
+		})
+	})
+
+	Context("when a route has destinations on two ports of a process", func() {
+		var appGUID string
+
+		BeforeEach(func() {
+			appName := random_name.BARARandomName("APP")
+			Expect(cf.Cf("push",
+				appName,
+				"-b", Config.GetGoBuildpackName(),
+				"-p", assets.NewAssets().MultiPortApp,
+				"-c", "multi-port-app --ports=8080,8081",
+				"-f", filepath.Join(assets.NewAssets().MultiPortApp, "manifest.yml"),
+			).Wait(Config.CfPushTimeoutDuration())).To(Exit(0))
+			appGUID = GetAppGUID(appName)
+		})
+
+		It("splits traffic between the ports by weight", func() {
+			web := &DestinationProcess{Type: "web"}
+			ReplaceDestinations(routeGUID, []Destination{
+				{App: App{GUID: appGUID, Process: web}, Port: 8080, Weight: 80},
+				{App: App{GUID: appGUID, Process: web}, Port: 8081, Weight: 20},
+			})
+			waitForRoute()
+
+			report := SendRequests(routeHost, "/", weightedRequests)
+			Expect(report.Errors()).To(BeZero(), report.String())
+
+			// multi-port-app answers with the port the request reached
+			observed := report.DistributionBy(func(body string) string {
+				for _, port := range []string{"8080", "8081"} {
+					if strings.Contains(body, port) {
+						return port
+					}
+				}
+				return body
+			})
+			Expect(prober.CheckWeights(observed, map[string]int{"8080": 80, "8081": 20}, weightTolerance)).To(Succeed())
+		})
+	})
+})
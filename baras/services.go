@@ -0,0 +1,194 @@
+package baras
+
+import (
+	"net/http"
+	"time"
+
+	. "github.com/cloudfoundry/capi-bara-tests/bara_suite_helpers"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/assets"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/broker"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/random_name"
+	. "github.com/cloudfoundry/capi-bara-tests/helpers/services"
+	. "github.com/cloudfoundry/capi-bara-tests/helpers/v3_helpers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = BaraDescribe(ServicesGroup, "services", func() {
+	var (
+		serviceBroker ServiceBroker
+		spaceGUID     string
+		offeringGUID  string
+	)
+
+	BeforeEach(func() {
+		spaceGUID = GetSpaceGuidFromName(TestSetup.RegularUserContext().Space)
+		domainGUID := GetDomainGUIDFromName(Config.GetAppsDomain())
+
+		By("Registering a Service Broker")
+		serviceBroker = NewServiceBroker(
+			random_name.BARARandomName("BRKR"),
+			spaceGUID,
+			domainGUID,
+			assets.NewAssets().ServiceBroker,
+			TestSetup,
+		)
+		serviceBroker.Push(Config)
+		serviceBroker.Configure()
+		serviceBroker.Create()
+		serviceBroker.PublicizePlans()
+		serviceBroker.ClearRequests()
+
+		offeringGUID = GetServiceOfferingByName(serviceBroker.Service.Name).GUID
+	})
+
+	AfterEach(func() {
+		serviceBroker.Destroy()
+	})
+
+	Describe("the requests Cloud Controller sends the broker", func() {
+		It("provisions and then binds, once each and on behalf of the user", func() {
+			plan := serviceBroker.SyncPlans[0]
+			instance := CreateManagedServiceInstance(ManagedServiceInstanceOptions{
+				Name:            random_name.BARARandomName("SVIN"),
+				SpaceGUID:       spaceGUID,
+				ServicePlanGUID: GetServicePlanByName(offeringGUID, plan.Name).GUID,
+				Parameters:      map[string]interface{}{"size": "small"},
+			})
+			appGUID := CreateApp(random_name.BARARandomName("APP"), spaceGUID, `{}`)
+			binding := BindServiceInstanceToApp(instance.GUID, appGUID, nil)
+
+			instancePath := "/v2/service_instances/" + instance.GUID
+			puts := serviceBroker.Requests().Matching(http.MethodPut, instancePath)
+			Expect(puts.Paths()).To(Equal([]string{
+				"PUT " + instancePath,
+				"PUT " + instancePath + "/service_bindings/" + binding.GUID,
+			}))
+
+			var provision struct {
+				ServiceID  string                 `json:"service_id"`
+				PlanID     string                 `json:"plan_id"`
+				SpaceGUID  string                 `json:"space_guid"`
+				Parameters map[string]interface{} `json:"parameters"`
+			}
+			Expect(puts[0].DecodeBody(&provision)).To(Succeed())
+			Expect(provision.ServiceID).To(Equal(serviceBroker.Service.ID))
+			Expect(provision.PlanID).To(Equal(plan.ID))
+			Expect(provision.SpaceGUID).To(Equal(spaceGUID))
+			Expect(provision.Parameters).To(Equal(map[string]interface{}{"size": "small"}))
+
+			var bind struct {
+				BindResource struct {
+					AppGUID string `json:"app_guid"`
+				} `json:"bind_resource"`
+			}
+			Expect(puts[1].DecodeBody(&bind)).To(Succeed())
+			Expect(bind.BindResource.AppGUID).To(Equal(appGUID))
+
+			for _, request := range puts {
+				Expect(request.APIVersion()).To(HavePrefix("2."))
+				identity, err := request.OriginatingIdentity()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(identity.Platform).To(Equal("cloudfoundry"))
+				Expect(identity.UserID).NotTo(BeEmpty())
+			}
+			provisioner, _ := puts[0].OriginatingIdentity()
+			binder, _ := puts[1].OriginatingIdentity()
+			Expect(binder.UserID).To(Equal(provisioner.UserID))
+		})
+
+		It("polls the last operation of an asynchronous provision until it finishes", func() {
+			plan := serviceBroker.AsyncPlans[0]
+			serviceBroker.Behaviours[plan.ID] = broker.Behaviour{Async: true, Delay: 10 * time.Second}
+			serviceBroker.Configure()
+
+			instance := CreateManagedServiceInstance(ManagedServiceInstanceOptions{
+				Name:            random_name.BARARandomName("SVIN"),
+				SpaceGUID:       spaceGUID,
+				ServicePlanGUID: GetServicePlanByName(offeringGUID, plan.Name).GUID,
+			})
+
+			instancePath := "/v2/service_instances/" + instance.GUID
+			requests := serviceBroker.Requests()
+			provisions := requests.Matching(http.MethodPut, instancePath)
+			Expect(provisions).To(HaveLen(1))
+			Expect(provisions[0].Query).To(ContainSubstring("accepts_incomplete=true"))
+
+			polls := requests.Matching(http.MethodGet, instancePath+"/last_operation")
+			Expect(polls).NotTo(BeEmpty())
+			for _, poll := range polls {
+				Expect(poll.Time).To(BeTemporally(">", provisions[0].Time))
+			}
+			Expect(polls[len(polls)-1].Time.Sub(provisions[0].Time)).To(BeNumerically(">=", 10*time.Second))
+		})
+	})
+
+	Describe("maintenance_info upgrades", func() {
+		var (
+			plan         Plan
+			instance     ServiceInstance
+			instancePath string
+		)
+
+		BeforeEach(func() {
+			plan = serviceBroker.SyncPlans[0]
+			serviceBroker.SetMaintenanceInfoVersion(plan.ID, "1.0.0")
+
+			instance = CreateManagedServiceInstance(ManagedServiceInstanceOptions{
+				Name:            random_name.BARARandomName("SVIN"),
+				SpaceGUID:       spaceGUID,
+				ServicePlanGUID: GetServicePlanByName(offeringGUID, plan.Name).GUID,
+			})
+			instancePath = "/v2/service_instances/" + instance.GUID
+			Expect(instance.MaintenanceInfoVersion).To(Equal("1.0.0"))
+			Expect(instance.UpgradeAvailable).To(BeFalse())
+
+			serviceBroker.SetMaintenanceInfoVersion(plan.ID, "2.0.0")
+			Expect(GetServiceInstance(instance.GUID).UpgradeAvailable).To(BeTrue())
+			serviceBroker.ClearRequests()
+		})
+
+		It("upgrades the instance, sending the broker the new maintenance_info", func() {
+			instance = UpgradeServiceInstance(instance.GUID)
+			Expect(instance.MaintenanceInfoVersion).To(Equal("2.0.0"))
+			Expect(instance.UpgradeAvailable).To(BeFalse())
+
+			updates := serviceBroker.Requests().Matching(http.MethodPatch, instancePath)
+			Expect(updates).To(HaveLen(1))
+
+			var update struct {
+				MaintenanceInfo broker.MaintenanceInfo `json:"maintenance_info"`
+				PreviousValues  struct {
+					MaintenanceInfo broker.MaintenanceInfo `json:"maintenance_info"`
+				} `json:"previous_values"`
+			}
+			Expect(updates[0].DecodeBody(&update)).To(Succeed())
+			Expect(update.MaintenanceInfo.Version).To(Equal("2.0.0"))
+			Expect(update.PreviousValues.MaintenanceInfo.Version).To(Equal("1.0.0"))
+		})
+
+		It("leaves the instance at its version when the broker rejects the upgrade", func() {
+			serviceBroker.Behaviours[plan.ID] = broker.Behaviour{
+				Failures: map[broker.Operation]broker.Failure{
+					broker.Update: {
+						Status:      http.StatusUnprocessableEntity,
+						Error:       "MaintenanceInfoConflict",
+						Description: "version 2.0.0 is not rolled out yet",
+					},
+				},
+			}
+			serviceBroker.Configure()
+
+			job := PollJobAsFailed(StartUpgradingServiceInstance(instance.GUID))
+			Expect(job.Errors).NotTo(BeEmpty())
+			Expect(job.Errors[0].Detail).To(ContainSubstring("version 2.0.0 is not rolled out yet"))
+
+			instance = GetServiceInstance(instance.GUID)
+			Expect(instance.MaintenanceInfoVersion).To(Equal("1.0.0"))
+			Expect(instance.UpgradeAvailable).To(BeTrue())
+			Expect(instance.LastOperation.Type).To(Equal("update"))
+			Expect(instance.LastOperation.State).To(Equal("failed"))
+			Expect(serviceBroker.Requests().Matching(http.MethodPatch, instancePath)).To(HaveLen(1))
+		})
+	})
+})
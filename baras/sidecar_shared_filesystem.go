@@ -16,7 +16,7 @@ import (
 	. "github.com/onsi/gomega/gexec"
 )
 
-var _ = Describe("sidecars", func() {
+var _ = BaraDescribe(SidecarsGroup, "sidecars", func() {
 	var (
 		appName    string
 		appGUID    string
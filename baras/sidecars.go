@@ -17,7 +17,7 @@ import (
 	. "github.com/onsi/gomega/gexec"
 )
 
-var _ = Describe("sidecars", func() {
+var _ = BaraDescribe(SidecarsGroup, "sidecars", func() {
 	var (
 		appName             string
 		appGUID             string
@@ -144,6 +144,9 @@ var _ = Describe("sidecars", func() {
 				Eventually(session).Should(Say("Hi, I'm Dora!"))
 				Eventually(session).Should(Exit(0))
 
+				webGUID := GetProcessGuidsForType(appGUID, "web")[0]
+				originalUptimes := InstanceUptimes(webGUID)
+
 				By("Crashing the sidecar process")
 				session = helpers.Curl(Config, fmt.Sprintf("%s.%s/sigterm/KILL", sidecarRoutePrefix1, Config.GetAppsDomain()))
 				Eventually(session).Should(Say("502"))
@@ -167,6 +170,11 @@ var _ = Describe("sidecars", func() {
 					Eventually(session).Should(Exit(0))
 					return session
 				}, Config.DefaultTimeoutDuration()).Should(Say("Hi, I'm Dora!"))
+
+				By("Checking that the instance was restarted")
+				WaitForInstancesInState(webGUID, "RUNNING", 1)
+				WaitForAllRoutable(webGUID)
+				Expect(RestartedInstances(originalUptimes, InstanceUptimes(webGUID))).To(Equal([]int{0}))
 			})
 		})
 
@@ -0,0 +1,168 @@
+package baras
+
+import (
+	. "github.com/cloudfoundry/capi-bara-tests/bara_suite_helpers"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/app_helpers"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/assets"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/random_name"
+	. "github.com/cloudfoundry/capi-bara-tests/helpers/v3_helpers"
+	"github.com/cloudfoundry/cf-test-helpers/v2/cf"
+	"github.com/cloudfoundry/cf-test-helpers/v2/workflowhelpers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gexec"
+)
+
+var _ = BaraDescribe(TasksGroup, "tasks", func() {
+	var (
+		appName string
+		appGUID string
+	)
+
+	pushCatnip := func() {
+		appName = random_name.BARARandomName("APP")
+		Expect(cf.Cf("push",
+			appName,
+			"-b", Config.GetGoBuildpackName(),
+			"-p", assets.NewAssets().CatnipZip,
+		).Wait(Config.CfPushTimeoutDuration())).To(Exit(0))
+		appGUID = GetAppGUID(appName)
+	}
+
+	Context("in the test space", func() {
+		BeforeEach(func() {
+			session := cf.Cf("target", "-o", TestSetup.RegularUserContext().Org, "-s", TestSetup.RegularUserContext().Space)
+			Eventually(session).Should(Exit(0))
+			pushCatnip()
+		})
+
+		AfterEach(func() {
+			FetchRecentLogs(appGUID, Config)
+			DeleteApp(appGUID)
+		})
+
+		It("runs the task with the requested limits and records its usage", func() {
+			lastUsageEventGUID := app_helpers.LastAppUsageEventGuid(TestSetup)
+
+			task := CreateTask(appGUID, TaskOptions{
+				Name:                         "greet",
+				Command:                      "echo hello",
+				MemoryInMB:                   256,
+				DiskInMB:                     512,
+				LogRateLimitInBytesPerSecond: 4096,
+			})
+			Expect(task.Name).To(Equal("greet"))
+			Expect(task.MemoryInMB).To(Equal(256))
+			Expect(task.DiskInMB).To(Equal(512))
+			Expect(task.LogRateLimitInBytesPerSecond).To(Equal(4096))
+			Expect(task.DropletGUID).To(Equal(GetDropletFromApp(appGUID)))
+
+			WaitForTaskToSucceed(task.GUID)
+			Expect(ListAppTasks(appGUID, "SUCCEEDED")).To(ContainElement(HaveField("GUID", task.GUID)))
+
+			taskEventStates := func() []string {
+				var states []string
+				for _, event := range app_helpers.UsageEventsAfterGuid(lastUsageEventGUID) {
+					if event.TaskGuid == task.GUID && event.ParentAppGuid == appGUID {
+						states = append(states, event.State)
+					}
+				}
+				return states
+			}
+			Eventually(taskEventStates).Should(ConsistOf("TASK_STARTED", "TASK_STOPPED"))
+		})
+
+		It("inherits the limits of a template process", func() {
+			ScaleProcess(appGUID, "web", "512")
+			webGUID := GetProcessGuidsForType(appGUID, "web")[0]
+
+			task := CreateTask(appGUID, TaskOptions{Command: "echo hello", TemplateProcessGUID: webGUID})
+			Expect(task.Command).To(Equal("echo hello"))
+			Expect(task.MemoryInMB).To(Equal(512))
+			WaitForTaskToSucceed(task.GUID)
+		})
+
+		It("reports why a task failed", func() {
+			task := CreateTask(appGUID, TaskOptions{Command: "exit 3"})
+			task = WaitForTaskToFail(task.GUID)
+			Expect(task.FailureReason).To(ContainSubstring("Exited with status 3"))
+		})
+
+		It("stops a canceled task", func() {
+			task := CreateTask(appGUID, TaskOptions{Command: "sleep 300"})
+			WaitForTaskState(task.GUID, "RUNNING")
+
+			CancelTask(task.GUID)
+			task = WaitForTaskToFail(task.GUID)
+			Expect(task.FailureReason).To(Equal("task was cancelled"))
+			Expect(ListAppTasks(appGUID, "RUNNING")).To(BeEmpty())
+		})
+
+		Context("when the app has a newer droplet", func() {
+			var catnipDropletGUID string
+
+			BeforeEach(func() {
+				catnipDropletGUID = GetDropletFromApp(appGUID)
+				doraDropletGUID := CreateAndAssociateNewDroplet(appGUID, assets.NewAssets().DoraZip, Config.GetRubyBuildpackName())
+				Expect(GetDropletFromApp(appGUID)).To(Equal(doraDropletGUID))
+			})
+
+			It("runs a task on the droplet it was given", func() {
+				// only dora has a config.ru
+				onCatnip := "test ! -f config.ru"
+
+				task := CreateTask(appGUID, TaskOptions{Command: onCatnip, DropletGUID: catnipDropletGUID})
+				Expect(task.DropletGUID).To(Equal(catnipDropletGUID))
+				WaitForTaskToSucceed(task.GUID)
+
+				task = CreateTask(appGUID, TaskOptions{Command: onCatnip})
+				Expect(task.DropletGUID).NotTo(Equal(catnipDropletGUID))
+				WaitForTaskToFail(task.GUID)
+			})
+		})
+	})
+
+	Context("with a per-app task quota", func() {
+		var (
+			orgName  string
+			orgQuota Quota
+		)
+
+		BeforeEach(func() {
+			orgName = random_name.BARARandomName("ORG")
+			spaceName := random_name.BARARandomName("SPACE")
+
+			workflowhelpers.AsUser(TestSetup.AdminUserContext(), Config.DefaultTimeoutDuration(), func() {
+				Eventually(cf.Cf("create-org", orgName)).Should(Exit(0))
+				orgQuota = CreateOrgTaskQuota(random_name.BARARandomName("ORG-QUOTA"), GetOrgGUIDFromName(orgName), 1)
+				Expect(orgQuota.Apps.PerAppTasks).To(Equal(1))
+
+				Eventually(cf.Cf("create-space", spaceName, "-o", orgName)).Should(Exit(0))
+				Eventually(cf.Cf("target", "-o", orgName, "-s", spaceName)).Should(Exit(0))
+				pushCatnip()
+			})
+		})
+
+		AfterEach(func() {
+			workflowhelpers.AsUser(TestSetup.AdminUserContext(), Config.DefaultTimeoutDuration(), func() {
+				Eventually(cf.Cf("delete-org", orgName, "-f")).Should(Exit(0))
+				DeleteOrgQuota(orgQuota.GUID)
+			})
+		})
+
+		It("refuses more running tasks than the quota allows", func() {
+			workflowhelpers.AsUser(TestSetup.AdminUserContext(), Config.DefaultTimeoutDuration(), func() {
+				running := CreateTask(appGUID, TaskOptions{Command: "sleep 300"})
+				WaitForTaskState(running.GUID, "RUNNING")
+
+				Expect(CreateTaskExpectingError(appGUID, TaskOptions{Command: "echo hello"})).To(ContainSubstring("app_task_limit_exceeded"))
+
+				CancelTask(running.GUID)
+				WaitForTaskToFail(running.GUID)
+
+				task := CreateTask(appGUID, TaskOptions{Command: "echo hello"})
+				WaitForTaskToSucceed(task.GUID)
+			})
+		})
+	})
+})
@@ -8,6 +8,7 @@ import (
 
 	. "github.com/cloudfoundry/capi-bara-tests/bara_suite_helpers"
 	"github.com/cloudfoundry/capi-bara-tests/helpers/assets"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/prober"
 	"github.com/cloudfoundry/capi-bara-tests/helpers/random_name"
 	. "github.com/cloudfoundry/capi-bara-tests/helpers/v3_helpers"
 	"github.com/cloudfoundry/cf-test-helpers/v2/cf"
@@ -18,7 +19,11 @@ import (
 	. "github.com/onsi/gomega/gexec"
 )
 
-var _ = Describe("Zero downtime operations", func() {
+// probeInterval is how often specs request an app while checking that an
+// operation causes no downtime.
+const probeInterval = 50 * time.Millisecond
+
+var _ = BaraDescribe(ZeroDowntimeGroup, "Zero downtime operations", func() {
 	var (
 		appName string
 		appGUID string
@@ -51,6 +56,11 @@ var _ = Describe("Zero downtime operations", func() {
 			Expect(err).ToNot(HaveOccurred())
 			Expect(originalUptime.Seconds()).To(BeNumerically(">", 0))
 
+			webGUID := GetProcessGuidsForType(appGUID, "web")[0]
+			originalInstanceUptimes := InstanceUptimes(webGUID)
+			probe := ProbeApp(appName, "/", probeInterval)
+			DeferCleanup(probe.Stop)
+
 			ScaleProcess(appGUID, "web", "1500")
 
 			Consistently(func() float64 {
@@ -58,6 +68,10 @@ var _ = Describe("Zero downtime operations", func() {
 				Expect(err).ToNot(HaveOccurred())
 				return currentUptime.Seconds()
 			}, Config.CcClockCycleDuration(), "1s").Should(BeNumerically(">", originalUptime.Seconds()))
+			Expect(RestartedInstances(originalInstanceUptimes, InstanceUptimes(webGUID))).To(BeEmpty())
+
+			report := probe.Stop()
+			Expect(report.Errors()).To(BeZero(), report.String())
 		})
 	})
 
@@ -89,6 +103,7 @@ var _ = Describe("Zero downtime operations", func() {
 			Expect(originalUptime.Seconds()).To(BeNumerically(">", 0))
 
 			process := GetFirstProcessByType(GetProcesses(appGUID, appName), "web")
+			originalInstanceUptimes := InstanceUptimes(process.Guid)
 			path := fmt.Sprintf("v3/processes/%s", process.Guid)
 			session := cf.Cf("curl", "-X", "PATCH", path, "-d", `{"health_check": {"type": "process"}}`).Wait()
 			Eventually(session).Should(Exit(0))
@@ -100,6 +115,7 @@ var _ = Describe("Zero downtime operations", func() {
 				Expect(err).ToNot(HaveOccurred())
 				return currentUptime.Seconds()
 			}, Config.CcClockCycleDuration(), "1s").Should(BeNumerically(">", originalUptime.Seconds()))
+			Expect(RestartedInstances(originalInstanceUptimes, InstanceUptimes(process.Guid))).To(BeEmpty())
 		})
 	})
 
@@ -154,4 +170,51 @@ var _ = Describe("Zero downtime operations", func() {
 			}, Config.DefaultTimeoutDuration(), "5s").Should(ContainSubstring("8080"))
 		})
 	})
+
+	Context("When rolling out a deployment", func() {
+		var catnipName string
+
+		BeforeEach(func() {
+			catnipName = random_name.BARARandomName("APP")
+			Expect(cf.Cf("push",
+				catnipName,
+				"-b", Config.GetGoBuildpackName(),
+				"-p", assets.NewAssets().Catnip,
+				"-i", "2",
+			).Wait(Config.CfPushTimeoutDuration())).To(Exit(0))
+		})
+
+		It("serves every request from the old or new instances", func() {
+			catnipGUID := GetAppGUID(catnipName)
+			oldProbe := ProbeApp(catnipName, "/id", probeInterval)
+			Eventually(func() map[string]int {
+				return oldProbe.Report().Distribution()
+			}).Should(HaveLen(2))
+			oldInstances := oldProbe.Stop().Distribution()
+
+			newInstances := func(report prober.Report) map[string]int {
+				distribution := map[string]int{}
+				for instance, responses := range report.Distribution() {
+					if _, old := oldInstances[instance]; !old {
+						distribution[instance] = responses
+					}
+				}
+				return distribution
+			}
+
+			probe := ProbeApp(catnipName, "/id", probeInterval)
+			DeferCleanup(probe.Stop)
+
+			deploymentGUID := CreateDeployment(catnipGUID)
+			WaitUntilDeploymentReachesStatus(deploymentGUID, "FINALIZED", "DEPLOYED")
+			WaitForAllRoutable(GetProcessGuidsForType(catnipGUID, "web")[0])
+			Eventually(func() map[string]int {
+				return newInstances(probe.Report())
+			}).Should(And(HaveLen(2), HaveEach(BeNumerically(">=", 5))))
+
+			report := probe.Stop()
+			Expect(report.Errors()).To(BeZero(), report.String())
+			Expect(report.MaxGap()).To(BeNumerically("<", 2*time.Second), report.String())
+		})
+	})
 })
@@ -0,0 +1,27 @@
+// Command bara-config prints the effective BARA config, merged from the
+// files listed in $CONFIG and overridden by BARA_* environment variables,
+// with secrets redacted.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/cloudfoundry/capi-bara-tests/helpers/config"
+)
+
+func main() {
+	cfg, err := config.NewBaraConfig(os.Getenv("CONFIG"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration:\n%s\n", err)
+		os.Exit(1)
+	}
+
+	redacted, err := cfg.RedactedJSON()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	fmt.Println(string(redacted))
+}
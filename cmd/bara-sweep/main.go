@@ -0,0 +1,101 @@
+// Command bara-sweep deletes the orgs, spaces, quotas, service brokers and
+// buildpacks that aborted BARA runs left behind: those named with the name
+// prefix of the config in $CONFIG and older than -min-age. It signs in as
+// the config's admin user unless the config sets auth_token.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry/capi-bara-tests/helpers/capi_client"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/config"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/sweeper"
+)
+
+func main() {
+	minAge := flag.Duration("min-age", 24*time.Hour, "only sweep resources at least this old")
+	dryRun := flag.Bool("dry-run", false, "list what would be deleted without deleting it")
+	flag.Parse()
+
+	cfg, err := config.NewBaraConfig(os.Getenv("CONFIG"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration:\n%s\n", err)
+		os.Exit(1)
+	}
+
+	token, err := adminToken(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to sign in as the admin user:", err)
+		os.Exit(1)
+	}
+
+	s := sweeper.Sweeper{
+		Client:             capi_client.NewClientFromConfig(cfg, func() string { return token }),
+		Prefix:             cfg.GetNamePrefix(),
+		MinAge:             *minAge,
+		DryRun:             *dryRun,
+		JobTimeout:         cfg.AsyncServiceOperationTimeoutDuration(),
+		JobPollingInterval: time.Second,
+	}
+
+	fmt.Printf("Sweeping resources named %s-* older than %s from %s\n", s.Prefix, s.MinAge, cfg.GetApiEndpoint())
+	report, err := s.Sweep()
+	report.WriteSummary(os.Stdout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if report.Failed() > 0 {
+		os.Exit(1)
+	}
+}
+
+// adminToken returns the config's auth_token, or else signs in with the cf
+// CLI in a throwaway CF_HOME so the user's own session is left alone.
+func adminToken(cfg config.BaraConfig) (string, error) {
+	if token := cfg.GetAuthToken(); token != "" {
+		return token, nil
+	}
+
+	cfHome, err := os.MkdirTemp("", "bara-sweep")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(cfHome)
+
+	cf := func(args ...string) ([]byte, error) {
+		cmd := exec.Command("cf", args...)
+		cmd.Env = append(os.Environ(),
+			"CF_HOME="+cfHome,
+			"CF_USERNAME="+cfg.GetAdminUser(),
+			"CF_PASSWORD="+cfg.GetAdminPassword(),
+		)
+		output, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("cf %s: %s", args[0], err)
+		}
+		return output, nil
+	}
+
+	apiArgs := []string{"api", cfg.Protocol() + cfg.GetApiEndpoint()}
+	if cfg.GetSkipSSLValidation() {
+		apiArgs = append(apiArgs, "--skip-ssl-validation")
+	}
+	if _, err := cf(apiArgs...); err != nil {
+		return "", err
+	}
+	if _, err := cf("auth"); err != nil {
+		return "", err
+	}
+
+	token, err := cf("oauth-token")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(token)), nil
+}
@@ -3,6 +3,9 @@ package app_helpers
 import (
 	"github.com/cloudfoundry/cf-test-helpers/v2/workflowhelpers"
 	. "github.com/cloudfoundry/capi-bara-tests/bara_suite_helpers"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/capi_client"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/v3_helpers"
+	. "github.com/onsi/gomega"
 )
 
 type Entity struct {
@@ -26,11 +29,6 @@ type AppUsageEvent struct {
 	Metadata `json:"metadata"`
 }
 
-type AppUsageEvents struct {
-	Resources []AppUsageEvent `struct:"resources"`
-	NextUrl   string          `json:"next_url"`
-}
-
 func UsageEventsInclude(events []AppUsageEvent, event AppUsageEvent) bool {
 	found := false
 	for _, e := range events {
@@ -48,13 +46,16 @@ func UsageEventsInclude(events []AppUsageEvent, event AppUsageEvent) bool {
 }
 
 func LastAppUsageEventGuid(testSetup *workflowhelpers.ReproducibleTestSuiteSetup) string {
-	var response AppUsageEvents
+	var guid string
 
-	workflowhelpers.AsUser(TestSetup.AdminUserContext(), Config.DefaultTimeoutDuration(), func() {
-		workflowhelpers.ApiRequest("GET", "/v2/app_usage_events?results-per-page=1&order-direction=desc&page=1", &response, Config.DefaultTimeoutDuration())
+	workflowhelpers.AsUser(testSetup.AdminUserContext(), Config.DefaultTimeoutDuration(), func() {
+		events := v3_helpers.CAPIClient().AppUsageEvents(capi_client.ListOptions{PerPage: 1, OrderBy: "-created_at"})
+		Expect(events.Next()).To(BeTrue(), "expected at least one app usage event")
+		Expect(events.Err()).NotTo(HaveOccurred())
+		guid = events.Value().GUID
 	})
 
-	return response.Resources[0].Metadata.Guid
+	return guid
 }
 
 // Returns all app usage events that occured since the given app usage event guid
@@ -62,22 +63,31 @@ func UsageEventsAfterGuid(guid string) []AppUsageEvent {
 	resources := make([]AppUsageEvent, 0)
 
 	workflowhelpers.AsUser(TestSetup.AdminUserContext(), Config.DefaultTimeoutDuration(), func() {
-		firstPageUrl := "/v2/app_usage_events?results-per-page=150&order-direction=desc&page=1&after_guid=" + guid
-		url := firstPageUrl
-
-		for {
-			var response AppUsageEvents
-			workflowhelpers.ApiRequest("GET", url, &response, Config.DefaultTimeoutDuration())
-
-			resources = append(resources, response.Resources...)
+		options := capi_client.ListOptions{PerPage: 150}.Filter("after_guid", guid)
+		events, err := v3_helpers.CAPIClient().ListAppUsageEvents(options)
+		Expect(err).NotTo(HaveOccurred())
 
-			if len(response.Resources) == 0 || response.NextUrl == "" {
-				break
-			}
-
-			url = response.NextUrl
+		for _, event := range events {
+			resources = append(resources, newAppUsageEvent(event))
 		}
 	})
 
 	return resources
 }
+
+func newAppUsageEvent(event capi_client.AppUsageEvent) AppUsageEvent {
+	return AppUsageEvent{
+		Entity: Entity{
+			AppName:       event.App.Name,
+			AppGuid:       event.Process.GUID,
+			State:         event.State.Current,
+			BuildpackName: event.Buildpack.Name,
+			BuildpackGuid: event.Buildpack.GUID,
+			ParentAppName: event.App.Name,
+			ParentAppGuid: event.App.GUID,
+			ProcessType:   event.Process.Type,
+			TaskGuid:      event.Task.GUID,
+		},
+		Metadata: Metadata{Guid: event.GUID},
+	}
+}
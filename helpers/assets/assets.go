@@ -88,7 +88,7 @@ func NewAssets() Assets {
 		PythonWithoutProcfileZip:   "assets/python-without-procfile.zip",
 		RubySimple:                 "assets/ruby_simple",
 		SecurityGroupBuildpack:     "assets/security_group_buildpack.zip",
-		ServiceBroker:              "assets/service_broker",
+		ServiceBroker:              "assets/service-broker",
 		SidecarDependent:           "assets/sidecar-dependent",
 		SleepySidecarBuildpack:     "assets/sleepy-sidecar-buildpack",
 		SleepySidecarBuildpackZip:  "assets/sleepy-sidecar-buildpack.zip",
@@ -0,0 +1,791 @@
+// Package broker is a service broker implementing Open Service Broker API
+// 2.15 whose behaviour per plan is scripted from Go. It serves in-process,
+// e.g. behind httptest.NewServer, or pushed as an app from
+// assets/service-broker, in which case specs script it through PUT /config
+// and read the journal of requests it received from GET /requests.
+package broker
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Config is everything a spec scripts about the broker.
+type Config struct {
+	Catalog Catalog `json:"catalog"`
+	// Behaviours are keyed by plan ID. Plans without one are synchronous
+	// and succeed straight away.
+	Behaviours map[string]Behaviour `json:"behaviours,omitempty"`
+}
+
+type Behaviour struct {
+	// Async plans accept operations with 202 Accepted and report on them
+	// from last_operation.
+	Async bool `json:"async"`
+	// Delay is how long operations take: synchronous responses are held
+	// back for it and asynchronous operations stay in progress until it has
+	// passed. In JSON it is a duration string such as "10s".
+	Delay time.Duration `json:"delay"`
+	// Failures are keyed by the operation that fails.
+	Failures map[Operation]Failure `json:"failures,omitempty"`
+
+	// Credentials are returned from app bindings and service keys.
+	Credentials map[string]interface{} `json:"credentials,omitempty"`
+	// VolumeMounts and SyslogDrainURL are returned from app bindings.
+	VolumeMounts   []VolumeMount `json:"volume_mounts,omitempty"`
+	SyslogDrainURL string        `json:"syslog_drain_url,omitempty"`
+	// RouteServiceURL is returned from route bindings.
+	RouteServiceURL string `json:"route_service_url,omitempty"`
+	DashboardURL    string `json:"dashboard_url,omitempty"`
+}
+
+// MarshalJSON writes Delay as a duration string rather than nanoseconds,
+// so that behaviours read and script naturally through /config.
+func (b Behaviour) MarshalJSON() ([]byte, error) {
+	type behaviour Behaviour
+	encoded := struct {
+		behaviour
+		Delay string `json:"delay,omitempty"`
+	}{behaviour: behaviour(b)}
+	if b.Delay != 0 {
+		encoded.Delay = b.Delay.String()
+	}
+	return json.Marshal(encoded)
+}
+
+func (b *Behaviour) UnmarshalJSON(data []byte) error {
+	type behaviour Behaviour
+	decoded := struct {
+		*behaviour
+		Delay string `json:"delay"`
+	}{behaviour: (*behaviour)(b)}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	b.Delay = 0
+	if decoded.Delay != "" {
+		delay, err := time.ParseDuration(decoded.Delay)
+		if err != nil {
+			return fmt.Errorf("delay: %w", err)
+		}
+		b.Delay = delay
+	}
+	return nil
+}
+
+type Operation string
+
+const (
+	Provision   Operation = "provision"
+	Update      Operation = "update"
+	Deprovision Operation = "deprovision"
+	Bind        Operation = "bind"
+	Unbind      Operation = "unbind"
+)
+
+// Failure makes an operation fail. With a Status the broker rejects the
+// request with it. Otherwise synchronous operations are rejected with
+// 400 Bad Request, and asynchronous ones are accepted and fail when they
+// finish, with last_operation returning the Description.
+type Failure struct {
+	Status      int    `json:"status,omitempty"`
+	Error       string `json:"error,omitempty"`
+	Description string `json:"description"`
+}
+
+// Broker keeps its instances and bindings in memory.
+type Broker struct {
+	username string
+	password string
+	router   *mux.Router
+
+	mu         sync.Mutex
+	config     Config
+	instances  map[string]*instance
+	bindings   map[string]*binding
+	creating   map[string]chan struct{}
+	operations int
+	journal    Journal
+}
+
+type instance struct {
+	created         attributes
+	serviceID       string
+	planID          string
+	parameters      map[string]interface{}
+	maintenanceInfo *MaintenanceInfo
+	dashboardURL    string
+	operation       *operation
+}
+
+type binding struct {
+	created    attributes
+	instanceID string
+	parameters map[string]interface{}
+	response   map[string]interface{}
+	operation  *operation
+}
+
+// attributes are what a repeated create must match to be answered as a
+// repeat rather than with 409 Conflict.
+type attributes struct {
+	ServiceID  string
+	PlanID     string
+	AppGUID    string
+	Route      string
+	Parameters map[string]interface{}
+}
+
+func (a attributes) equal(other attributes) bool {
+	a.Parameters, other.Parameters = nonNil(a.Parameters), nonNil(other.Parameters)
+	return reflect.DeepEqual(a, other)
+}
+
+// operation is an asynchronous operation, which takes effect the first
+// time it is seen to have finished without failing.
+type operation struct {
+	id       string
+	kind     Operation
+	finishAt time.Time
+	failure  *Failure
+	succeed  func()
+	settled  bool
+}
+
+// New returns a broker with an empty catalog, which Cloud Controller
+// authenticates with using basic auth.
+func New(username, password string) *Broker {
+	b := &Broker{
+		username:  username,
+		password:  password,
+		router:    mux.NewRouter(),
+		instances: map[string]*instance{},
+		bindings:  map[string]*binding{},
+		creating:  map[string]chan struct{}{},
+	}
+	b.router.Use(b.authenticate)
+
+	b.router.HandleFunc("/config", b.getConfig).Methods(http.MethodGet)
+	b.router.HandleFunc("/config", b.putConfig).Methods(http.MethodPut)
+	b.router.HandleFunc("/requests", b.getRequests).Methods(http.MethodGet)
+	b.router.HandleFunc("/requests", b.deleteRequests).Methods(http.MethodDelete)
+
+	v2 := b.router.PathPrefix("/v2").Subrouter()
+	v2.Use(checkAPIVersion)
+	v2.HandleFunc("/catalog", b.getCatalog).Methods(http.MethodGet)
+	v2.HandleFunc("/service_instances/{instance_id}", b.provision).Methods(http.MethodPut)
+	v2.HandleFunc("/service_instances/{instance_id}", b.update).Methods(http.MethodPatch)
+	v2.HandleFunc("/service_instances/{instance_id}", b.deprovision).Methods(http.MethodDelete)
+	v2.HandleFunc("/service_instances/{instance_id}", b.getInstance).Methods(http.MethodGet)
+	v2.HandleFunc("/service_instances/{instance_id}/last_operation", b.getInstanceLastOperation).Methods(http.MethodGet)
+	v2.HandleFunc("/service_instances/{instance_id}/service_bindings/{binding_id}", b.bind).Methods(http.MethodPut)
+	v2.HandleFunc("/service_instances/{instance_id}/service_bindings/{binding_id}", b.unbind).Methods(http.MethodDelete)
+	v2.HandleFunc("/service_instances/{instance_id}/service_bindings/{binding_id}", b.getBinding).Methods(http.MethodGet)
+	v2.HandleFunc("/service_instances/{instance_id}/service_bindings/{binding_id}/last_operation", b.getBindingLastOperation).Methods(http.MethodGet)
+
+	return b
+}
+
+func (b *Broker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b.record(r)
+	b.router.ServeHTTP(w, r)
+}
+
+// Configure replaces the catalog and behaviours. Existing instances and
+// bindings are kept.
+func (b *Broker) Configure(config Config) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.config = config
+}
+
+func (b *Broker) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok || username != b.username || password != b.password {
+			w.Header().Set("WWW-Authenticate", `Basic realm="broker"`)
+			writeError(w, http.StatusUnauthorized, "", "invalid credentials")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func checkAPIVersion(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if version := r.Header.Get("X-Broker-API-Version"); !strings.HasPrefix(version, "2.") {
+			writeError(w, http.StatusPreconditionFailed, "", fmt.Sprintf("X-Broker-API-Version must be 2.x, not %q", version))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (b *Broker) getConfig(w http.ResponseWriter, r *http.Request) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	writeJSON(w, http.StatusOK, b.config)
+}
+
+func (b *Broker) putConfig(w http.ResponseWriter, r *http.Request) {
+	var config Config
+	if !decode(w, r, &config) {
+		return
+	}
+	b.Configure(config)
+	writeJSON(w, http.StatusOK, config)
+}
+
+func (b *Broker) getCatalog(w http.ResponseWriter, r *http.Request) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	writeJSON(w, http.StatusOK, b.config.Catalog)
+}
+
+func (b *Broker) provision(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		ServiceID       string                 `json:"service_id"`
+		PlanID          string                 `json:"plan_id"`
+		Parameters      map[string]interface{} `json:"parameters"`
+		MaintenanceInfo *MaintenanceInfo       `json:"maintenance_info"`
+	}
+	if !decode(w, r, &request) {
+		return
+	}
+	instanceID := mux.Vars(r)["instance_id"]
+
+	b.mu.Lock()
+	plan, ok := b.config.Catalog.findPlan(request.ServiceID, request.PlanID)
+	if !ok {
+		b.mu.Unlock()
+		writeError(w, http.StatusBadRequest, "", fmt.Sprintf("plan %s of service %s is not in the catalog", request.PlanID, request.ServiceID))
+		return
+	}
+	requested := attributes{ServiceID: request.ServiceID, PlanID: request.PlanID, Parameters: request.Parameters}
+	for {
+		if existing, ok := b.instances[instanceID]; ok && !b.createFailed(existing.operation, Provision) {
+			status := b.repeatStatus(existing.created, requested, existing.operation)
+			op, body := existing.operation, map[string]interface{}{"dashboard_url": existing.dashboardURL}
+			b.mu.Unlock()
+			writeRepeat(w, status, op, body, fmt.Sprintf("instance %s already exists with other attributes", instanceID))
+			return
+		}
+		if b.claim(r) {
+			break
+		}
+		if r.Context().Err() != nil {
+			b.mu.Unlock()
+			return
+		}
+	}
+	defer b.release(r)
+	behaviour := b.config.Behaviours[plan.ID]
+	b.mu.Unlock()
+
+	if !checkMaintenanceInfo(w, plan, request.MaintenanceInfo) || !checkAsync(w, r, behaviour) {
+		return
+	}
+
+	inst := &instance{
+		created:         requested,
+		serviceID:       request.ServiceID,
+		planID:          request.PlanID,
+		parameters:      request.Parameters,
+		maintenanceInfo: request.MaintenanceInfo,
+		dashboardURL:    behaviour.DashboardURL,
+	}
+	b.perform(w, r, behaviour, Provision, http.StatusCreated, map[string]interface{}{"dashboard_url": inst.dashboardURL},
+		func(op *operation) {
+			inst.operation = op
+			b.instances[instanceID] = inst
+		},
+		func() { b.instances[instanceID] = inst },
+	)
+}
+
+func (b *Broker) update(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		ServiceID       string                 `json:"service_id"`
+		PlanID          string                 `json:"plan_id"`
+		Parameters      map[string]interface{} `json:"parameters"`
+		MaintenanceInfo *MaintenanceInfo       `json:"maintenance_info"`
+	}
+	if !decode(w, r, &request) {
+		return
+	}
+
+	b.mu.Lock()
+	inst, ok := b.findInstance(w, r)
+	if !ok {
+		b.mu.Unlock()
+		return
+	}
+	if b.inProgress(inst.operation) {
+		b.mu.Unlock()
+		writeConcurrencyError(w)
+		return
+	}
+	planID := request.PlanID
+	if planID == "" {
+		planID = inst.planID
+	}
+	plan, ok := b.config.Catalog.findPlan(inst.serviceID, planID)
+	if !ok {
+		b.mu.Unlock()
+		writeError(w, http.StatusBadRequest, "", fmt.Sprintf("plan %s of service %s is not in the catalog", planID, inst.serviceID))
+		return
+	}
+	behaviour := b.config.Behaviours[plan.ID]
+	b.mu.Unlock()
+
+	if !checkMaintenanceInfo(w, plan, request.MaintenanceInfo) || !checkAsync(w, r, behaviour) {
+		return
+	}
+
+	b.perform(w, r, behaviour, Update, http.StatusOK, map[string]interface{}{"dashboard_url": behaviour.DashboardURL},
+		func(op *operation) { inst.operation = op },
+		func() {
+			inst.planID = planID
+			inst.dashboardURL = behaviour.DashboardURL
+			if request.Parameters != nil {
+				inst.parameters = request.Parameters
+			}
+			if request.MaintenanceInfo != nil {
+				inst.maintenanceInfo = request.MaintenanceInfo
+			}
+		},
+	)
+}
+
+func (b *Broker) deprovision(w http.ResponseWriter, r *http.Request) {
+	instanceID := mux.Vars(r)["instance_id"]
+
+	b.mu.Lock()
+	inst, ok := b.instances[instanceID]
+	if !ok {
+		b.mu.Unlock()
+		writeJSON(w, http.StatusGone, map[string]interface{}{})
+		return
+	}
+	if b.inProgress(inst.operation) {
+		b.mu.Unlock()
+		writeConcurrencyError(w)
+		return
+	}
+	behaviour := b.config.Behaviours[inst.planID]
+	b.mu.Unlock()
+
+	if !checkAsync(w, r, behaviour) {
+		return
+	}
+
+	b.perform(w, r, behaviour, Deprovision, http.StatusOK, map[string]interface{}{},
+		func(op *operation) { inst.operation = op },
+		func() {
+			delete(b.instances, instanceID)
+			for bindingID, bnd := range b.bindings {
+				if bnd.instanceID == instanceID {
+					delete(b.bindings, bindingID)
+				}
+			}
+		},
+	)
+}
+
+func (b *Broker) getInstance(w http.ResponseWriter, r *http.Request) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	inst, ok := b.instances[mux.Vars(r)["instance_id"]]
+	if !ok || (inst.operation != nil && inst.operation.kind == Provision && !b.succeeded(inst.operation)) {
+		writeError(w, http.StatusNotFound, "", "instance does not exist")
+		return
+	}
+	if b.inProgress(inst.operation) {
+		writeConcurrencyError(w)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"service_id":       inst.serviceID,
+		"plan_id":          inst.planID,
+		"dashboard_url":    inst.dashboardURL,
+		"parameters":       nonNil(inst.parameters),
+		"maintenance_info": inst.maintenanceInfo,
+	})
+}
+
+func (b *Broker) getInstanceLastOperation(w http.ResponseWriter, r *http.Request) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	inst, ok := b.instances[mux.Vars(r)["instance_id"]]
+	if !ok {
+		writeJSON(w, http.StatusGone, map[string]interface{}{})
+		return
+	}
+	b.writeLastOperation(w, inst.operation)
+}
+
+func (b *Broker) bind(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		ServiceID    string `json:"service_id"`
+		PlanID       string `json:"plan_id"`
+		BindResource struct {
+			AppGUID string `json:"app_guid"`
+			Route   string `json:"route"`
+		} `json:"bind_resource"`
+		Parameters map[string]interface{} `json:"parameters"`
+	}
+	if !decode(w, r, &request) {
+		return
+	}
+	instanceID := mux.Vars(r)["instance_id"]
+	bindingID := mux.Vars(r)["binding_id"]
+
+	requested := attributes{
+		ServiceID:  request.ServiceID,
+		PlanID:     request.PlanID,
+		AppGUID:    request.BindResource.AppGUID,
+		Route:      request.BindResource.Route,
+		Parameters: request.Parameters,
+	}
+
+	b.mu.Lock()
+	var inst *instance
+	for {
+		var ok bool
+		inst, ok = b.findInstance(w, r)
+		if !ok {
+			b.mu.Unlock()
+			return
+		}
+		if b.inProgress(inst.operation) {
+			b.mu.Unlock()
+			writeConcurrencyError(w)
+			return
+		}
+		if existing, ok := b.bindings[bindingID]; ok && !b.createFailed(existing.operation, Bind) {
+			status := b.repeatStatus(existing.created, requested, existing.operation)
+			op := existing.operation
+			b.mu.Unlock()
+			writeRepeat(w, status, op, existing.response, fmt.Sprintf("binding %s already exists with other attributes", bindingID))
+			return
+		}
+		if b.claim(r) {
+			break
+		}
+		if r.Context().Err() != nil {
+			b.mu.Unlock()
+			return
+		}
+	}
+	defer b.release(r)
+	behaviour := b.config.Behaviours[inst.planID]
+	b.mu.Unlock()
+
+	if !checkAsync(w, r, behaviour) {
+		return
+	}
+
+	response := map[string]interface{}{}
+	switch {
+	case request.BindResource.Route != "":
+		if behaviour.RouteServiceURL != "" {
+			response["route_service_url"] = behaviour.RouteServiceURL
+		}
+	default:
+		response["credentials"] = nonNil(behaviour.Credentials)
+		if request.BindResource.AppGUID != "" {
+			if behaviour.SyslogDrainURL != "" {
+				response["syslog_drain_url"] = behaviour.SyslogDrainURL
+			}
+			if len(behaviour.VolumeMounts) > 0 {
+				response["volume_mounts"] = behaviour.VolumeMounts
+			}
+		}
+	}
+
+	bnd := &binding{created: requested, instanceID: instanceID, parameters: request.Parameters, response: response}
+	b.perform(w, r, behaviour, Bind, http.StatusCreated, response,
+		func(op *operation) {
+			bnd.operation = op
+			b.bindings[bindingID] = bnd
+		},
+		func() { b.bindings[bindingID] = bnd },
+	)
+}
+
+func (b *Broker) unbind(w http.ResponseWriter, r *http.Request) {
+	bindingID := mux.Vars(r)["binding_id"]
+
+	b.mu.Lock()
+	bnd, ok := b.bindings[bindingID]
+	if !ok {
+		b.mu.Unlock()
+		writeJSON(w, http.StatusGone, map[string]interface{}{})
+		return
+	}
+	if b.inProgress(bnd.operation) {
+		b.mu.Unlock()
+		writeConcurrencyError(w)
+		return
+	}
+	var behaviour Behaviour
+	if inst, ok := b.instances[bnd.instanceID]; ok {
+		behaviour = b.config.Behaviours[inst.planID]
+	}
+	b.mu.Unlock()
+
+	if !checkAsync(w, r, behaviour) {
+		return
+	}
+
+	b.perform(w, r, behaviour, Unbind, http.StatusOK, map[string]interface{}{},
+		func(op *operation) { bnd.operation = op },
+		func() { delete(b.bindings, bindingID) },
+	)
+}
+
+func (b *Broker) getBinding(w http.ResponseWriter, r *http.Request) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	bnd, ok := b.bindings[mux.Vars(r)["binding_id"]]
+	if !ok || (bnd.operation != nil && bnd.operation.kind == Bind && !b.succeeded(bnd.operation)) {
+		writeError(w, http.StatusNotFound, "", "binding does not exist")
+		return
+	}
+
+	response := map[string]interface{}{"parameters": nonNil(bnd.parameters)}
+	for key, value := range bnd.response {
+		response[key] = value
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (b *Broker) getBindingLastOperation(w http.ResponseWriter, r *http.Request) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	bnd, ok := b.bindings[mux.Vars(r)["binding_id"]]
+	if !ok {
+		writeJSON(w, http.StatusGone, map[string]interface{}{})
+		return
+	}
+	b.writeLastOperation(w, bnd.operation)
+}
+
+// perform carries out an operation the way the plan's behaviour says, with
+// b.mu unlocked. Synchronous operations call succeed before responding with
+// status and body; asynchronous ones are handed to track and respond with
+// 202 Accepted, calling succeed once they are seen to have finished.
+func (b *Broker) perform(w http.ResponseWriter, r *http.Request, behaviour Behaviour, kind Operation, status int, body map[string]interface{}, track func(*operation), succeed func()) {
+	failure, failing := behaviour.Failures[kind]
+	if failing && failure.Status != 0 {
+		writeError(w, failure.Status, failure.Error, failure.Description)
+		return
+	}
+
+	if !behaviour.Async {
+		select {
+		case <-time.After(behaviour.Delay):
+		case <-r.Context().Done():
+			return
+		}
+		if failing {
+			writeError(w, http.StatusBadRequest, failure.Error, failure.Description)
+			return
+		}
+		b.mu.Lock()
+		succeed()
+		b.mu.Unlock()
+		writeJSON(w, status, body)
+		return
+	}
+
+	b.mu.Lock()
+	b.operations++
+	op := &operation{
+		id:       fmt.Sprintf("%s-%d", kind, b.operations),
+		kind:     kind,
+		finishAt: time.Now().Add(behaviour.Delay),
+		succeed:  succeed,
+	}
+	if failing {
+		op.failure = &failure
+	}
+	track(op)
+	b.mu.Unlock()
+
+	accepted := map[string]interface{}{"operation": op.id}
+	if dashboardURL, ok := body["dashboard_url"]; ok {
+		accepted["dashboard_url"] = dashboardURL
+	}
+	writeJSON(w, http.StatusAccepted, accepted)
+}
+
+// claim marks the instance or binding at the request's path as being created
+// until release, by which time it has been stored if it is going to be. If
+// another request is creating it already, claim waits for that to finish,
+// with mu released, and returns false for the caller to look again, so that
+// a concurrent create is answered as a repeat rather than also succeeding.
+// It must be called with mu held.
+func (b *Broker) claim(r *http.Request) bool {
+	if done, ok := b.creating[r.URL.Path]; ok {
+		b.mu.Unlock()
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+		b.mu.Lock()
+		return false
+	}
+	b.creating[r.URL.Path] = make(chan struct{})
+	return true
+}
+
+func (b *Broker) release(r *http.Request) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	close(b.creating[r.URL.Path])
+	delete(b.creating, r.URL.Path)
+}
+
+// repeatStatus answers a create of an instance or binding that exists:
+// 409 Conflict if it was created with other attributes, 202 Accepted while
+// its asynchronous create is in progress and 200 OK once it has succeeded.
+// It must be called with mu held.
+func (b *Broker) repeatStatus(created, requested attributes, op *operation) int {
+	switch {
+	case !created.equal(requested):
+		return http.StatusConflict
+	case op != nil && (op.kind == Provision || op.kind == Bind) && b.inProgress(op):
+		return http.StatusAccepted
+	}
+	return http.StatusOK
+}
+
+// createFailed reports whether the resource's create failed, in which case
+// a repeated create starts over. It must be called with mu held.
+func (b *Broker) createFailed(op *operation, kind Operation) bool {
+	return op != nil && op.kind == kind && b.settle(op) == "failed"
+}
+
+func writeRepeat(w http.ResponseWriter, status int, op *operation, body map[string]interface{}, conflict string) {
+	switch status {
+	case http.StatusConflict:
+		writeError(w, status, "", conflict)
+	case http.StatusAccepted:
+		accepted := map[string]interface{}{"operation": op.id}
+		if dashboardURL, ok := body["dashboard_url"]; ok {
+			accepted["dashboard_url"] = dashboardURL
+		}
+		writeJSON(w, status, accepted)
+	default:
+		writeJSON(w, status, body)
+	}
+}
+
+// settle returns the state of the operation, making it take effect if it
+// has just finished. A nil operation was synchronous and has succeeded.
+func (b *Broker) settle(op *operation) string {
+	switch {
+	case op == nil:
+		return "succeeded"
+	case time.Now().Before(op.finishAt):
+		return "in progress"
+	case op.failure != nil:
+		return "failed"
+	}
+	if !op.settled {
+		op.settled = true
+		op.succeed()
+	}
+	return "succeeded"
+}
+
+func (b *Broker) inProgress(op *operation) bool {
+	return b.settle(op) == "in progress"
+}
+
+func (b *Broker) succeeded(op *operation) bool {
+	return b.settle(op) == "succeeded"
+}
+
+func (b *Broker) writeLastOperation(w http.ResponseWriter, op *operation) {
+	state := b.settle(op)
+	response := map[string]interface{}{"state": state}
+	if state == "failed" {
+		response["description"] = op.failure.Description
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (b *Broker) findInstance(w http.ResponseWriter, r *http.Request) (*instance, bool) {
+	inst, ok := b.instances[mux.Vars(r)["instance_id"]]
+	if !ok {
+		writeError(w, http.StatusUnprocessableEntity, "", fmt.Sprintf("instance %s does not exist", mux.Vars(r)["instance_id"]))
+	}
+	return inst, ok
+}
+
+// checkMaintenanceInfo rejects requests for another version of the plan
+// than the catalog's.
+func checkMaintenanceInfo(w http.ResponseWriter, plan Plan, requested *MaintenanceInfo) bool {
+	if requested == nil || (plan.MaintenanceInfo != nil && plan.MaintenanceInfo.Version == requested.Version) {
+		return true
+	}
+	writeError(w, http.StatusUnprocessableEntity, "MaintenanceInfoConflict",
+		fmt.Sprintf("maintenance_info.version %s does not match the catalog", requested.Version))
+	return false
+}
+
+func checkAsync(w http.ResponseWriter, r *http.Request, behaviour Behaviour) bool {
+	if !behaviour.Async || r.URL.Query().Get("accepts_incomplete") == "true" {
+		return true
+	}
+	writeError(w, http.StatusUnprocessableEntity, "AsyncRequired", "This plan requires accepts_incomplete=true")
+	return false
+}
+
+func writeConcurrencyError(w http.ResponseWriter) {
+	writeError(w, http.StatusUnprocessableEntity, "ConcurrencyError", "Another operation is in progress")
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, code, description string) {
+	body := map[string]string{"description": description}
+	if code != "" {
+		body["error"] = code
+	}
+	writeJSON(w, status, body)
+}
+
+func decode(w http.ResponseWriter, r *http.Request, body interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+		writeError(w, http.StatusBadRequest, "", "Request invalid due to parse error: "+err.Error())
+		return false
+	}
+	return true
+}
+
+func nonNil(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return map[string]interface{}{}
+	}
+	return m
+}
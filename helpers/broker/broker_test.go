@@ -0,0 +1,413 @@
+package broker_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/cloudfoundry/capi-bara-tests/helpers/broker"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type response struct {
+	Status int
+	Body   map[string]interface{}
+}
+
+var _ = Describe("Broker", func() {
+	var (
+		b      *broker.Broker
+		server *httptest.Server
+		config broker.Config
+	)
+
+	request := func(method, path string, body interface{}) response {
+		var encoded []byte
+		if body != nil {
+			var err error
+			encoded, err = json.Marshal(body)
+			Expect(err).NotTo(HaveOccurred())
+		}
+		req, err := http.NewRequest(method, server.URL+path, bytes.NewReader(encoded))
+		Expect(err).NotTo(HaveOccurred())
+		req.SetBasicAuth("username", "password")
+		req.Header.Set("X-Broker-API-Version", "2.15")
+
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close()
+
+		result := response{Status: resp.StatusCode}
+		Expect(json.NewDecoder(resp.Body).Decode(&result.Body)).To(Succeed())
+		return result
+	}
+
+	provision := func(instanceID, planID string, parameters map[string]interface{}) response {
+		return request(http.MethodPut, "/v2/service_instances/"+instanceID+"?accepts_incomplete=true", map[string]interface{}{
+			"service_id": "service-id",
+			"plan_id":    planID,
+			"parameters": parameters,
+		})
+	}
+
+	lastOperation := func(instanceID string) func() string {
+		return func() string {
+			return request(http.MethodGet, "/v2/service_instances/"+instanceID+"/last_operation", nil).Body["state"].(string)
+		}
+	}
+
+	BeforeEach(func() {
+		config = broker.Config{
+			Catalog: broker.Catalog{Services: []broker.Service{{
+				ID:       "service-id",
+				Name:     "some-service",
+				Bindable: true,
+				Requires: []string{"route_forwarding", "syslog_drain"},
+				Plans: []broker.Plan{
+					{ID: "sync-id", Name: "sync", MaintenanceInfo: &broker.MaintenanceInfo{Version: "1.0.0"}},
+					{ID: "async-id", Name: "async"},
+				},
+			}}},
+			Behaviours: map[string]broker.Behaviour{
+				"sync-id": {
+					Credentials:     map[string]interface{}{"password": "secret"},
+					SyslogDrainURL:  "syslog://drain.example.com",
+					RouteServiceURL: "https://route-service.example.com",
+				},
+				"async-id": {Async: true, Delay: 100 * time.Millisecond},
+			},
+		}
+		b = broker.New("username", "password")
+		b.Configure(config)
+		server = httptest.NewServer(b)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("serves the configured catalog", func() {
+		catalog := request(http.MethodGet, "/v2/catalog", nil)
+		Expect(catalog.Status).To(Equal(http.StatusOK))
+		Expect(catalog.Body["services"]).To(HaveLen(1))
+	})
+
+	It("requires basic auth and an API version header", func() {
+		resp, err := http.Get(server.URL + "/v2/catalog")
+		Expect(err).NotTo(HaveOccurred())
+		resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/v2/catalog", nil)
+		Expect(err).NotTo(HaveOccurred())
+		req.SetBasicAuth("username", "password")
+		resp, err = http.DefaultClient.Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusPreconditionFailed))
+	})
+
+	It("is configured through PUT /config", func() {
+		config.Catalog.Services[0].Name = "renamed-service"
+		Expect(request(http.MethodPut, "/config", config).Status).To(Equal(http.StatusOK))
+
+		catalog := request(http.MethodGet, "/v2/catalog", nil)
+		Expect(catalog.Body["services"]).To(ConsistOf(HaveKeyWithValue("name", "renamed-service")))
+		Expect(request(http.MethodGet, "/config", nil).Body).To(HaveKey("behaviours"))
+	})
+
+	It("takes delays as duration strings", func() {
+		encoded, err := json.Marshal(config.Behaviours["async-id"])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(encoded).To(MatchJSON(`{"async": true, "delay": "100ms"}`))
+
+		var behaviour broker.Behaviour
+		Expect(json.Unmarshal([]byte(`{"async": true, "delay": "1m30s"}`), &behaviour)).To(Succeed())
+		Expect(behaviour).To(Equal(broker.Behaviour{Async: true, Delay: 90 * time.Second}))
+
+		Expect(json.Unmarshal([]byte(`{"delay": 10000000000}`), &behaviour)).NotTo(Succeed())
+		Expect(json.Unmarshal([]byte(`{"delay": "soon"}`), &behaviour)).To(MatchError(ContainSubstring("delay: ")))
+	})
+
+	Describe("synchronous plans", func() {
+		It("provisions, fetches, updates and deprovisions an instance", func() {
+			Expect(provision("instance-id", "sync-id", map[string]interface{}{"size": "small"}).Status).To(Equal(http.StatusCreated))
+
+			fetched := request(http.MethodGet, "/v2/service_instances/instance-id", nil)
+			Expect(fetched.Body).To(HaveKeyWithValue("plan_id", "sync-id"))
+			Expect(fetched.Body).To(HaveKeyWithValue("parameters", map[string]interface{}{"size": "small"}))
+
+			updated := request(http.MethodPatch, "/v2/service_instances/instance-id", map[string]interface{}{
+				"service_id": "service-id",
+				"parameters": map[string]interface{}{"size": "large"},
+			})
+			Expect(updated.Status).To(Equal(http.StatusOK))
+			fetched = request(http.MethodGet, "/v2/service_instances/instance-id", nil)
+			Expect(fetched.Body).To(HaveKeyWithValue("parameters", map[string]interface{}{"size": "large"}))
+
+			Expect(request(http.MethodDelete, "/v2/service_instances/instance-id?service_id=service-id&plan_id=sync-id", nil).Status).To(Equal(http.StatusOK))
+			Expect(request(http.MethodDelete, "/v2/service_instances/instance-id?service_id=service-id&plan_id=sync-id", nil).Status).To(Equal(http.StatusGone))
+		})
+
+		It("returns what each kind of binding is configured with", func() {
+			provision("instance-id", "sync-id", nil)
+
+			appBinding := request(http.MethodPut, "/v2/service_instances/instance-id/service_bindings/app-binding", map[string]interface{}{
+				"service_id":    "service-id",
+				"plan_id":       "sync-id",
+				"bind_resource": map[string]interface{}{"app_guid": "app-guid"},
+				"parameters":    map[string]interface{}{"role": "reader"},
+			})
+			Expect(appBinding.Status).To(Equal(http.StatusCreated))
+			Expect(appBinding.Body).To(Equal(map[string]interface{}{
+				"credentials":      map[string]interface{}{"password": "secret"},
+				"syslog_drain_url": "syslog://drain.example.com",
+			}))
+			Expect(request(http.MethodGet, "/v2/service_instances/instance-id/service_bindings/app-binding", nil).Body).
+				To(HaveKeyWithValue("parameters", map[string]interface{}{"role": "reader"}))
+
+			key := request(http.MethodPut, "/v2/service_instances/instance-id/service_bindings/key", map[string]interface{}{
+				"service_id": "service-id",
+				"plan_id":    "sync-id",
+			})
+			Expect(key.Body).To(Equal(map[string]interface{}{"credentials": map[string]interface{}{"password": "secret"}}))
+
+			routeBinding := request(http.MethodPut, "/v2/service_instances/instance-id/service_bindings/route-binding", map[string]interface{}{
+				"service_id":    "service-id",
+				"plan_id":       "sync-id",
+				"bind_resource": map[string]interface{}{"route": "app.example.com"},
+			})
+			Expect(routeBinding.Body).To(Equal(map[string]interface{}{"route_service_url": "https://route-service.example.com"}))
+
+			Expect(request(http.MethodDelete, "/v2/service_instances/instance-id/service_bindings/key", nil).Status).To(Equal(http.StatusOK))
+			Expect(request(http.MethodGet, "/v2/service_instances/instance-id/service_bindings/key", nil).Status).To(Equal(http.StatusNotFound))
+		})
+
+		It("rejects failing operations", func() {
+			behaviour := config.Behaviours["sync-id"]
+			behaviour.Failures = map[broker.Operation]broker.Failure{
+				broker.Provision: {Description: "out of capacity"},
+				broker.Update:    {Status: http.StatusInternalServerError, Description: "broken"},
+			}
+			config.Behaviours["sync-id"] = behaviour
+			b.Configure(config)
+
+			failed := provision("instance-id", "sync-id", nil)
+			Expect(failed.Status).To(Equal(http.StatusBadRequest))
+			Expect(failed.Body).To(HaveKeyWithValue("description", "out of capacity"))
+			Expect(request(http.MethodGet, "/v2/service_instances/instance-id", nil).Status).To(Equal(http.StatusNotFound))
+		})
+
+		It("holds responses back for the delay", func() {
+			behaviour := config.Behaviours["sync-id"]
+			behaviour.Delay = 200 * time.Millisecond
+			config.Behaviours["sync-id"] = behaviour
+			b.Configure(config)
+
+			start := time.Now()
+			Expect(provision("instance-id", "sync-id", nil).Status).To(Equal(http.StatusCreated))
+			Expect(time.Since(start)).To(BeNumerically(">=", 200*time.Millisecond))
+		})
+	})
+
+	Describe("asynchronous plans", func() {
+		It("requires accepts_incomplete", func() {
+			rejected := request(http.MethodPut, "/v2/service_instances/instance-id", map[string]interface{}{
+				"service_id": "service-id",
+				"plan_id":    "async-id",
+			})
+			Expect(rejected.Status).To(Equal(http.StatusUnprocessableEntity))
+			Expect(rejected.Body).To(HaveKeyWithValue("error", "AsyncRequired"))
+		})
+
+		It("reports on operations from last_operation until they finish", func() {
+			accepted := provision("instance-id", "async-id", nil)
+			Expect(accepted.Status).To(Equal(http.StatusAccepted))
+			Expect(accepted.Body).To(HaveKey("operation"))
+
+			Expect(lastOperation("instance-id")()).To(Equal("in progress"))
+			Expect(request(http.MethodGet, "/v2/service_instances/instance-id", nil).Status).To(Equal(http.StatusNotFound))
+			Expect(request(http.MethodDelete, "/v2/service_instances/instance-id?accepts_incomplete=true", nil).Body).
+				To(HaveKeyWithValue("error", "ConcurrencyError"))
+			Eventually(lastOperation("instance-id")).Should(Equal("succeeded"))
+			Expect(request(http.MethodGet, "/v2/service_instances/instance-id", nil).Status).To(Equal(http.StatusOK))
+
+			bound := request(http.MethodPut, "/v2/service_instances/instance-id/service_bindings/binding-id?accepts_incomplete=true", map[string]interface{}{
+				"service_id":    "service-id",
+				"plan_id":       "async-id",
+				"bind_resource": map[string]interface{}{"app_guid": "app-guid"},
+			})
+			Expect(bound.Status).To(Equal(http.StatusAccepted))
+			Eventually(func() string {
+				return request(http.MethodGet, "/v2/service_instances/instance-id/service_bindings/binding-id/last_operation", nil).Body["state"].(string)
+			}).Should(Equal("succeeded"))
+
+			Expect(request(http.MethodDelete, "/v2/service_instances/instance-id?accepts_incomplete=true", nil).Status).To(Equal(http.StatusAccepted))
+			Eventually(lastOperation("instance-id")).Should(Equal("succeeded"))
+			Expect(request(http.MethodGet, "/v2/service_instances/instance-id/last_operation", nil).Status).To(Equal(http.StatusGone))
+			Expect(request(http.MethodGet, "/v2/service_instances/instance-id/service_bindings/binding-id", nil).Status).To(Equal(http.StatusNotFound))
+		})
+
+		It("fails operations when they finish", func() {
+			behaviour := config.Behaviours["async-id"]
+			behaviour.Failures = map[broker.Operation]broker.Failure{broker.Provision: {Description: "out of capacity"}}
+			config.Behaviours["async-id"] = behaviour
+			b.Configure(config)
+
+			Expect(provision("instance-id", "async-id", nil).Status).To(Equal(http.StatusAccepted))
+			Eventually(lastOperation("instance-id")).Should(Equal("failed"))
+			Expect(request(http.MethodGet, "/v2/service_instances/instance-id/last_operation", nil).Body).
+				To(HaveKeyWithValue("description", "out of capacity"))
+		})
+	})
+
+	Describe("repeated creates", func() {
+		bind := func(bindingID, planID string, parameters map[string]interface{}) response {
+			return request(http.MethodPut, "/v2/service_instances/instance-id/service_bindings/"+bindingID+"?accepts_incomplete=true", map[string]interface{}{
+				"service_id":    "service-id",
+				"plan_id":       planID,
+				"bind_resource": map[string]interface{}{"app_guid": "app-guid"},
+				"parameters":    parameters,
+			})
+		}
+
+		concurrently := func(create ...func() response) []int {
+			statuses := make(chan int, len(create))
+			for _, c := range create {
+				go func(c func() response) {
+					defer GinkgoRecover()
+					statuses <- c().Status
+				}(c)
+			}
+			var all []int
+			for range create {
+				all = append(all, <-statuses)
+			}
+			return all
+		}
+
+		slowDown := func(planID string) {
+			behaviour := config.Behaviours[planID]
+			behaviour.Delay = 200 * time.Millisecond
+			config.Behaviours[planID] = behaviour
+			b.Configure(config)
+		}
+
+		It("answers an identical provision with 200 and a different one with 409", func() {
+			small := map[string]interface{}{"size": "small"}
+			Expect(provision("instance-id", "sync-id", small).Status).To(Equal(http.StatusCreated))
+
+			repeated := provision("instance-id", "sync-id", small)
+			Expect(repeated.Status).To(Equal(http.StatusOK))
+			Expect(repeated.Body).To(HaveKey("dashboard_url"))
+			Expect(provision("instance-id", "sync-id", map[string]interface{}{"size": "large"}).Status).To(Equal(http.StatusConflict))
+			Expect(provision("instance-id", "sync-id", nil).Status).To(Equal(http.StatusConflict))
+			Expect(provision("instance-id", "async-id", small).Status).To(Equal(http.StatusConflict))
+		})
+
+		It("answers an identical provision with 202 until the first has succeeded", func() {
+			accepted := provision("instance-id", "async-id", nil)
+			Expect(accepted.Status).To(Equal(http.StatusAccepted))
+
+			repeated := provision("instance-id", "async-id", nil)
+			Expect(repeated.Status).To(Equal(http.StatusAccepted))
+			Expect(repeated.Body).To(HaveKeyWithValue("operation", accepted.Body["operation"]))
+			Expect(provision("instance-id", "async-id", map[string]interface{}{"size": "large"}).Status).To(Equal(http.StatusConflict))
+
+			Eventually(lastOperation("instance-id")).Should(Equal("succeeded"))
+			Expect(provision("instance-id", "async-id", nil).Status).To(Equal(http.StatusOK))
+		})
+
+		It("provisions again after a failed asynchronous provision", func() {
+			behaviour := config.Behaviours["async-id"]
+			behaviour.Failures = map[broker.Operation]broker.Failure{broker.Provision: {Description: "out of capacity"}}
+			config.Behaviours["async-id"] = behaviour
+			b.Configure(config)
+
+			Expect(provision("instance-id", "async-id", nil).Status).To(Equal(http.StatusAccepted))
+			Eventually(lastOperation("instance-id")).Should(Equal("failed"))
+			Expect(provision("instance-id", "sync-id", nil).Status).To(Equal(http.StatusCreated))
+		})
+
+		It("answers concurrent provisions as repeats of the first", func() {
+			slowDown("sync-id")
+			Expect(concurrently(
+				func() response { return provision("instance-id", "sync-id", nil) },
+				func() response { return provision("instance-id", "sync-id", nil) },
+			)).To(ConsistOf(http.StatusCreated, http.StatusOK))
+
+			Expect(concurrently(
+				func() response {
+					return provision("other-instance-id", "sync-id", map[string]interface{}{"size": "small"})
+				},
+				func() response {
+					return provision("other-instance-id", "sync-id", map[string]interface{}{"size": "large"})
+				},
+			)).To(ConsistOf(http.StatusCreated, http.StatusConflict))
+		})
+
+		It("answers an identical bind with 200 and a different one with 409", func() {
+			provision("instance-id", "sync-id", nil)
+			Expect(bind("binding-id", "sync-id", nil).Status).To(Equal(http.StatusCreated))
+
+			repeated := bind("binding-id", "sync-id", nil)
+			Expect(repeated.Status).To(Equal(http.StatusOK))
+			Expect(repeated.Body).To(HaveKey("credentials"))
+			Expect(bind("binding-id", "sync-id", map[string]interface{}{"role": "admin"}).Status).To(Equal(http.StatusConflict))
+		})
+
+		It("answers an identical bind with 202 until the first has succeeded", func() {
+			provision("instance-id", "async-id", nil)
+			Eventually(lastOperation("instance-id")).Should(Equal("succeeded"))
+
+			accepted := bind("binding-id", "async-id", nil)
+			Expect(accepted.Status).To(Equal(http.StatusAccepted))
+			repeated := bind("binding-id", "async-id", nil)
+			Expect(repeated.Status).To(Equal(http.StatusAccepted))
+			Expect(repeated.Body).To(HaveKeyWithValue("operation", accepted.Body["operation"]))
+			Expect(bind("binding-id", "async-id", map[string]interface{}{"role": "admin"}).Status).To(Equal(http.StatusConflict))
+
+			Eventually(func() int { return bind("binding-id", "async-id", nil).Status }).Should(Equal(http.StatusOK))
+		})
+
+		It("answers concurrent binds as repeats of the first", func() {
+			provision("instance-id", "sync-id", nil)
+			slowDown("sync-id")
+			Expect(concurrently(
+				func() response { return bind("binding-id", "sync-id", nil) },
+				func() response { return bind("binding-id", "sync-id", nil) },
+			)).To(ConsistOf(http.StatusCreated, http.StatusOK))
+		})
+	})
+
+	Describe("maintenance_info", func() {
+		It("rejects versions other than the catalog's", func() {
+			rejected := request(http.MethodPut, "/v2/service_instances/instance-id", map[string]interface{}{
+				"service_id":       "service-id",
+				"plan_id":          "sync-id",
+				"maintenance_info": map[string]interface{}{"version": "0.9.0"},
+			})
+			Expect(rejected.Status).To(Equal(http.StatusUnprocessableEntity))
+			Expect(rejected.Body).To(HaveKeyWithValue("error", "MaintenanceInfoConflict"))
+		})
+
+		It("records the version the instance was upgraded to", func() {
+			provision("instance-id", "sync-id", nil)
+
+			config.Catalog.Services[0].Plans[0].MaintenanceInfo.Version = "2.0.0"
+			b.Configure(config)
+			upgraded := request(http.MethodPatch, "/v2/service_instances/instance-id", map[string]interface{}{
+				"service_id":       "service-id",
+				"maintenance_info": map[string]interface{}{"version": "2.0.0"},
+			})
+			Expect(upgraded.Status).To(Equal(http.StatusOK))
+
+			fetched := request(http.MethodGet, "/v2/service_instances/instance-id", nil)
+			Expect(fetched.Body).To(HaveKeyWithValue("maintenance_info", HaveKeyWithValue("version", "2.0.0")))
+		})
+	})
+})
@@ -0,0 +1,95 @@
+package broker
+
+// Catalog is what the broker returns from GET /v2/catalog.
+type Catalog struct {
+	Services []Service `json:"services"`
+}
+
+// Service is an offering of the catalog. Requires lists the permissions it
+// needs, e.g. "route_forwarding", "syslog_drain" or "volume_mount"; Cloud
+// Controller rejects route services, syslog drains and volume mounts from
+// services that do not require them.
+type Service struct {
+	ID                   string                 `json:"id"`
+	Name                 string                 `json:"name"`
+	Description          string                 `json:"description"`
+	Tags                 []string               `json:"tags,omitempty"`
+	Requires             []string               `json:"requires,omitempty"`
+	Bindable             bool                   `json:"bindable"`
+	InstancesRetrievable bool                   `json:"instances_retrievable"`
+	BindingsRetrievable  bool                   `json:"bindings_retrievable"`
+	PlanUpdateable       bool                   `json:"plan_updateable"`
+	Metadata             map[string]interface{} `json:"metadata,omitempty"`
+	DashboardClient      *DashboardClient       `json:"dashboard_client,omitempty"`
+	Plans                []Plan                 `json:"plans"`
+}
+
+type DashboardClient struct {
+	ID          string `json:"id"`
+	Secret      string `json:"secret"`
+	RedirectURI string `json:"redirect_uri"`
+}
+
+type Plan struct {
+	ID              string           `json:"id"`
+	Name            string           `json:"name"`
+	Description     string           `json:"description"`
+	Schemas         *Schemas         `json:"schemas,omitempty"`
+	MaintenanceInfo *MaintenanceInfo `json:"maintenance_info,omitempty"`
+}
+
+type Schemas struct {
+	ServiceInstance ServiceInstanceSchemas `json:"service_instance"`
+	ServiceBinding  ServiceBindingSchemas  `json:"service_binding"`
+}
+
+type ServiceInstanceSchemas struct {
+	Create ParametersSchema `json:"create"`
+	Update ParametersSchema `json:"update"`
+}
+
+type ServiceBindingSchemas struct {
+	Create ParametersSchema `json:"create"`
+}
+
+// ParametersSchema holds a JSON schema for the parameters of a request.
+type ParametersSchema struct {
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+}
+
+// MaintenanceInfo versions a plan. Cloud Controller flags instances
+// provisioned at another version as having an upgrade available, and sends
+// the plan's version when upgrading them.
+type MaintenanceInfo struct {
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// VolumeMount is returned from app bindings of services requiring
+// "volume_mount".
+type VolumeMount struct {
+	Driver       string `json:"driver"`
+	ContainerDir string `json:"container_dir"`
+	Mode         string `json:"mode"`
+	DeviceType   string `json:"device_type"`
+	Device       Device `json:"device"`
+}
+
+type Device struct {
+	VolumeID    string                 `json:"volume_id"`
+	MountConfig map[string]interface{} `json:"mount_config,omitempty"`
+}
+
+func (c Catalog) findPlan(serviceID, planID string) (Plan, bool) {
+	for _, service := range c.Services {
+		if service.ID != serviceID {
+			continue
+		}
+		for _, plan := range service.Plans {
+			if plan.ID == planID {
+				return plan, true
+			}
+		}
+	}
+	return Plan{}, false
+}
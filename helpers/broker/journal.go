@@ -0,0 +1,129 @@
+package broker
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Request is a request the broker received, as recorded in its journal.
+type Request struct {
+	Method string      `json:"method"`
+	Path   string      `json:"path"`
+	Query  string      `json:"query,omitempty"`
+	Header http.Header `json:"header"`
+	Body   string      `json:"body,omitempty"`
+	Time   time.Time   `json:"time"`
+}
+
+// OriginatingIdentity is the user Cloud Controller acted for, sent in the
+// X-Broker-API-Originating-Identity header.
+type OriginatingIdentity struct {
+	Platform string
+	UserID   string `json:"user_id"`
+}
+
+// Journal lists requests in the order the broker received them.
+type Journal []Request
+
+// Matching returns the requests with the method whose path starts with
+// pathPrefix, e.g. Matching(http.MethodPut, "/v2/service_instances/"+id).
+func (j Journal) Matching(method, pathPrefix string) Journal {
+	var matching Journal
+	for _, request := range j {
+		if request.Method == method && strings.HasPrefix(request.Path, pathPrefix) {
+			matching = append(matching, request)
+		}
+	}
+	return matching
+}
+
+// Paths returns "METHOD path" for each request, for asserting on order.
+func (j Journal) Paths() []string {
+	var paths []string
+	for _, request := range j {
+		paths = append(paths, request.Method+" "+request.Path)
+	}
+	return paths
+}
+
+func (r Request) APIVersion() string {
+	return r.Header.Get("X-Broker-API-Version")
+}
+
+// OriginatingIdentity decodes the X-Broker-API-Originating-Identity header,
+// which holds the platform followed by base64-encoded JSON.
+func (r Request) OriginatingIdentity() (OriginatingIdentity, error) {
+	header := r.Header.Get("X-Broker-API-Originating-Identity")
+	platform, encoded, ok := strings.Cut(header, " ")
+	if !ok {
+		return OriginatingIdentity{}, fmt.Errorf("malformed X-Broker-API-Originating-Identity %q", header)
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return OriginatingIdentity{}, fmt.Errorf("decoding X-Broker-API-Originating-Identity: %w", err)
+	}
+	identity := OriginatingIdentity{Platform: platform}
+	if err := json.Unmarshal(decoded, &identity); err != nil {
+		return OriginatingIdentity{}, fmt.Errorf("decoding X-Broker-API-Originating-Identity: %w", err)
+	}
+	return identity, nil
+}
+
+// DecodeBody unmarshals the JSON body of the request.
+func (r Request) DecodeBody(v interface{}) error {
+	return json.Unmarshal([]byte(r.Body), v)
+}
+
+// Requests returns the journal, which records every request but those for
+// the journal and the broker's configuration. Authorization headers are left
+// out.
+func (b *Broker) Requests() Journal {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append(Journal{}, b.journal...)
+}
+
+func (b *Broker) ClearRequests() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.journal = nil
+}
+
+func (b *Broker) record(r *http.Request) {
+	if r.URL.Path == "/requests" || r.URL.Path == "/config" {
+		return
+	}
+
+	body, _ := io.ReadAll(r.Body)
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	header := r.Header.Clone()
+	header.Del("Authorization")
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.journal = append(b.journal, Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.RawQuery,
+		Header: header,
+		Body:   string(body),
+		Time:   time.Now(),
+	})
+}
+
+func (b *Broker) getRequests(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, b.Requests())
+}
+
+func (b *Broker) deleteRequests(w http.ResponseWriter, r *http.Request) {
+	b.ClearRequests()
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,102 @@
+package broker_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/cloudfoundry/capi-bara-tests/helpers/broker"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Journal", func() {
+	var (
+		b      *broker.Broker
+		server *httptest.Server
+	)
+
+	send := func(method, path, body string) {
+		req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		Expect(err).NotTo(HaveOccurred())
+		req.SetBasicAuth("username", "password")
+		req.Header.Set("X-Broker-API-Version", "2.15")
+		req.Header.Set("X-Broker-API-Originating-Identity",
+			"cloudfoundry "+base64.StdEncoding.EncodeToString([]byte(`{"user_id": "user-guid"}`)))
+
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		resp.Body.Close()
+	}
+
+	BeforeEach(func() {
+		b = broker.New("username", "password")
+		b.Configure(broker.Config{Catalog: broker.Catalog{Services: []broker.Service{{
+			ID:    "service-id",
+			Plans: []broker.Plan{{ID: "plan-id"}},
+		}}}})
+		server = httptest.NewServer(b)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("records requests in order, without the credentials", func() {
+		send(http.MethodGet, "/v2/catalog", "")
+		send(http.MethodPut, "/v2/service_instances/instance-id?accepts_incomplete=true", `{"service_id": "service-id", "plan_id": "plan-id"}`)
+		send(http.MethodPut, "/v2/service_instances/instance-id/service_bindings/binding-id", `{"service_id": "service-id", "plan_id": "plan-id"}`)
+
+		requests := b.Requests()
+		Expect(requests.Paths()).To(Equal([]string{
+			"GET /v2/catalog",
+			"PUT /v2/service_instances/instance-id",
+			"PUT /v2/service_instances/instance-id/service_bindings/binding-id",
+		}))
+		Expect(requests.Matching(http.MethodPut, "/v2/service_instances/instance-id/service_bindings/")).To(HaveLen(1))
+
+		provision := requests[1]
+		Expect(provision.Query).To(Equal("accepts_incomplete=true"))
+		Expect(provision.APIVersion()).To(Equal("2.15"))
+		Expect(provision.Header).NotTo(HaveKey("Authorization"))
+		Expect(provision.Time).NotTo(BeZero())
+
+		var body struct {
+			PlanID string `json:"plan_id"`
+		}
+		Expect(provision.DecodeBody(&body)).To(Succeed())
+		Expect(body.PlanID).To(Equal("plan-id"))
+
+		identity, err := provision.OriginatingIdentity()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(identity).To(Equal(broker.OriginatingIdentity{Platform: "cloudfoundry", UserID: "user-guid"}))
+	})
+
+	It("is served from /requests, which is left out of it", func() {
+		send(http.MethodGet, "/v2/catalog", "")
+
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/requests", nil)
+		Expect(err).NotTo(HaveOccurred())
+		req.SetBasicAuth("username", "password")
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close()
+
+		var journal broker.Journal
+		Expect(json.NewDecoder(resp.Body).Decode(&journal)).To(Succeed())
+		Expect(journal.Paths()).To(Equal([]string{"GET /v2/catalog"}))
+
+		send(http.MethodDelete, "/requests", "")
+		Expect(b.Requests()).To(BeEmpty())
+	})
+
+	It("requires credentials to read it", func() {
+		resp, err := http.Get(server.URL + "/requests")
+		Expect(err).NotTo(HaveOccurred())
+		resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+	})
+})
@@ -0,0 +1,101 @@
+package capi_client
+
+import (
+	"fmt"
+	"net/http"
+)
+
+const (
+	AppStateStarted = "STARTED"
+	AppStateStopped = "STOPPED"
+)
+
+type App struct {
+	Resource
+	Name          string           `json:"name"`
+	State         string           `json:"state"`
+	Lifecycle     Lifecycle        `json:"lifecycle"`
+	Relationships AppRelationships `json:"relationships"`
+}
+
+type AppRelationships struct {
+	Space Relationship `json:"space"`
+}
+
+type CreateAppRequest struct {
+	Name                 string            `json:"name"`
+	Relationships        AppRelationships  `json:"relationships"`
+	EnvironmentVariables map[string]string `json:"environment_variables,omitempty"`
+	Lifecycle            *Lifecycle        `json:"lifecycle,omitempty"`
+	Metadata             *Metadata         `json:"metadata,omitempty"`
+}
+
+type EnvironmentVariables struct {
+	Var map[string]*string `json:"var"`
+}
+
+func (c *Client) CreateApp(request CreateAppRequest) (App, error) {
+	var app App
+	err := c.Post("/v3/apps", request, &app)
+	return app, err
+}
+
+func (c *Client) ListApps(options ListOptions) ([]App, error) {
+	return List[App](c, "/v3/apps", options)
+}
+
+func (c *Client) GetApp(appGUID string) (App, error) {
+	var app App
+	err := c.Get(appPath(appGUID), &app)
+	return app, err
+}
+
+func (c *Client) StartApp(appGUID string) (App, error) {
+	return c.appAction(appGUID, "start")
+}
+
+func (c *Client) StopApp(appGUID string) (App, error) {
+	return c.appAction(appGUID, "stop")
+}
+
+func (c *Client) RestartApp(appGUID string) (App, error) {
+	return c.appAction(appGUID, "restart")
+}
+
+// DeleteApp returns the path of the job deleting the app.
+func (c *Client) DeleteApp(appGUID string) (string, error) {
+	return c.DoAsync(http.MethodDelete, appPath(appGUID), nil)
+}
+
+func (c *Client) GetAppCurrentDroplet(appGUID string) (Droplet, error) {
+	var droplet Droplet
+	err := c.Get(appPath(appGUID)+"/droplets/current", &droplet)
+	return droplet, err
+}
+
+func (c *Client) SetAppCurrentDroplet(appGUID, dropletGUID string) error {
+	return c.Patch(appPath(appGUID)+"/relationships/current_droplet", NewRelationship(dropletGUID), nil)
+}
+
+func (c *Client) UpdateAppEnvironmentVariables(appGUID string, envVars map[string]*string) (EnvironmentVariables, error) {
+	var updated EnvironmentVariables
+	err := c.Patch(appPath(appGUID)+"/environment_variables", EnvironmentVariables{Var: envVars}, &updated)
+	return updated, err
+}
+
+func (c *Client) SetAppFeature(appGUID, feature string, enabled bool) error {
+	body := struct {
+		Enabled bool `json:"enabled"`
+	}{enabled}
+	return c.Patch(fmt.Sprintf("%s/features/%s", appPath(appGUID), feature), body, nil)
+}
+
+func (c *Client) appAction(appGUID, action string) (App, error) {
+	var app App
+	err := c.Post(fmt.Sprintf("%s/actions/%s", appPath(appGUID), action), nil, &app)
+	return app, err
+}
+
+func appPath(appGUID string) string {
+	return fmt.Sprintf("/v3/apps/%s", appGUID)
+}
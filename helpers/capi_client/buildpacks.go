@@ -0,0 +1,11 @@
+package capi_client
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// DeleteBuildpack returns the path of the job deleting the buildpack.
+func (c *Client) DeleteBuildpack(buildpackGUID string) (string, error) {
+	return c.DoAsync(http.MethodDelete, fmt.Sprintf("/v3/buildpacks/%s", buildpackGUID), nil)
+}
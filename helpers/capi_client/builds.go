@@ -0,0 +1,35 @@
+package capi_client
+
+import "fmt"
+
+const (
+	BuildStateStaging = "STAGING"
+	BuildStateStaged  = "STAGED"
+	BuildStateFailed  = "FAILED"
+)
+
+type Build struct {
+	Resource
+	State     string    `json:"state"`
+	Error     string    `json:"error"`
+	Lifecycle Lifecycle `json:"lifecycle"`
+	Package   GUIDRef   `json:"package"`
+	Droplet   *GUIDRef  `json:"droplet"`
+}
+
+type CreateBuildRequest struct {
+	Package   GUIDRef    `json:"package"`
+	Lifecycle *Lifecycle `json:"lifecycle,omitempty"`
+}
+
+func (c *Client) CreateBuild(request CreateBuildRequest) (Build, error) {
+	var build Build
+	err := c.Post("/v3/builds", request, &build)
+	return build, err
+}
+
+func (c *Client) GetBuild(buildGUID string) (Build, error) {
+	var build Build
+	err := c.Get(fmt.Sprintf("/v3/builds/%s", buildGUID), &build)
+	return build, err
+}
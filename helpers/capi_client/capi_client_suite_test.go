@@ -0,0 +1,13 @@
+package capi_client_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestCapiClient(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CAPI Client Suite")
+}
@@ -0,0 +1,172 @@
+package capi_client
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/cloudfoundry/capi-bara-tests/helpers/config"
+)
+
+// TokenFunc returns the value of the Authorization header sent with every
+// request, e.g. "bearer eyJhbGciOi...". It is called once per request so
+// that helpers running under workflowhelpers.AsUser pick up the right user.
+type TokenFunc func() string
+
+type Client struct {
+	apiURL     string
+	token      TokenFunc
+	httpClient *http.Client
+}
+
+func NewClient(apiURL string, token TokenFunc, skipSSLValidation bool) *Client {
+	httpClient := &http.Client{}
+	if skipSSLValidation {
+		httpClient.Transport = &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		}
+	}
+
+	return &Client{
+		apiURL:     strings.TrimSuffix(apiURL, "/"),
+		token:      token,
+		httpClient: httpClient,
+	}
+}
+
+func NewClientFromConfig(config config.BaraConfig, token TokenFunc) *Client {
+	return NewClient(config.Protocol()+config.GetApiEndpoint(), token, config.GetSkipSSLValidation())
+}
+
+func (c *Client) Get(path string, result interface{}) error {
+	_, err := c.do(http.MethodGet, path, nil, result)
+	return err
+}
+
+func (c *Client) Post(path string, body, result interface{}) error {
+	_, err := c.do(http.MethodPost, path, body, result)
+	return err
+}
+
+func (c *Client) Patch(path string, body, result interface{}) error {
+	_, err := c.do(http.MethodPatch, path, body, result)
+	return err
+}
+
+func (c *Client) Delete(path string) error {
+	_, err := c.do(http.MethodDelete, path, nil, nil)
+	return err
+}
+
+// DoAsync performs a request that Cloud Controller answers with
+// 202 Accepted and returns the path of the job from the Location header.
+func (c *Client) DoAsync(method, path string, body interface{}) (string, error) {
+	resp, err := c.do(method, path, body, nil)
+	if err != nil {
+		return "", err
+	}
+
+	if resp.StatusCode != http.StatusAccepted {
+		return "", fmt.Errorf("%s %s: expected 202 Accepted but got %s", method, path, resp.Status)
+	}
+
+	return jobPathFromResponse(method, path, resp)
+}
+
+// DoMaybeAsync performs a request that Cloud Controller answers either
+// straight away, decoding the body into result and returning "", or with
+// 202 Accepted, returning the path of the job from the Location header.
+// Operations on service instances and bindings answer one way or the other
+// depending on whether they involve the broker.
+func (c *Client) DoMaybeAsync(method, path string, body, result interface{}) (string, error) {
+	resp, err := c.do(method, path, body, result)
+	if err != nil || resp.StatusCode != http.StatusAccepted {
+		return "", err
+	}
+
+	return jobPathFromResponse(method, path, resp)
+}
+
+func jobPathFromResponse(method, path string, resp *http.Response) (string, error) {
+	jobPath, err := JobPathFromLocation(resp.Header.Get("Location"))
+	if err != nil {
+		return "", fmt.Errorf("%s %s: %s", method, path, err)
+	}
+	return jobPath, nil
+}
+
+func (c *Client) URL(path string) string {
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return path
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return c.apiURL + path
+}
+
+func (c *Client) do(method, path string, body, result interface{}) (*http.Response, error) {
+	var reqBody io.Reader
+	if body != nil {
+		bodyJSON, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reqBody = bytes.NewReader(bodyJSON)
+	}
+
+	req, err := http.NewRequest(method, c.URL(path), reqBody)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	return c.send(req, result)
+}
+
+func (c *Client) send(req *http.Request, result interface{}) (*http.Response, error) {
+	req.Header.Set("Accept", "application/json")
+	if c.token != nil {
+		req.Header.Set("Authorization", c.token())
+	}
+
+	tracePath := traceFile()
+	var requestDump string
+	if tracePath != "" {
+		requestDump = dumpRequest(req)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if tracePath != "" {
+		traceExchange(tracePath, requestDump, resp, respBody)
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return resp, newResponseError(req, resp, respBody)
+	}
+
+	if result != nil && len(respBody) > 0 {
+		if err := json.Unmarshal(respBody, result); err != nil {
+			return resp, fmt.Errorf("%s %s: failed to decode response: %s", req.Method, req.URL.Path, err)
+		}
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,276 @@
+package capi_client_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry/capi-bara-tests/helpers/capi_client"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type receivedRequest struct {
+	Method        string
+	Path          string
+	RawQuery      string
+	Authorization string
+	ContentType   string
+	Body          []byte
+}
+
+var _ = Describe("Client", func() {
+	var (
+		server   *httptest.Server
+		client   *capi_client.Client
+		requests []receivedRequest
+
+		responseStatus  int
+		responseHeaders map[string]string
+		responseBody    string
+	)
+
+	BeforeEach(func() {
+		requests = nil
+		responseStatus = http.StatusOK
+		responseHeaders = map[string]string{}
+		responseBody = `{}`
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(r.Body)
+			Expect(err).NotTo(HaveOccurred())
+			requests = append(requests, receivedRequest{
+				Method:        r.Method,
+				Path:          r.URL.Path,
+				RawQuery:      r.URL.RawQuery,
+				Authorization: r.Header.Get("Authorization"),
+				ContentType:   r.Header.Get("Content-Type"),
+				Body:          body,
+			})
+
+			for name, value := range responseHeaders {
+				w.Header().Set(name, value)
+			}
+			w.WriteHeader(responseStatus)
+			io.WriteString(w, responseBody)
+		}))
+
+		client = capi_client.NewClient(server.URL, func() string { return "bearer some-token" }, false)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("CreateApp", func() {
+		BeforeEach(func() {
+			responseStatus = http.StatusCreated
+			responseBody = `{"guid": "app-guid", "name": "some-app", "state": "STOPPED", "created_at": "2020-01-02T03:04:05Z"}`
+		})
+
+		It("posts a typed request with the auth token and decodes the app", func() {
+			app, err := client.CreateApp(capi_client.CreateAppRequest{
+				Name:                 "some-app",
+				Relationships:        capi_client.AppRelationships{Space: capi_client.NewRelationship("space-guid")},
+				EnvironmentVariables: map[string]string{"foo": "bar"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(app.GUID).To(Equal("app-guid"))
+			Expect(app.State).To(Equal(capi_client.AppStateStopped))
+			Expect(app.CreatedAt.Year()).To(Equal(2020))
+
+			Expect(requests).To(HaveLen(1))
+			Expect(requests[0].Method).To(Equal(http.MethodPost))
+			Expect(requests[0].Path).To(Equal("/v3/apps"))
+			Expect(requests[0].Authorization).To(Equal("bearer some-token"))
+			Expect(requests[0].ContentType).To(Equal("application/json"))
+			Expect(requests[0].Body).To(MatchJSON(`{
+				"name": "some-app",
+				"relationships": {"space": {"data": {"guid": "space-guid"}}},
+				"environment_variables": {"foo": "bar"}
+			}`))
+		})
+	})
+
+	Describe("ListAppProcesses", func() {
+		BeforeEach(func() {
+			responseBody = `{"resources": [{"guid": "process-guid", "type": "web", "relationships": {"revision": {"data": {"guid": "revision-guid"}}}}]}`
+		})
+
+		It("filters by process type", func() {
+			processes, err := client.ListAppProcesses("app-guid", capi_client.ListOptions{}.Filter("types", "web"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(processes).To(HaveLen(1))
+			Expect(processes[0].GUID).To(Equal("process-guid"))
+			Expect(processes[0].Relationships.Revision.GUID()).To(Equal("revision-guid"))
+
+			Expect(requests[0].Path).To(Equal("/v3/apps/app-guid/processes"))
+			Expect(requests[0].RawQuery).To(Equal("types=web"))
+		})
+	})
+
+	Describe("DoAsync", func() {
+		BeforeEach(func() {
+			responseStatus = http.StatusAccepted
+			responseHeaders["Location"] = "https://api.example.com/v3/jobs/job-guid"
+		})
+
+		It("returns the job path from the Location header", func() {
+			jobPath, err := client.DeleteApp("app-guid")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(jobPath).To(Equal("/v3/jobs/job-guid"))
+			Expect(requests[0].Method).To(Equal(http.MethodDelete))
+			Expect(requests[0].Path).To(Equal("/v3/apps/app-guid"))
+		})
+
+		Context("when the response is not 202 Accepted", func() {
+			BeforeEach(func() {
+				responseStatus = http.StatusNoContent
+			})
+
+			It("returns an error", func() {
+				_, err := client.DeleteApp("app-guid")
+				Expect(err).To(MatchError(ContainSubstring("expected 202 Accepted")))
+			})
+		})
+	})
+
+	Describe("DoMaybeAsync", func() {
+		It("returns the job path when Cloud Controller accepts the request", func() {
+			responseStatus = http.StatusAccepted
+			responseHeaders["Location"] = "https://api.example.com/v3/jobs/job-guid"
+
+			instance, jobPath, err := client.CreateServiceInstance(capi_client.CreateServiceInstanceRequest{Type: "managed", Name: "some-instance"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(jobPath).To(Equal("/v3/jobs/job-guid"))
+			Expect(instance.GUID).To(BeEmpty())
+		})
+
+		It("decodes the resource when Cloud Controller answers straight away", func() {
+			responseStatus = http.StatusCreated
+			responseBody = `{"guid": "instance-guid", "type": "user-provided"}`
+
+			instance, jobPath, err := client.CreateServiceInstance(capi_client.CreateServiceInstanceRequest{Type: "user-provided", Name: "some-instance"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(jobPath).To(BeEmpty())
+			Expect(instance.GUID).To(Equal("instance-guid"))
+		})
+
+		It("returns no job path for 204 No Content", func() {
+			responseStatus = http.StatusNoContent
+
+			jobPath, err := client.DeleteServiceCredentialBinding("binding-guid")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(jobPath).To(BeEmpty())
+		})
+	})
+
+	Describe("UploadPackageBits", func() {
+		var zipPath string
+
+		BeforeEach(func() {
+			responseBody = `{"guid": "package-guid", "state": "PROCESSING_UPLOAD"}`
+
+			zipFile, err := os.CreateTemp("", "package-bits")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = zipFile.WriteString("some-bits")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(zipFile.Close()).To(Succeed())
+			zipPath = zipFile.Name()
+		})
+
+		AfterEach(func() {
+			os.Remove(zipPath)
+		})
+
+		It("uploads the file as the multipart 'bits' field", func() {
+			pkg, err := client.UploadPackageBits("package-guid", zipPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pkg.State).To(Equal("PROCESSING_UPLOAD"))
+
+			Expect(requests[0].Path).To(Equal("/v3/packages/package-guid/upload"))
+			Expect(requests[0].ContentType).To(HavePrefix("multipart/form-data"))
+			Expect(string(requests[0].Body)).To(ContainSubstring(`name="bits"`))
+			Expect(string(requests[0].Body)).To(ContainSubstring("some-bits"))
+		})
+	})
+
+	Context("when Cloud Controller returns an error", func() {
+		BeforeEach(func() {
+			responseStatus = http.StatusUnprocessableEntity
+			responseBody = `{"errors": [{"code": 10008, "title": "CF-UnprocessableEntity", "detail": "name must be unique in space"}]}`
+		})
+
+		It("returns a structured ResponseError", func() {
+			_, err := client.CreateApp(capi_client.CreateAppRequest{Name: "some-app"})
+			Expect(err).To(HaveOccurred())
+
+			responseErr, ok := err.(capi_client.ResponseError)
+			Expect(ok).To(BeTrue())
+			Expect(responseErr.StatusCode).To(Equal(http.StatusUnprocessableEntity))
+			Expect(responseErr.HasTitle("CF-UnprocessableEntity")).To(BeTrue())
+			Expect(responseErr.Errors).To(ConsistOf(capi_client.Error{
+				Code:   10008,
+				Title:  "CF-UnprocessableEntity",
+				Detail: "name must be unique in space",
+			}))
+			Expect(err.Error()).To(ContainSubstring("POST /v3/apps: 422 CF-UnprocessableEntity (10008): name must be unique in space"))
+		})
+	})
+
+	Context("when the resource does not exist", func() {
+		BeforeEach(func() {
+			responseStatus = http.StatusNotFound
+			responseBody = `{"errors": [{"code": 10010, "title": "CF-ResourceNotFound", "detail": "App not found"}]}`
+		})
+
+		It("can be identified with IsNotFound", func() {
+			_, err := client.GetApp("missing-guid")
+			Expect(capi_client.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	Describe("ReplaceRouteDestinations", func() {
+		It("sends an empty list rather than null when removing every destination", func() {
+			_, err := client.ReplaceRouteDestinations("route-guid", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			var body map[string]json.RawMessage
+			Expect(json.Unmarshal(requests[0].Body, &body)).To(Succeed())
+			Expect(string(body["destinations"])).To(Equal("[]"))
+		})
+	})
+
+	Describe("CF_TRACE", func() {
+		var tracePath string
+
+		BeforeEach(func() {
+			tracePath = filepath.Join(GinkgoT().TempDir(), "trace.txt")
+			Expect(os.Setenv("CF_TRACE", tracePath)).To(Succeed())
+			DeferCleanup(os.Unsetenv, "CF_TRACE")
+
+			responseStatus = http.StatusCreated
+			responseBody = `{"guid": "app-guid", "name": "some-app"}`
+		})
+
+		It("appends each exchange to the trace file with the token redacted", func() {
+			_, err := client.CreateApp(capi_client.CreateAppRequest{Name: "some-app"})
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := os.ReadFile(tracePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(ContainSubstring("REQUEST: ["))
+			Expect(string(contents)).To(ContainSubstring("POST /v3/apps HTTP/1.1"))
+			Expect(string(contents)).To(ContainSubstring(`"name":"some-app"`))
+			Expect(string(contents)).To(ContainSubstring("RESPONSE: ["))
+			Expect(string(contents)).To(ContainSubstring("HTTP/1.1 201 Created"))
+			Expect(string(contents)).To(ContainSubstring(`{"guid": "app-guid", "name": "some-app"}`))
+			Expect(string(contents)).To(ContainSubstring("Authorization: [PRIVATE DATA HIDDEN]"))
+			Expect(string(contents)).NotTo(ContainSubstring("some-token"))
+		})
+	})
+})
@@ -0,0 +1,99 @@
+package capi_client
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	DeploymentStatusValueActive    = "ACTIVE"
+	DeploymentStatusValueFinalized = "FINALIZED"
+
+	DeploymentStatusReasonDeploying  = "DEPLOYING"
+	DeploymentStatusReasonPaused     = "PAUSED"
+	DeploymentStatusReasonDeployed   = "DEPLOYED"
+	DeploymentStatusReasonCanceling  = "CANCELING"
+	DeploymentStatusReasonCanceled   = "CANCELED"
+	DeploymentStatusReasonSuperseded = "SUPERSEDED"
+
+	DeploymentStrategyRolling = "rolling"
+	DeploymentStrategyCanary  = "canary"
+)
+
+type Deployment struct {
+	Resource
+	State           string                  `json:"state"`
+	Status          DeploymentStatus        `json:"status"`
+	Strategy        string                  `json:"strategy"`
+	Options         DeploymentOptions       `json:"options"`
+	Droplet         GUIDRef                 `json:"droplet"`
+	PreviousDroplet GUIDRef                 `json:"previous_droplet"`
+	NewProcesses    []DeploymentProcess     `json:"new_processes"`
+	Revision        *DeploymentRevision     `json:"revision"`
+	Relationships   DeploymentRelationships `json:"relationships"`
+}
+
+type DeploymentOptions struct {
+	// MaxInFlight is how many new instances a rolling deployment starts at
+	// once. Cloud Controller defaults it to 1.
+	MaxInFlight *int `json:"max_in_flight,omitempty"`
+}
+
+type DeploymentStatus struct {
+	Value   string                  `json:"value"`
+	Reason  string                  `json:"reason"`
+	Details DeploymentStatusDetails `json:"details"`
+}
+
+type DeploymentStatusDetails struct {
+	LastSuccessfulHealthcheck *time.Time `json:"last_successful_healthcheck"`
+	LastStatusChange          *time.Time `json:"last_status_change"`
+}
+
+type DeploymentProcess struct {
+	GUID string `json:"guid"`
+	Type string `json:"type"`
+}
+
+type DeploymentRevision struct {
+	GUID    string `json:"guid"`
+	Version int    `json:"version"`
+}
+
+type DeploymentRelationships struct {
+	App Relationship `json:"app"`
+}
+
+type CreateDeploymentRequest struct {
+	Droplet       *GUIDRef                `json:"droplet,omitempty"`
+	Revision      *GUIDRef                `json:"revision,omitempty"`
+	Strategy      string                  `json:"strategy,omitempty"`
+	Options       *DeploymentOptions      `json:"options,omitempty"`
+	Relationships DeploymentRelationships `json:"relationships"`
+}
+
+func (c *Client) CreateDeployment(request CreateDeploymentRequest) (Deployment, error) {
+	var deployment Deployment
+	err := c.Post("/v3/deployments", request, &deployment)
+	return deployment, err
+}
+
+func (c *Client) GetDeployment(deploymentGUID string) (Deployment, error) {
+	var deployment Deployment
+	err := c.Get(deploymentPath(deploymentGUID), &deployment)
+	return deployment, err
+}
+
+func (c *Client) CancelDeployment(deploymentGUID string) error {
+	return c.Post(deploymentPath(deploymentGUID)+"/actions/cancel", nil, nil)
+}
+
+// ContinueDeployment resumes a canary deployment paused after starting its
+// canary instance.
+func (c *Client) ContinueDeployment(deploymentGUID string) error {
+	return c.Post(deploymentPath(deploymentGUID)+"/actions/continue", nil, nil)
+}
+
+func deploymentPath(deploymentGUID string) string {
+	return fmt.Sprintf("/v3/deployments/%s", deploymentGUID)
+}
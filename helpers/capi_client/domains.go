@@ -0,0 +1,10 @@
+package capi_client
+
+type Domain struct {
+	Resource
+	Name string `json:"name"`
+}
+
+func (c *Client) ListDomains(options ListOptions) ([]Domain, error) {
+	return List[Domain](c, "/v3/domains", options)
+}
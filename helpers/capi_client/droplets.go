@@ -0,0 +1,53 @@
+package capi_client
+
+import "fmt"
+
+const (
+	DropletStateAwaitingUpload   = "AWAITING_UPLOAD"
+	DropletStateProcessingUpload = "PROCESSING_UPLOAD"
+	DropletStateStaged           = "STAGED"
+	DropletStateCopying          = "COPYING"
+	DropletStateFailed           = "FAILED"
+	DropletStateExpired          = "EXPIRED"
+)
+
+type Droplet struct {
+	Resource
+	State         string               `json:"state"`
+	Error         string               `json:"error"`
+	Image         string               `json:"image"`
+	Lifecycle     Lifecycle            `json:"lifecycle"`
+	ProcessTypes  map[string]string    `json:"process_types"`
+	Relationships DropletRelationships `json:"relationships"`
+}
+
+type DropletRelationships struct {
+	App Relationship `json:"app"`
+}
+
+type CreateDropletRequest struct {
+	Relationships DropletRelationships `json:"relationships"`
+	ProcessTypes  map[string]string    `json:"process_types,omitempty"`
+}
+
+func (c *Client) CreateDroplet(request CreateDropletRequest) (Droplet, error) {
+	var droplet Droplet
+	err := c.Post("/v3/droplets", request, &droplet)
+	return droplet, err
+}
+
+func (c *Client) GetDroplet(dropletGUID string) (Droplet, error) {
+	var droplet Droplet
+	err := c.Get(dropletPath(dropletGUID), &droplet)
+	return droplet, err
+}
+
+func (c *Client) UploadDropletBits(dropletGUID, tgzPath string) (Droplet, error) {
+	var droplet Droplet
+	err := c.uploadFile(dropletPath(dropletGUID)+"/upload", tgzPath, &droplet)
+	return droplet, err
+}
+
+func dropletPath(dropletGUID string) string {
+	return fmt.Sprintf("/v3/droplets/%s", dropletGUID)
+}
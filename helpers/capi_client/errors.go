@@ -0,0 +1,69 @@
+package capi_client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Error is a single entry of the "errors" array Cloud Controller returns
+// for failed requests and failed jobs.
+type Error struct {
+	Code   int    `json:"code"`
+	Title  string `json:"title"`
+	Detail string `json:"detail"`
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("%s (%d): %s", e.Title, e.Code, e.Detail)
+}
+
+type ResponseError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Errors     []Error
+	Body       string
+}
+
+func (e ResponseError) Error() string {
+	if len(e.Errors) == 0 {
+		return fmt.Sprintf("%s %s: %d %s", e.Method, e.Path, e.StatusCode, e.Body)
+	}
+
+	details := make([]string, 0, len(e.Errors))
+	for _, cfErr := range e.Errors {
+		details = append(details, cfErr.Error())
+	}
+	return fmt.Sprintf("%s %s: %d %s", e.Method, e.Path, e.StatusCode, strings.Join(details, "; "))
+}
+
+func (e ResponseError) HasTitle(title string) bool {
+	for _, cfErr := range e.Errors {
+		if cfErr.Title == title {
+			return true
+		}
+	}
+	return false
+}
+
+func IsNotFound(err error) bool {
+	responseErr, ok := err.(ResponseError)
+	return ok && responseErr.StatusCode == http.StatusNotFound
+}
+
+func newResponseError(req *http.Request, resp *http.Response, body []byte) ResponseError {
+	var errorsJSON struct {
+		Errors []Error `json:"errors"`
+	}
+	_ = json.Unmarshal(body, &errorsJSON)
+
+	return ResponseError{
+		Method:     req.Method,
+		Path:       req.URL.Path,
+		StatusCode: resp.StatusCode,
+		Errors:     errorsJSON.Errors,
+		Body:       string(body),
+	}
+}
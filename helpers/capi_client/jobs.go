@@ -0,0 +1,127 @@
+package capi_client
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	JobStateProcessing = "PROCESSING"
+	JobStatePolling    = "POLLING"
+	JobStateComplete   = "COMPLETE"
+	JobStateFailed     = "FAILED"
+)
+
+type Job struct {
+	Resource
+	Operation string       `json:"operation"`
+	State     string       `json:"state"`
+	Errors    []Error      `json:"errors"`
+	Warnings  []JobWarning `json:"warnings"`
+}
+
+type JobWarning struct {
+	Detail string `json:"detail"`
+}
+
+func (j Job) IsTerminal() bool {
+	return j.State == JobStateComplete || j.State == JobStateFailed
+}
+
+// JobStateError is returned when a job reaches a terminal state other than
+// the one the caller was waiting for.
+type JobStateError struct {
+	JobPath  string
+	Expected string
+	Job      Job
+}
+
+func (e JobStateError) Error() string {
+	details := make([]string, 0, len(e.Job.Errors))
+	for _, jobErr := range e.Job.Errors {
+		details = append(details, jobErr.Error())
+	}
+	if len(details) == 0 {
+		details = append(details, "no errors reported")
+	}
+
+	return fmt.Sprintf("job %s (%s) reached state %s, expected %s: %s",
+		e.JobPath, e.Job.Operation, e.Job.State, e.Expected, strings.Join(details, "; "))
+}
+
+type JobTimeoutError struct {
+	JobPath  string
+	Expected string
+	Timeout  time.Duration
+	Job      Job
+}
+
+func (e JobTimeoutError) Error() string {
+	return fmt.Sprintf("timed out after %s waiting for job %s (%s) to reach state %s, last state was %s",
+		e.Timeout, e.JobPath, e.Job.Operation, e.Expected, e.Job.State)
+}
+
+// JobPoll is the outcome of WaitForJob. States lists every distinct state
+// the job was observed in, in order, e.g. [PROCESSING POLLING COMPLETE].
+type JobPoll struct {
+	Job    Job
+	States []string
+}
+
+// GetJob accepts either a job GUID or a job path such as "/v3/jobs/<guid>".
+func (c *Client) GetJob(jobGUIDOrPath string) (Job, error) {
+	path := jobGUIDOrPath
+	if !strings.Contains(path, "/") {
+		path = fmt.Sprintf("/v3/jobs/%s", jobGUIDOrPath)
+	}
+
+	var job Job
+	err := c.Get(path, &job)
+	return job, err
+}
+
+// WaitForJob polls the job until it reaches a terminal state or the timeout
+// expires. Reaching a terminal state other than expectedState returns a
+// JobStateError straight away rather than waiting for the timeout.
+func (c *Client) WaitForJob(jobPath, expectedState string, timeout, interval time.Duration) (JobPoll, error) {
+	var poll JobPoll
+	deadline := time.Now().Add(timeout)
+
+	for {
+		job, err := c.GetJob(jobPath)
+		if err != nil {
+			return poll, err
+		}
+
+		poll.Job = job
+		if len(poll.States) == 0 || poll.States[len(poll.States)-1] != job.State {
+			poll.States = append(poll.States, job.State)
+		}
+
+		if job.State == expectedState {
+			return poll, nil
+		}
+		if job.IsTerminal() {
+			return poll, JobStateError{JobPath: jobPath, Expected: expectedState, Job: job}
+		}
+		if time.Now().Add(interval).After(deadline) {
+			return poll, JobTimeoutError{JobPath: jobPath, Expected: expectedState, Timeout: timeout, Job: job}
+		}
+
+		time.Sleep(interval)
+	}
+}
+
+// JobPathFromLocation returns the path of the job a Location header points at.
+func JobPathFromLocation(location string) (string, error) {
+	locationURL, err := url.Parse(strings.TrimSpace(location))
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(locationURL.Path, "/v3/jobs/") {
+		return "", fmt.Errorf("location %q does not point at a job", location)
+	}
+	return locationURL.Path, nil
+}
@@ -0,0 +1,99 @@
+package capi_client_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/cloudfoundry/capi-bara-tests/helpers/capi_client"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WaitForJob", func() {
+	var (
+		server   *httptest.Server
+		client   *capi_client.Client
+		states   []string
+		errors   string
+		requests int
+	)
+
+	BeforeEach(func() {
+		requests = 0
+		errors = `[]`
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.URL.Path).To(Equal("/v3/jobs/job-guid"))
+
+			state := states[len(states)-1]
+			if requests < len(states) {
+				state = states[requests]
+			}
+			requests++
+
+			io.WriteString(w, fmt.Sprintf(`{
+				"guid": "job-guid",
+				"operation": "app.apply_manifest",
+				"state": "%s",
+				"errors": %s,
+				"warnings": [{"detail": "something to note"}]
+			}`, state, errors))
+		}))
+
+		client = capi_client.NewClient(server.URL, func() string { return "bearer some-token" }, false)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("records each state transition until the expected state", func() {
+		states = []string{"PROCESSING", "PROCESSING", "POLLING", "COMPLETE"}
+
+		poll, err := client.WaitForJob("/v3/jobs/job-guid", capi_client.JobStateComplete, time.Second, time.Millisecond)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(poll.States).To(Equal([]string{"PROCESSING", "POLLING", "COMPLETE"}))
+		Expect(poll.Job.Warnings).To(ConsistOf(capi_client.JobWarning{Detail: "something to note"}))
+	})
+
+	It("fails fast with the job errors when the job fails unexpectedly", func() {
+		states = []string{"PROCESSING", "FAILED"}
+		errors = `[{"code": 10008, "title": "CF-UnprocessableEntity", "detail": "memory too small"}]`
+
+		poll, err := client.WaitForJob("job-guid", capi_client.JobStateComplete, time.Minute, time.Millisecond)
+		Expect(err).To(BeAssignableToTypeOf(capi_client.JobStateError{}))
+		Expect(err).To(MatchError(ContainSubstring("memory too small")))
+		Expect(poll.Job.Errors).To(HaveLen(1))
+		Expect(requests).To(Equal(2))
+	})
+
+	It("treats COMPLETE as unexpected when waiting for a failure", func() {
+		states = []string{"COMPLETE"}
+
+		_, err := client.WaitForJob("job-guid", capi_client.JobStateFailed, time.Minute, time.Millisecond)
+		Expect(err).To(MatchError(ContainSubstring("reached state COMPLETE, expected FAILED")))
+	})
+
+	It("gives up once the timeout expires", func() {
+		states = []string{"PROCESSING"}
+
+		poll, err := client.WaitForJob("job-guid", capi_client.JobStateComplete, 20*time.Millisecond, 5*time.Millisecond)
+		Expect(err).To(BeAssignableToTypeOf(capi_client.JobTimeoutError{}))
+		Expect(poll.Job.State).To(Equal("PROCESSING"))
+	})
+})
+
+var _ = Describe("JobPathFromLocation", func() {
+	It("returns the path of the job", func() {
+		Expect(capi_client.JobPathFromLocation(" https://api.example.com/v3/jobs/job-guid\r")).To(Equal("/v3/jobs/job-guid"))
+	})
+
+	It("rejects locations that are not jobs", func() {
+		_, err := capi_client.JobPathFromLocation("https://api.example.com/v3/apps/app-guid")
+		Expect(err).To(HaveOccurred())
+	})
+})
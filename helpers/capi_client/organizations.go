@@ -0,0 +1,21 @@
+package capi_client
+
+import (
+	"fmt"
+	"net/http"
+)
+
+type Organization struct {
+	Resource
+	Name string `json:"name"`
+}
+
+func (c *Client) ListOrganizations(options ListOptions) ([]Organization, error) {
+	return List[Organization](c, "/v3/organizations", options)
+}
+
+// DeleteOrganization returns the path of the job deleting the organization
+// and everything in it.
+func (c *Client) DeleteOrganization(orgGUID string) (string, error) {
+	return c.DoAsync(http.MethodDelete, fmt.Sprintf("/v3/organizations/%s", orgGUID), nil)
+}
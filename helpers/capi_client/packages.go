@@ -0,0 +1,89 @@
+package capi_client
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+const (
+	PackageStateAwaitingUpload   = "AWAITING_UPLOAD"
+	PackageStateProcessingUpload = "PROCESSING_UPLOAD"
+	PackageStateReady            = "READY"
+	PackageStateFailed           = "FAILED"
+)
+
+type Package struct {
+	Resource
+	Type          string               `json:"type"`
+	State         string               `json:"state"`
+	Relationships PackageRelationships `json:"relationships"`
+}
+
+type PackageRelationships struct {
+	App Relationship `json:"app"`
+}
+
+type CreatePackageRequest struct {
+	Type          string               `json:"type"`
+	Relationships PackageRelationships `json:"relationships"`
+}
+
+func (c *Client) CreatePackage(appGUID string) (Package, error) {
+	var pkg Package
+	err := c.Post("/v3/packages", CreatePackageRequest{
+		Type:          "bits",
+		Relationships: PackageRelationships{App: NewRelationship(appGUID)},
+	}, &pkg)
+	return pkg, err
+}
+
+func (c *Client) GetPackage(packageGUID string) (Package, error) {
+	var pkg Package
+	err := c.Get(packagePath(packageGUID), &pkg)
+	return pkg, err
+}
+
+func (c *Client) UploadPackageBits(packageGUID, zipPath string) (Package, error) {
+	var pkg Package
+	err := c.uploadFile(packagePath(packageGUID)+"/upload", zipPath, &pkg)
+	return pkg, err
+}
+
+func (c *Client) uploadFile(path, filePath string, result interface{}) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("bits", filepath.Base(filePath))
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(part, file); err != nil {
+		return err
+	}
+	if err = writer.Close(); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.URL(path), body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	_, err = c.send(req, result)
+	return err
+}
+
+func packagePath(packageGUID string) string {
+	return fmt.Sprintf("/v3/packages/%s", packageGUID)
+}
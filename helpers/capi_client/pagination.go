@@ -0,0 +1,144 @@
+package capi_client
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+type Pagination struct {
+	TotalResults int   `json:"total_results"`
+	TotalPages   int   `json:"total_pages"`
+	First        *Link `json:"first"`
+	Last         *Link `json:"last"`
+	Next         *Link `json:"next"`
+	Previous     *Link `json:"previous"`
+}
+
+type page[T any] struct {
+	Pagination Pagination `json:"pagination"`
+	Resources  []T        `json:"resources"`
+}
+
+// ListOptions are the query parameters shared by every v3 list endpoint.
+// Filters holds field filters such as "names", "types" or "after_guid";
+// multiple values for one filter are sent comma separated.
+type ListOptions struct {
+	PerPage       int
+	OrderBy       string
+	LabelSelector string
+	Filters       url.Values
+}
+
+// Filter returns a copy of the options with the given field filter set.
+func (o ListOptions) Filter(name string, values ...string) ListOptions {
+	filters := url.Values{}
+	for key, existing := range o.Filters {
+		filters[key] = append([]string{}, existing...)
+	}
+	filters.Set(name, strings.Join(values, ","))
+
+	o.Filters = filters
+	return o
+}
+
+func (o ListOptions) Query() url.Values {
+	query := url.Values{}
+	for name, values := range o.Filters {
+		query.Set(name, strings.Join(values, ","))
+	}
+	if o.PerPage > 0 {
+		query.Set("per_page", strconv.Itoa(o.PerPage))
+	}
+	if o.OrderBy != "" {
+		query.Set("order_by", o.OrderBy)
+	}
+	if o.LabelSelector != "" {
+		query.Set("label_selector", o.LabelSelector)
+	}
+	return query
+}
+
+// Iterator walks every resource of a v3 list endpoint, requesting the next
+// page from pagination.next.href only once the current page is exhausted.
+type Iterator[T any] struct {
+	client  *Client
+	nextURL string
+	visited map[string]bool
+	page    []T
+	current T
+	err     error
+}
+
+func NewIterator[T any](client *Client, path string, options ListOptions) *Iterator[T] {
+	if query := options.Query().Encode(); query != "" {
+		separator := "?"
+		if strings.Contains(path, "?") {
+			separator = "&"
+		}
+		path += separator + query
+	}
+
+	return &Iterator[T]{
+		client:  client,
+		nextURL: path,
+		visited: map[string]bool{},
+	}
+}
+
+func (it *Iterator[T]) Next() bool {
+	for len(it.page) == 0 {
+		if it.err != nil || it.nextURL == "" {
+			return false
+		}
+		it.fetch()
+	}
+
+	it.current = it.page[0]
+	it.page = it.page[1:]
+	return true
+}
+
+func (it *Iterator[T]) Value() T {
+	return it.current
+}
+
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// All drains the iterator and returns every remaining resource.
+func (it *Iterator[T]) All() ([]T, error) {
+	resources := []T{}
+	for it.Next() {
+		resources = append(resources, it.Value())
+	}
+	return resources, it.Err()
+}
+
+func (it *Iterator[T]) fetch() {
+	requestURL := it.nextURL
+	if it.visited[requestURL] {
+		it.err = fmt.Errorf("pagination loop detected at %s", requestURL)
+		return
+	}
+	it.visited[requestURL] = true
+
+	var result page[T]
+	if err := it.client.Get(requestURL, &result); err != nil {
+		it.err = err
+		return
+	}
+
+	it.page = result.Resources
+	it.nextURL = ""
+	if result.Pagination.Next != nil {
+		it.nextURL = result.Pagination.Next.Href
+	}
+}
+
+// List returns the resources from every page of a v3 list endpoint.
+func List[T any](client *Client, path string, options ListOptions) ([]T, error) {
+	return NewIterator[T](client, path, options).All()
+}
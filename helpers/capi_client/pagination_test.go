@@ -0,0 +1,101 @@
+package capi_client_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/cloudfoundry/capi-bara-tests/helpers/capi_client"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Pagination", func() {
+	var (
+		server   *httptest.Server
+		client   *capi_client.Client
+		requests []string
+		pages    map[string]string
+	)
+
+	BeforeEach(func() {
+		requests = nil
+		pages = map[string]string{}
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r.URL.RequestURI())
+			body, ok := pages[r.URL.RequestURI()]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			io.WriteString(w, body)
+		}))
+
+		client = capi_client.NewClient(server.URL, func() string { return "bearer some-token" }, false)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	pageBody := func(next string, guids ...string) string {
+		nextLink := "null"
+		if next != "" {
+			nextLink = fmt.Sprintf(`{"href": "%s%s"}`, server.URL, next)
+		}
+
+		resources := ""
+		for i, guid := range guids {
+			if i > 0 {
+				resources += ","
+			}
+			resources += fmt.Sprintf(`{"guid": "%s"}`, guid)
+		}
+		return fmt.Sprintf(`{"pagination": {"next": %s}, "resources": [%s]}`, nextLink, resources)
+	}
+
+	It("follows pagination.next.href until the last page", func() {
+		pages["/v3/apps/app-guid/revisions"] = pageBody("/v3/apps/app-guid/revisions?page=2", "revision-1", "revision-2")
+		pages["/v3/apps/app-guid/revisions?page=2"] = pageBody("", "revision-3")
+
+		revisions, err := client.ListAppRevisions("app-guid", capi_client.ListOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(revisions).To(HaveLen(3))
+		Expect(revisions[2].GUID).To(Equal("revision-3"))
+		Expect(requests).To(HaveLen(2))
+	})
+
+	It("sends per_page, order_by, label and field filters", func() {
+		pages["/v3/app_usage_events?after_guid=event-guid&label_selector=env%3Dprod&order_by=-created_at&per_page=2"] = pageBody("", "event-1")
+
+		options := capi_client.ListOptions{PerPage: 2, OrderBy: "-created_at", LabelSelector: "env=prod"}.Filter("after_guid", "event-guid")
+		events, err := client.ListAppUsageEvents(options)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(events).To(HaveLen(1))
+	})
+
+	It("only fetches the next page once the current one is exhausted", func() {
+		pages["/v3/app_usage_events?per_page=1"] = pageBody("/v3/app_usage_events?page=2&per_page=1", "event-1")
+
+		events := client.AppUsageEvents(capi_client.ListOptions{PerPage: 1})
+		Expect(events.Next()).To(BeTrue())
+		Expect(events.Value().GUID).To(Equal("event-1"))
+		Expect(requests).To(HaveLen(1))
+	})
+
+	It("fails when the next link points at a page already visited", func() {
+		pages["/v3/apps/app-guid/sidecars"] = pageBody("/v3/apps/app-guid/sidecars?page=2", "sidecar-1")
+		pages["/v3/apps/app-guid/sidecars?page=2"] = pageBody("/v3/apps/app-guid/sidecars?page=2", "sidecar-2")
+
+		_, err := client.ListAppSidecars("app-guid", capi_client.ListOptions{})
+		Expect(err).To(MatchError(ContainSubstring("pagination loop detected")))
+	})
+
+	It("returns request errors", func() {
+		_, err := client.ListAppRoutes("missing-app", capi_client.ListOptions{})
+		Expect(capi_client.IsNotFound(err)).To(BeTrue())
+	})
+})
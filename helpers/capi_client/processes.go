@@ -0,0 +1,118 @@
+package capi_client
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	InstanceStateRunning  = "RUNNING"
+	InstanceStateCrashed  = "CRASHED"
+	InstanceStateStarting = "STARTING"
+	InstanceStateDown     = "DOWN"
+)
+
+type Process struct {
+	Resource
+	Type          string               `json:"type"`
+	Command       string               `json:"command"`
+	Instances     int                  `json:"instances"`
+	MemoryInMB    int                  `json:"memory_in_mb"`
+	DiskInMB      int                  `json:"disk_in_mb"`
+	HealthCheck   HealthCheck          `json:"health_check"`
+	Relationships ProcessRelationships `json:"relationships"`
+}
+
+type ProcessRelationships struct {
+	App      Relationship `json:"app"`
+	Revision Relationship `json:"revision"`
+}
+
+type HealthCheck struct {
+	Type string          `json:"type,omitempty"`
+	Data HealthCheckData `json:"data"`
+}
+
+type HealthCheckData struct {
+	Timeout           *int   `json:"timeout,omitempty"`
+	InvocationTimeout *int   `json:"invocation_timeout,omitempty"`
+	Endpoint          string `json:"endpoint,omitempty"`
+}
+
+type UpdateProcessRequest struct {
+	Command     *string      `json:"command,omitempty"`
+	HealthCheck *HealthCheck `json:"health_check,omitempty"`
+}
+
+type ScaleProcessRequest struct {
+	Instances  *int `json:"instances,omitempty"`
+	MemoryInMB *int `json:"memory_in_mb,omitempty"`
+	DiskInMB   *int `json:"disk_in_mb,omitempty"`
+}
+
+type ProcessInstanceStats struct {
+	Type  string `json:"type"`
+	Index int    `json:"index"`
+	State string `json:"state"`
+	Host  string `json:"host"`
+	// Uptime is in seconds.
+	Uptime           int64          `json:"uptime"`
+	Usage            InstanceUsage  `json:"usage"`
+	MemQuota         *int64         `json:"mem_quota"`
+	DiskQuota        *int64         `json:"disk_quota"`
+	FdsQuota         int64          `json:"fds_quota"`
+	InstancePorts    []InstancePort `json:"instance_ports"`
+	IsolationSegment *string        `json:"isolation_segment"`
+	// Routable is nil on Cloud Controllers that do not report it.
+	Routable *bool   `json:"routable"`
+	Details  *string `json:"details"`
+}
+
+// InstanceUsage is empty for instances that are not running.
+type InstanceUsage struct {
+	Time *time.Time `json:"time"`
+	CPU  float64    `json:"cpu"`
+	Mem  int64      `json:"mem"`
+	Disk int64      `json:"disk"`
+}
+
+type InstancePort struct {
+	External             int `json:"external"`
+	Internal             int `json:"internal"`
+	ExternalTLSProxyPort int `json:"external_tls_proxy_port"`
+	InternalTLSProxyPort int `json:"internal_tls_proxy_port"`
+}
+
+func (c *Client) GetProcess(processGUID string) (Process, error) {
+	var process Process
+	err := c.Get(processPath(processGUID), &process)
+	return process, err
+}
+
+func (c *Client) ListAppProcesses(appGUID string, options ListOptions) ([]Process, error) {
+	return List[Process](c, appPath(appGUID)+"/processes", options)
+}
+
+func (c *Client) UpdateProcess(processGUID string, request UpdateProcessRequest) (Process, error) {
+	var process Process
+	err := c.Patch(processPath(processGUID), request, &process)
+	return process, err
+}
+
+func (c *Client) ScaleAppProcess(appGUID, processType string, request ScaleProcessRequest) (Process, error) {
+	var process Process
+	err := c.Post(fmt.Sprintf("%s/processes/%s/actions/scale", appPath(appGUID), processType), request, &process)
+	return process, err
+}
+
+func (c *Client) GetProcessStats(processGUID string) ([]ProcessInstanceStats, error) {
+	var stats struct {
+		Resources []ProcessInstanceStats `json:"resources"`
+	}
+	err := c.Get(processPath(processGUID)+"/stats", &stats)
+	return stats.Resources, err
+}
+
+func processPath(processGUID string) string {
+	return fmt.Sprintf("/v3/processes/%s", processGUID)
+}
@@ -0,0 +1,74 @@
+package capi_client
+
+import (
+	"fmt"
+	"net/http"
+)
+
+type Quota struct {
+	Resource
+	Name string    `json:"name"`
+	Apps QuotaApps `json:"apps"`
+}
+
+type QuotaApps struct {
+	TotalInstances *int `json:"total_instances,omitempty"`
+	PerAppTasks    *int `json:"per_app_tasks,omitempty"`
+}
+
+type CreateOrgQuotaRequest struct {
+	Name          string                `json:"name"`
+	Apps          QuotaApps             `json:"apps"`
+	Relationships OrgQuotaRelationships `json:"relationships"`
+}
+
+type OrgQuotaRelationships struct {
+	Organizations ToManyRelationship `json:"organizations"`
+}
+
+type CreateSpaceQuotaRequest struct {
+	Name          string                  `json:"name"`
+	Apps          QuotaApps               `json:"apps"`
+	Relationships SpaceQuotaRelationships `json:"relationships"`
+}
+
+type SpaceQuotaRelationships struct {
+	Organization Relationship       `json:"organization"`
+	Spaces       ToManyRelationship `json:"spaces"`
+}
+
+func (c *Client) CreateOrgQuota(request CreateOrgQuotaRequest) (Quota, error) {
+	var quota Quota
+	err := c.Post("/v3/organization_quotas", request, &quota)
+	return quota, err
+}
+
+func (c *Client) ListOrgQuotas(options ListOptions) ([]Quota, error) {
+	return List[Quota](c, "/v3/organization_quotas", options)
+}
+
+// ApplyOrgQuota applies the quota to the organizations, replacing whichever
+// quota they had before.
+func (c *Client) ApplyOrgQuota(quotaGUID string, orgGUIDs ...string) error {
+	var organizations ToManyRelationship
+	for _, orgGUID := range orgGUIDs {
+		organizations.Data = append(organizations.Data, RelationshipData{GUID: orgGUID})
+	}
+	return c.Post(fmt.Sprintf("/v3/organization_quotas/%s/relationships/organizations", quotaGUID), organizations, nil)
+}
+
+// DeleteOrgQuota returns the path of the job deleting the quota.
+func (c *Client) DeleteOrgQuota(quotaGUID string) (string, error) {
+	return c.DoAsync(http.MethodDelete, fmt.Sprintf("/v3/organization_quotas/%s", quotaGUID), nil)
+}
+
+func (c *Client) CreateSpaceQuota(request CreateSpaceQuotaRequest) (Quota, error) {
+	var quota Quota
+	err := c.Post("/v3/space_quotas", request, &quota)
+	return quota, err
+}
+
+// DeleteSpaceQuota returns the path of the job deleting the quota.
+func (c *Client) DeleteSpaceQuota(quotaGUID string) (string, error) {
+	return c.DoAsync(http.MethodDelete, fmt.Sprintf("/v3/space_quotas/%s", quotaGUID), nil)
+}
@@ -0,0 +1,58 @@
+package capi_client
+
+import "time"
+
+type Relationship struct {
+	Data *RelationshipData `json:"data"`
+}
+
+type ToManyRelationship struct {
+	Data []RelationshipData `json:"data"`
+}
+
+type RelationshipData struct {
+	GUID string `json:"guid"`
+}
+
+func NewRelationship(guid string) Relationship {
+	return Relationship{Data: &RelationshipData{GUID: guid}}
+}
+
+func (r Relationship) GUID() string {
+	if r.Data == nil {
+		return ""
+	}
+	return r.Data.GUID
+}
+
+type GUIDRef struct {
+	GUID string `json:"guid"`
+}
+
+type Link struct {
+	Href   string `json:"href"`
+	Method string `json:"method,omitempty"`
+}
+
+type Metadata struct {
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type Lifecycle struct {
+	Type string        `json:"type"`
+	Data LifecycleData `json:"data"`
+}
+
+type LifecycleData struct {
+	Buildpacks []string `json:"buildpacks,omitempty"`
+	Stack      string   `json:"stack,omitempty"`
+}
+
+type Resource struct {
+	GUID      string          `json:"guid"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+	Metadata  Metadata        `json:"metadata"`
+	Links     map[string]Link `json:"links,omitempty"`
+}
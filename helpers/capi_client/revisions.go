@@ -0,0 +1,44 @@
+package capi_client
+
+import "fmt"
+
+type Revision struct {
+	Resource
+	Version     int                        `json:"version"`
+	Description string                     `json:"description"`
+	Deployable  bool                       `json:"deployable"`
+	Droplet     GUIDRef                    `json:"droplet"`
+	Processes   map[string]RevisionProcess `json:"processes"`
+	Sidecars    []RevisionSidecar          `json:"sidecars"`
+}
+
+type RevisionProcess struct {
+	Command string `json:"command"`
+}
+
+type RevisionSidecar struct {
+	Name         string   `json:"name"`
+	Command      string   `json:"command"`
+	ProcessTypes []string `json:"process_types"`
+	MemoryInMB   int      `json:"memory_in_mb"`
+}
+
+func (c *Client) GetRevision(revisionGUID string) (Revision, error) {
+	var revision Revision
+	err := c.Get(revisionPath(revisionGUID), &revision)
+	return revision, err
+}
+
+func (c *Client) ListAppRevisions(appGUID string, options ListOptions) ([]Revision, error) {
+	return List[Revision](c, appPath(appGUID)+"/revisions", options)
+}
+
+func (c *Client) GetRevisionEnvironmentVariables(revisionGUID string) (EnvironmentVariables, error) {
+	var envVars EnvironmentVariables
+	err := c.Get(revisionPath(revisionGUID)+"/environment_variables", &envVars)
+	return envVars, err
+}
+
+func revisionPath(revisionGUID string) string {
+	return fmt.Sprintf("/v3/revisions/%s", revisionGUID)
+}
@@ -0,0 +1,93 @@
+package capi_client
+
+import (
+	"fmt"
+	"net/http"
+)
+
+type Route struct {
+	Resource
+	Host          string             `json:"host"`
+	Path          string             `json:"path"`
+	URL           string             `json:"url"`
+	Destinations  []Destination      `json:"destinations"`
+	Relationships RouteRelationships `json:"relationships"`
+}
+
+type RouteRelationships struct {
+	Space  Relationship `json:"space"`
+	Domain Relationship `json:"domain"`
+}
+
+type CreateRouteRequest struct {
+	Host          string             `json:"host,omitempty"`
+	Path          string             `json:"path,omitempty"`
+	Relationships RouteRelationships `json:"relationships"`
+}
+
+type DestinationProcess struct {
+	Type string `json:"type"`
+}
+
+type DestinationApp struct {
+	GUID    string              `json:"guid"`
+	Process *DestinationProcess `json:"process,omitempty"`
+}
+
+type Destination struct {
+	GUID   string         `json:"guid,omitempty"`
+	App    DestinationApp `json:"app"`
+	Port   int            `json:"port,omitempty"`
+	Weight int            `json:"weight,omitempty"`
+}
+
+type Destinations struct {
+	Destinations []Destination `json:"destinations"`
+}
+
+func (c *Client) CreateRoute(request CreateRouteRequest) (Route, error) {
+	var route Route
+	err := c.Post("/v3/routes", request, &route)
+	return route, err
+}
+
+func (c *Client) GetRoute(routeGUID string) (Route, error) {
+	var route Route
+	err := c.Get(routePath(routeGUID), &route)
+	return route, err
+}
+
+// DeleteRoute returns the path of the job deleting the route.
+func (c *Client) DeleteRoute(routeGUID string) (string, error) {
+	return c.DoAsync(http.MethodDelete, routePath(routeGUID), nil)
+}
+
+func (c *Client) ListAppRoutes(appGUID string, options ListOptions) ([]Route, error) {
+	return List[Route](c, appPath(appGUID)+"/routes", options)
+}
+
+func (c *Client) ListRouteDestinations(routeGUID string) ([]Destination, error) {
+	var destinations Destinations
+	err := c.Get(routePath(routeGUID)+"/destinations", &destinations)
+	return destinations.Destinations, err
+}
+
+func (c *Client) InsertRouteDestinations(routeGUID string, destinations []Destination) ([]Destination, error) {
+	var response Destinations
+	err := c.Post(routePath(routeGUID)+"/destinations", Destinations{Destinations: destinations}, &response)
+	return response.Destinations, err
+}
+
+func (c *Client) ReplaceRouteDestinations(routeGUID string, destinations []Destination) ([]Destination, error) {
+	if destinations == nil {
+		destinations = []Destination{}
+	}
+
+	var response Destinations
+	err := c.Patch(routePath(routeGUID)+"/destinations", Destinations{Destinations: destinations}, &response)
+	return response.Destinations, err
+}
+
+func routePath(routeGUID string) string {
+	return fmt.Sprintf("/v3/routes/%s", routeGUID)
+}
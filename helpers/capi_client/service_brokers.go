@@ -0,0 +1,21 @@
+package capi_client
+
+import (
+	"fmt"
+	"net/http"
+)
+
+type ServiceBroker struct {
+	Resource
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+func (c *Client) ListServiceBrokers(options ListOptions) ([]ServiceBroker, error) {
+	return List[ServiceBroker](c, "/v3/service_brokers", options)
+}
+
+// DeleteServiceBroker returns the path of the job deleting the broker.
+func (c *Client) DeleteServiceBroker(brokerGUID string) (string, error) {
+	return c.DoAsync(http.MethodDelete, fmt.Sprintf("/v3/service_brokers/%s", brokerGUID), nil)
+}
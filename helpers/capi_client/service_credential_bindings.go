@@ -0,0 +1,84 @@
+package capi_client
+
+import (
+	"fmt"
+	"net/http"
+)
+
+const (
+	ServiceCredentialBindingTypeApp = "app"
+	ServiceCredentialBindingTypeKey = "key"
+)
+
+// ServiceCredentialBinding is either an app binding or a service key.
+type ServiceCredentialBinding struct {
+	Resource
+	Name          string                                `json:"name"`
+	Type          string                                `json:"type"`
+	LastOperation LastOperation                         `json:"last_operation"`
+	Relationships ServiceCredentialBindingRelationships `json:"relationships"`
+}
+
+// ServiceCredentialBindingRelationships leaves out the app for service
+// keys.
+type ServiceCredentialBindingRelationships struct {
+	App             *Relationship `json:"app,omitempty"`
+	ServiceInstance Relationship  `json:"service_instance"`
+}
+
+type CreateServiceCredentialBindingRequest struct {
+	Type          string                                `json:"type"`
+	Name          string                                `json:"name,omitempty"`
+	Parameters    map[string]interface{}                `json:"parameters,omitempty"`
+	Relationships ServiceCredentialBindingRelationships `json:"relationships"`
+}
+
+// ServiceCredentialBindingDetails are the credentials the broker returned
+// for the binding.
+type ServiceCredentialBindingDetails struct {
+	Credentials    map[string]interface{} `json:"credentials"`
+	SyslogDrainURL string                 `json:"syslog_drain_url,omitempty"`
+}
+
+// CreateServiceCredentialBinding returns the binding when Cloud Controller
+// creates it straight away, as it does for user-provided instances, or else
+// the path of the job asking the broker for credentials.
+func (c *Client) CreateServiceCredentialBinding(request CreateServiceCredentialBindingRequest) (ServiceCredentialBinding, string, error) {
+	var binding ServiceCredentialBinding
+	jobPath, err := c.DoMaybeAsync(http.MethodPost, "/v3/service_credential_bindings", request, &binding)
+	return binding, jobPath, err
+}
+
+func (c *Client) GetServiceCredentialBinding(bindingGUID string) (ServiceCredentialBinding, error) {
+	var binding ServiceCredentialBinding
+	err := c.Get(serviceCredentialBindingPath(bindingGUID), &binding)
+	return binding, err
+}
+
+func (c *Client) ListServiceCredentialBindings(options ListOptions) ([]ServiceCredentialBinding, error) {
+	return List[ServiceCredentialBinding](c, "/v3/service_credential_bindings", options)
+}
+
+// DeleteServiceCredentialBinding returns the path of the job unbinding it,
+// or "" if it was deleted straight away.
+func (c *Client) DeleteServiceCredentialBinding(bindingGUID string) (string, error) {
+	return c.DoMaybeAsync(http.MethodDelete, serviceCredentialBindingPath(bindingGUID), nil, nil)
+}
+
+func (c *Client) GetServiceCredentialBindingDetails(bindingGUID string) (ServiceCredentialBindingDetails, error) {
+	var details ServiceCredentialBindingDetails
+	err := c.Get(serviceCredentialBindingPath(bindingGUID)+"/details", &details)
+	return details, err
+}
+
+// GetServiceCredentialBindingParameters fetches the binding's parameters
+// from the broker.
+func (c *Client) GetServiceCredentialBindingParameters(bindingGUID string) (map[string]interface{}, error) {
+	parameters := map[string]interface{}{}
+	err := c.Get(serviceCredentialBindingPath(bindingGUID)+"/parameters", &parameters)
+	return parameters, err
+}
+
+func serviceCredentialBindingPath(bindingGUID string) string {
+	return fmt.Sprintf("/v3/service_credential_bindings/%s", bindingGUID)
+}
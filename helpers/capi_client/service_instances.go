@@ -0,0 +1,138 @@
+package capi_client
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	ServiceInstanceTypeManaged      = "managed"
+	ServiceInstanceTypeUserProvided = "user-provided"
+)
+
+const (
+	LastOperationTypeCreate = "create"
+	LastOperationTypeUpdate = "update"
+	LastOperationTypeDelete = "delete"
+
+	LastOperationStateInProgress = "in progress"
+	LastOperationStateSucceeded  = "succeeded"
+	LastOperationStateFailed     = "failed"
+)
+
+// LastOperation is the latest operation on a service instance or binding,
+// which for asynchronous brokers stays in progress while Cloud Controller
+// polls the broker.
+type LastOperation struct {
+	Type        string    `json:"type"`
+	State       string    `json:"state"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ServiceInstance has an upgrade available when its plan's maintenance_info
+// is at another version than its own.
+type ServiceInstance struct {
+	Resource
+	Name             string                       `json:"name"`
+	Type             string                       `json:"type"`
+	Tags             []string                     `json:"tags"`
+	LastOperation    LastOperation                `json:"last_operation"`
+	MaintenanceInfo  MaintenanceInfo              `json:"maintenance_info"`
+	UpgradeAvailable bool                         `json:"upgrade_available"`
+	SyslogDrainURL   string                       `json:"syslog_drain_url,omitempty"`
+	RouteServiceURL  string                       `json:"route_service_url,omitempty"`
+	DashboardURL     *string                      `json:"dashboard_url,omitempty"`
+	Relationships    ServiceInstanceRelationships `json:"relationships"`
+}
+
+// ServiceInstanceRelationships leaves out the plan for user-provided
+// instances.
+type ServiceInstanceRelationships struct {
+	Space       Relationship  `json:"space"`
+	ServicePlan *Relationship `json:"service_plan,omitempty"`
+}
+
+type CreateServiceInstanceRequest struct {
+	Type       string                 `json:"type"`
+	Name       string                 `json:"name"`
+	Tags       []string               `json:"tags,omitempty"`
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+	// Credentials, SyslogDrainURL and RouteServiceURL are only for
+	// user-provided instances.
+	Credentials     map[string]interface{}       `json:"credentials,omitempty"`
+	SyslogDrainURL  string                       `json:"syslog_drain_url,omitempty"`
+	RouteServiceURL string                       `json:"route_service_url,omitempty"`
+	Relationships   ServiceInstanceRelationships `json:"relationships"`
+}
+
+type UpdateServiceInstanceRequest struct {
+	Name        *string                `json:"name,omitempty"`
+	Tags        []string               `json:"tags,omitempty"`
+	Parameters  map[string]interface{} `json:"parameters,omitempty"`
+	Credentials map[string]interface{} `json:"credentials,omitempty"`
+	// MaintenanceInfo upgrades a managed instance to the version of its
+	// plan.
+	MaintenanceInfo *MaintenanceInfo `json:"maintenance_info,omitempty"`
+	// Relationships changes the plan of a managed instance.
+	Relationships *UpdateServiceInstanceRelationships `json:"relationships,omitempty"`
+}
+
+type UpdateServiceInstanceRelationships struct {
+	ServicePlan Relationship `json:"service_plan"`
+}
+
+// CreateServiceInstance returns the instance when Cloud Controller creates
+// it straight away, as it does user-provided instances, or else the path of
+// the job provisioning it.
+func (c *Client) CreateServiceInstance(request CreateServiceInstanceRequest) (ServiceInstance, string, error) {
+	var instance ServiceInstance
+	jobPath, err := c.DoMaybeAsync(http.MethodPost, "/v3/service_instances", request, &instance)
+	return instance, jobPath, err
+}
+
+func (c *Client) GetServiceInstance(instanceGUID string) (ServiceInstance, error) {
+	var instance ServiceInstance
+	err := c.Get(serviceInstancePath(instanceGUID), &instance)
+	return instance, err
+}
+
+func (c *Client) ListServiceInstances(options ListOptions) ([]ServiceInstance, error) {
+	return List[ServiceInstance](c, "/v3/service_instances", options)
+}
+
+// UpdateServiceInstance returns the updated instance, or the path of the job
+// updating it when the broker is involved.
+func (c *Client) UpdateServiceInstance(instanceGUID string, request UpdateServiceInstanceRequest) (ServiceInstance, string, error) {
+	var instance ServiceInstance
+	jobPath, err := c.DoMaybeAsync(http.MethodPatch, serviceInstancePath(instanceGUID), request, &instance)
+	return instance, jobPath, err
+}
+
+// DeleteServiceInstance deletes the instance and its bindings, returning the
+// path of the job deprovisioning it or "" if it was deleted straight away.
+func (c *Client) DeleteServiceInstance(instanceGUID string) (string, error) {
+	return c.DoMaybeAsync(http.MethodDelete, serviceInstancePath(instanceGUID), nil, nil)
+}
+
+// GetServiceInstanceParameters fetches the parameters of a managed instance
+// from its broker.
+func (c *Client) GetServiceInstanceParameters(instanceGUID string) (map[string]interface{}, error) {
+	parameters := map[string]interface{}{}
+	err := c.Get(serviceInstancePath(instanceGUID)+"/parameters", &parameters)
+	return parameters, err
+}
+
+// GetServiceInstanceCredentials returns the credentials of a user-provided
+// instance.
+func (c *Client) GetServiceInstanceCredentials(instanceGUID string) (map[string]interface{}, error) {
+	credentials := map[string]interface{}{}
+	err := c.Get(serviceInstancePath(instanceGUID)+"/credentials", &credentials)
+	return credentials, err
+}
+
+func serviceInstancePath(instanceGUID string) string {
+	return fmt.Sprintf("/v3/service_instances/%s", instanceGUID)
+}
@@ -0,0 +1,161 @@
+package capi_client
+
+import "fmt"
+
+const (
+	ServicePlanVisibilityPublic       = "public"
+	ServicePlanVisibilityAdmin        = "admin"
+	ServicePlanVisibilityOrganization = "organization"
+	ServicePlanVisibilitySpace        = "space"
+)
+
+type ServiceOffering struct {
+	Resource
+	Name          string                       `json:"name"`
+	Description   string                       `json:"description"`
+	Available     bool                         `json:"available"`
+	Tags          []string                     `json:"tags"`
+	Shareable     bool                         `json:"shareable"`
+	BrokerCatalog ServiceOfferingBrokerCatalog `json:"broker_catalog"`
+	Relationships ServiceOfferingRelationships `json:"relationships"`
+}
+
+// ServiceOfferingBrokerCatalog is the offering as the broker's catalog
+// describes it.
+type ServiceOfferingBrokerCatalog struct {
+	ID       string                  `json:"id"`
+	Features ServiceOfferingFeatures `json:"features"`
+}
+
+type ServiceOfferingFeatures struct {
+	PlanUpdateable       bool `json:"plan_updateable"`
+	Bindable             bool `json:"bindable"`
+	InstancesRetrievable bool `json:"instances_retrievable"`
+	BindingsRetrievable  bool `json:"bindings_retrievable"`
+	AllowContextUpdates  bool `json:"allow_context_updates"`
+}
+
+type ServiceOfferingRelationships struct {
+	ServiceBroker Relationship `json:"service_broker"`
+}
+
+type ServicePlan struct {
+	Resource
+	Name            string                   `json:"name"`
+	Description     string                   `json:"description"`
+	Free            bool                     `json:"free"`
+	Available       bool                     `json:"available"`
+	VisibilityType  string                   `json:"visibility_type"`
+	MaintenanceInfo MaintenanceInfo          `json:"maintenance_info"`
+	BrokerCatalog   ServicePlanBrokerCatalog `json:"broker_catalog"`
+	Schemas         ServicePlanSchemas       `json:"schemas"`
+	Relationships   ServicePlanRelationships `json:"relationships"`
+}
+
+// MaintenanceInfo is the version of a plan in the broker's catalog, and
+// the version a service instance was provisioned or last upgraded at. The
+// Version is empty for plans that do not support upgrades.
+type MaintenanceInfo struct {
+	Version     string `json:"version,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+type ServicePlanBrokerCatalog struct {
+	ID string `json:"id"`
+}
+
+// ServicePlanSchemas are the JSON schemas of the parameters the plan
+// accepts when creating or updating an instance and when binding to it.
+type ServicePlanSchemas struct {
+	ServiceInstance struct {
+		Create ServicePlanSchema `json:"create"`
+		Update ServicePlanSchema `json:"update"`
+	} `json:"service_instance"`
+	ServiceBinding struct {
+		Create ServicePlanSchema `json:"create"`
+	} `json:"service_binding"`
+}
+
+type ServicePlanSchema struct {
+	Parameters map[string]interface{} `json:"parameters"`
+}
+
+type ServicePlanRelationships struct {
+	ServiceOffering Relationship `json:"service_offering"`
+}
+
+// ServicePlanVisibility is who may create instances of a plan. Organizations
+// is set for the organization type and Space for plans of a space scoped
+// broker.
+type ServicePlanVisibility struct {
+	Type          string                        `json:"type"`
+	Organizations []ServicePlanVisibilityTarget `json:"organizations,omitempty"`
+	Space         *ServicePlanVisibilityTarget  `json:"space,omitempty"`
+}
+
+// ServicePlanVisibilityTarget is an organization or space a plan is
+// visible in. Cloud Controller fills in the name.
+type ServicePlanVisibilityTarget struct {
+	GUID string `json:"guid"`
+	Name string `json:"name,omitempty"`
+}
+
+func (c *Client) GetServiceOffering(offeringGUID string) (ServiceOffering, error) {
+	var offering ServiceOffering
+	err := c.Get(serviceOfferingPath(offeringGUID), &offering)
+	return offering, err
+}
+
+func (c *Client) ListServiceOfferings(options ListOptions) ([]ServiceOffering, error) {
+	return List[ServiceOffering](c, "/v3/service_offerings", options)
+}
+
+// PurgeServiceOffering removes the offering, its plans and instances from
+// Cloud Controller without asking the broker.
+func (c *Client) PurgeServiceOffering(offeringGUID string) error {
+	return c.Delete(serviceOfferingPath(offeringGUID) + "?purge=true")
+}
+
+func (c *Client) GetServicePlan(planGUID string) (ServicePlan, error) {
+	var plan ServicePlan
+	err := c.Get(servicePlanPath(planGUID), &plan)
+	return plan, err
+}
+
+func (c *Client) ListServicePlans(options ListOptions) ([]ServicePlan, error) {
+	return List[ServicePlan](c, "/v3/service_plans", options)
+}
+
+func (c *Client) GetServicePlanVisibility(planGUID string) (ServicePlanVisibility, error) {
+	var visibility ServicePlanVisibility
+	err := c.Get(servicePlanPath(planGUID)+"/visibility", &visibility)
+	return visibility, err
+}
+
+// UpdateServicePlanVisibility replaces the plan's visibility, including the
+// organizations it is enabled for.
+func (c *Client) UpdateServicePlanVisibility(planGUID string, visibility ServicePlanVisibility) (ServicePlanVisibility, error) {
+	var updated ServicePlanVisibility
+	err := c.Patch(servicePlanPath(planGUID)+"/visibility", visibility, &updated)
+	return updated, err
+}
+
+// AppendServicePlanVisibility enables the plan for more organizations,
+// keeping those it is already enabled for.
+func (c *Client) AppendServicePlanVisibility(planGUID string, visibility ServicePlanVisibility) (ServicePlanVisibility, error) {
+	var updated ServicePlanVisibility
+	err := c.Post(servicePlanPath(planGUID)+"/visibility", visibility, &updated)
+	return updated, err
+}
+
+func (c *Client) RemoveServicePlanVisibilityOrg(planGUID, orgGUID string) error {
+	return c.Delete(fmt.Sprintf("%s/visibility/%s", servicePlanPath(planGUID), orgGUID))
+}
+
+func serviceOfferingPath(offeringGUID string) string {
+	return fmt.Sprintf("/v3/service_offerings/%s", offeringGUID)
+}
+
+func servicePlanPath(planGUID string) string {
+	return fmt.Sprintf("/v3/service_plans/%s", planGUID)
+}
@@ -0,0 +1,38 @@
+package capi_client
+
+import "fmt"
+
+type Sidecar struct {
+	Resource
+	Name          string               `json:"name"`
+	Command       string               `json:"command"`
+	ProcessTypes  []string             `json:"process_types"`
+	MemoryInMB    int                  `json:"memory_in_mb"`
+	Origin        string               `json:"origin"`
+	Relationships SidecarRelationships `json:"relationships"`
+}
+
+type SidecarRelationships struct {
+	App Relationship `json:"app"`
+}
+
+type CreateSidecarRequest struct {
+	Name         string   `json:"name"`
+	Command      string   `json:"command"`
+	ProcessTypes []string `json:"process_types"`
+	MemoryInMB   int      `json:"memory_in_mb,omitempty"`
+}
+
+func (c *Client) CreateSidecar(appGUID string, request CreateSidecarRequest) (Sidecar, error) {
+	var sidecar Sidecar
+	err := c.Post(appPath(appGUID)+"/sidecars", request, &sidecar)
+	return sidecar, err
+}
+
+func (c *Client) ListAppSidecars(appGUID string, options ListOptions) ([]Sidecar, error) {
+	return List[Sidecar](c, appPath(appGUID)+"/sidecars", options)
+}
+
+func (c *Client) DeleteSidecar(sidecarGUID string) error {
+	return c.Delete(fmt.Sprintf("/v3/sidecars/%s", sidecarGUID))
+}
@@ -0,0 +1,20 @@
+package capi_client
+
+import (
+	"fmt"
+	"net/http"
+)
+
+type Space struct {
+	Resource
+	Name string `json:"name"`
+}
+
+func (c *Client) ListSpaces(options ListOptions) ([]Space, error) {
+	return List[Space](c, "/v3/spaces", options)
+}
+
+// DeleteSpace returns the path of the job deleting the space.
+func (c *Client) DeleteSpace(spaceGUID string) (string, error) {
+	return c.DoAsync(http.MethodDelete, fmt.Sprintf("/v3/spaces/%s", spaceGUID), nil)
+}
@@ -0,0 +1,77 @@
+package capi_client
+
+import "fmt"
+
+const (
+	TaskStatePending   = "PENDING"
+	TaskStateRunning   = "RUNNING"
+	TaskStateSucceeded = "SUCCEEDED"
+	TaskStateCanceling = "CANCELING"
+	TaskStateFailed    = "FAILED"
+)
+
+type Task struct {
+	Resource
+	SequenceID                   int               `json:"sequence_id"`
+	Name                         string            `json:"name"`
+	Command                      string            `json:"command"`
+	State                        string            `json:"state"`
+	MemoryInMB                   int               `json:"memory_in_mb"`
+	DiskInMB                     int               `json:"disk_in_mb"`
+	LogRateLimitInBytesPerSecond int               `json:"log_rate_limit_in_bytes_per_second"`
+	Result                       TaskResult        `json:"result"`
+	DropletGUID                  string            `json:"droplet_guid"`
+	Relationships                TaskRelationships `json:"relationships"`
+}
+
+type TaskResult struct {
+	FailureReason *string `json:"failure_reason"`
+}
+
+type TaskRelationships struct {
+	App Relationship `json:"app"`
+}
+
+type CreateTaskRequest struct {
+	Name                         string        `json:"name,omitempty"`
+	Command                      string        `json:"command,omitempty"`
+	MemoryInMB                   *int          `json:"memory_in_mb,omitempty"`
+	DiskInMB                     *int          `json:"disk_in_mb,omitempty"`
+	LogRateLimitInBytesPerSecond *int          `json:"log_rate_limit_in_bytes_per_second,omitempty"`
+	DropletGUID                  string        `json:"droplet_guid,omitempty"`
+	Template                     *TaskTemplate `json:"template,omitempty"`
+}
+
+// TaskTemplate makes a task inherit the command and limits of a process
+// where the request leaves them out.
+type TaskTemplate struct {
+	Process GUIDRef `json:"process"`
+}
+
+func (c *Client) CreateTask(appGUID string, request CreateTaskRequest) (Task, error) {
+	var task Task
+	err := c.Post(appPath(appGUID)+"/tasks", request, &task)
+	return task, err
+}
+
+func (c *Client) GetTask(taskGUID string) (Task, error) {
+	var task Task
+	err := c.Get(taskPath(taskGUID), &task)
+	return task, err
+}
+
+func (c *Client) ListAppTasks(appGUID string, options ListOptions) ([]Task, error) {
+	return List[Task](c, appPath(appGUID)+"/tasks", options)
+}
+
+// CancelTask returns the task in the CANCELING state; it is FAILED once
+// Diego has stopped it.
+func (c *Client) CancelTask(taskGUID string) (Task, error) {
+	var task Task
+	err := c.Post(taskPath(taskGUID)+"/actions/cancel", nil, &task)
+	return task, err
+}
+
+func taskPath(taskGUID string) string {
+	return fmt.Sprintf("/v3/tasks/%s", taskGUID)
+}
@@ -0,0 +1,46 @@
+package capi_client
+
+import (
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"strings"
+
+	"github.com/cloudfoundry/capi-bara-tests/helpers/trace"
+)
+
+// traceFile returns the file named by CF_TRACE, so requests made by the
+// client land in the same trace as those made by the cf CLI. Like the CLI,
+// "true" and "false" are not file names; only file tracing is supported.
+func traceFile() string {
+	path := os.Getenv("CF_TRACE")
+	switch strings.ToLower(path) {
+	case "", "true", "false":
+		return ""
+	}
+	return path
+}
+
+// dumpRequest renders req for the trace. Only JSON bodies are included;
+// uploads are left out like the CLI does.
+func dumpRequest(req *http.Request) string {
+	includeBody := strings.HasPrefix(req.Header.Get("Content-Type"), "application/json")
+	dump, err := httputil.DumpRequestOut(req, includeBody)
+	if err != nil {
+		return req.Method + " " + req.URL.String()
+	}
+	if !includeBody && req.Body != nil {
+		mediaType := strings.TrimSpace(strings.Split(req.Header.Get("Content-Type"), ";")[0])
+		return string(dump) + "[" + strings.ToUpper(mediaType) + " CONTENT HIDDEN]"
+	}
+	return string(dump)
+}
+
+func traceExchange(path, request string, resp *http.Response, respBody []byte) {
+	response, err := httputil.DumpResponse(resp, false)
+	if err != nil {
+		return
+	}
+	// a failure to trace must not fail the request being traced
+	_ = trace.Append(path, request, string(response)+string(respBody))
+}
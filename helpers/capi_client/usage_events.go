@@ -0,0 +1,29 @@
+package capi_client
+
+type AppUsageEvent struct {
+	Resource
+	State     UsageEventState `json:"state"`
+	App       UsageEventRef   `json:"app"`
+	Process   UsageEventRef   `json:"process"`
+	Task      UsageEventRef   `json:"task"`
+	Buildpack UsageEventRef   `json:"buildpack"`
+}
+
+type UsageEventState struct {
+	Current  string `json:"current"`
+	Previous string `json:"previous"`
+}
+
+type UsageEventRef struct {
+	GUID string `json:"guid"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+func (c *Client) ListAppUsageEvents(options ListOptions) ([]AppUsageEvent, error) {
+	return List[AppUsageEvent](c, "/v3/app_usage_events", options)
+}
+
+func (c *Client) AppUsageEvents(options ListOptions) *Iterator[AppUsageEvent] {
+	return NewIterator[AppUsageEvent](c, "/v3/app_usage_events", options)
+}
@@ -0,0 +1,167 @@
+// Package cleanup records the resources a spec creates so that they are
+// deleted when the spec ends, however far it got.
+package cleanup
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry/capi-bara-tests/helpers/capi_client"
+)
+
+// DeleteFunc deletes a resource and returns the path of the job deleting
+// it, or "" if Cloud Controller deleted it straight away.
+type DeleteFunc func(client *capi_client.Client) (string, error)
+
+type Resource struct {
+	Kind   string
+	ID     string
+	Delete DeleteFunc
+}
+
+func (r Resource) String() string {
+	return fmt.Sprintf("%s %s", r.Kind, r.ID)
+}
+
+var (
+	trackedMutex     sync.Mutex
+	trackedResources []Resource
+)
+
+// Track records a resource created by the running spec.
+func Track(resource Resource) {
+	trackedMutex.Lock()
+	defer trackedMutex.Unlock()
+	trackedResources = append(trackedResources, resource)
+}
+
+// Forget stops tracking a resource the spec deleted itself, e.g.
+// Forget(App(appGUID)).
+func Forget(deleted Resource) {
+	trackedMutex.Lock()
+	defer trackedMutex.Unlock()
+
+	remaining := trackedResources[:0]
+	for _, resource := range trackedResources {
+		if resource.Kind != deleted.Kind || resource.ID != deleted.ID {
+			remaining = append(remaining, resource)
+		}
+	}
+	trackedResources = remaining
+}
+
+func Tracked() []Resource {
+	trackedMutex.Lock()
+	defer trackedMutex.Unlock()
+	return append([]Resource{}, trackedResources...)
+}
+
+func Reset() {
+	trackedMutex.Lock()
+	defer trackedMutex.Unlock()
+	trackedResources = nil
+}
+
+// DeleteAll deletes every tracked resource, newest first, waiting for each
+// deletion job to complete before moving on, and stops tracking them.
+// Resources that are already gone are skipped; any other failure is
+// collected so that one stuck resource does not leak the rest.
+func DeleteAll(client *capi_client.Client, jobTimeout, jobPollingInterval time.Duration) error {
+	trackedMutex.Lock()
+	resources := trackedResources
+	trackedResources = nil
+	trackedMutex.Unlock()
+
+	var failures []string
+	for i := len(resources) - 1; i >= 0; i-- {
+		if err := deleteResource(client, resources[i], jobTimeout, jobPollingInterval); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %s", resources[i], err))
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("failed to clean up %d resource(s):\n%s", len(failures), strings.Join(failures, "\n"))
+	}
+	return nil
+}
+
+func deleteResource(client *capi_client.Client, resource Resource, jobTimeout, jobPollingInterval time.Duration) error {
+	jobPath, err := resource.Delete(client)
+	if capi_client.IsNotFound(err) {
+		return nil
+	}
+	if err != nil || jobPath == "" {
+		return err
+	}
+
+	_, err = client.WaitForJob(jobPath, capi_client.JobStateComplete, jobTimeout, jobPollingInterval)
+	return err
+}
+
+func App(appGUID string) Resource {
+	return Resource{Kind: "app", ID: appGUID, Delete: func(client *capi_client.Client) (string, error) {
+		return client.DeleteApp(appGUID)
+	}}
+}
+
+func Route(routeGUID string) Resource {
+	return Resource{Kind: "route", ID: routeGUID, Delete: func(client *capi_client.Client) (string, error) {
+		return client.DeleteRoute(routeGUID)
+	}}
+}
+
+func Sidecar(sidecarGUID string) Resource {
+	return Resource{Kind: "sidecar", ID: sidecarGUID, Delete: func(client *capi_client.Client) (string, error) {
+		return "", client.DeleteSidecar(sidecarGUID)
+	}}
+}
+
+func OrgQuota(quotaGUID string) Resource {
+	return Resource{Kind: "organization quota", ID: quotaGUID, Delete: func(client *capi_client.Client) (string, error) {
+		return client.DeleteOrgQuota(quotaGUID)
+	}}
+}
+
+func SpaceQuota(quotaGUID string) Resource {
+	return Resource{Kind: "space quota", ID: quotaGUID, Delete: func(client *capi_client.Client) (string, error) {
+		return client.DeleteSpaceQuota(quotaGUID)
+	}}
+}
+
+// ServiceBroker is tracked by name because the cf CLI registers brokers
+// without reporting their GUID. Its offerings are purged first, as Cloud
+// Controller refuses to delete a broker that still has service instances.
+func ServiceBroker(brokerName string) Resource {
+	return Resource{Kind: "service broker", ID: brokerName, Delete: func(client *capi_client.Client) (string, error) {
+		brokers, err := client.ListServiceBrokers(capi_client.ListOptions{}.Filter("names", brokerName))
+		if err != nil || len(brokers) == 0 {
+			return "", err
+		}
+
+		offerings, err := client.ListServiceOfferings(capi_client.ListOptions{}.Filter("service_broker_guids", brokers[0].GUID))
+		if err != nil {
+			return "", err
+		}
+		for _, offering := range offerings {
+			if err := client.PurgeServiceOffering(offering.GUID); err != nil && !capi_client.IsNotFound(err) {
+				return "", err
+			}
+		}
+
+		return client.DeleteServiceBroker(brokers[0].GUID)
+	}}
+}
+
+func ServiceInstance(instanceGUID string) Resource {
+	return Resource{Kind: "service instance", ID: instanceGUID, Delete: func(client *capi_client.Client) (string, error) {
+		return client.DeleteServiceInstance(instanceGUID)
+	}}
+}
+
+func ServiceCredentialBinding(bindingGUID string) Resource {
+	return Resource{Kind: "service credential binding", ID: bindingGUID, Delete: func(client *capi_client.Client) (string, error) {
+		return client.DeleteServiceCredentialBinding(bindingGUID)
+	}}
+}
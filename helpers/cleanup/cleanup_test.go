@@ -0,0 +1,116 @@
+package cleanup_test
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry/capi-bara-tests/helpers/capi_client"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/cleanup"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/fake_cc"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cleanup", func() {
+	var (
+		fakeCC *fake_cc.FakeCC
+		client *capi_client.Client
+	)
+
+	// recording returns a resource that notes its deletion in deleted.
+	recording := func(id string, deleted *[]string, err error) cleanup.Resource {
+		return cleanup.Resource{Kind: "thing", ID: id, Delete: func(*capi_client.Client) (string, error) {
+			*deleted = append(*deleted, id)
+			return "", err
+		}}
+	}
+
+	deleteAll := func() error {
+		return cleanup.DeleteAll(client, time.Second, time.Millisecond)
+	}
+
+	BeforeEach(func() {
+		cleanup.Reset()
+		fakeCC = fake_cc.New()
+		client = fakeCC.Client()
+	})
+
+	AfterEach(func() {
+		fakeCC.Close()
+	})
+
+	It("deletes tracked resources newest first and stops tracking them", func() {
+		var deleted []string
+		cleanup.Track(recording("first", &deleted, nil))
+		cleanup.Track(recording("second", &deleted, nil))
+		cleanup.Track(recording("third", &deleted, nil))
+
+		Expect(deleteAll()).To(Succeed())
+		Expect(deleted).To(Equal([]string{"third", "second", "first"}))
+		Expect(cleanup.Tracked()).To(BeEmpty())
+	})
+
+	It("does not delete resources the spec deleted itself", func() {
+		var deleted []string
+		cleanup.Track(recording("kept", &deleted, nil))
+		cleanup.Track(recording("gone", &deleted, nil))
+		cleanup.Forget(cleanup.Resource{Kind: "thing", ID: "gone"})
+
+		Expect(deleteAll()).To(Succeed())
+		Expect(deleted).To(Equal([]string{"kept"}))
+	})
+
+	It("waits for deletion jobs to complete", func() {
+		app, err := client.CreateApp(capi_client.CreateAppRequest{
+			Name:          "some-app",
+			Relationships: capi_client.AppRelationships{Space: capi_client.NewRelationship("space-guid")},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		route, err := client.CreateRoute(capi_client.CreateRouteRequest{
+			Host: "some-host",
+			Relationships: capi_client.RouteRelationships{
+				Space:  capi_client.NewRelationship("space-guid"),
+				Domain: capi_client.NewRelationship("domain-guid"),
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		cleanup.Track(cleanup.App(app.GUID))
+		cleanup.Track(cleanup.Route(route.GUID))
+		Expect(deleteAll()).To(Succeed())
+
+		_, err = client.GetApp(app.GUID)
+		Expect(capi_client.IsNotFound(err)).To(BeTrue())
+		_, err = client.GetRoute(route.GUID)
+		Expect(capi_client.IsNotFound(err)).To(BeTrue())
+
+		var jobReads int
+		for _, request := range fakeCC.Requests() {
+			if request.Method == http.MethodGet && strings.HasPrefix(request.Path, "/v3/jobs/") {
+				jobReads++
+			}
+		}
+		Expect(jobReads).To(Equal(4))
+	})
+
+	It("skips resources that are already gone", func() {
+		cleanup.Track(cleanup.App("deleted-app"))
+		Expect(deleteAll()).To(Succeed())
+	})
+
+	It("keeps going after a failure and reports every failure", func() {
+		var deleted []string
+		cleanup.Track(recording("first", &deleted, errors.New("boom")))
+		cleanup.Track(recording("second", &deleted, nil))
+		cleanup.Track(recording("third", &deleted, errors.New("bang")))
+
+		err := deleteAll()
+		Expect(err).To(MatchError(ContainSubstring("failed to clean up 2 resource(s)")))
+		Expect(err).To(MatchError(ContainSubstring("thing third: bang")))
+		Expect(err).To(MatchError(ContainSubstring("thing first: boom")))
+		Expect(deleted).To(Equal([]string{"third", "second", "first"}))
+	})
+})
@@ -1,7 +1,12 @@
 package config
 
 import (
+	"encoding/json"
+	"net/url"
+	"reflect"
 	"time"
+
+	. "github.com/cloudfoundry/capi-bara-tests/helpers/validationerrors"
 )
 
 type BaraConfig interface {
@@ -13,9 +18,19 @@ type BaraConfig interface {
 	GetAdminPassword() string
 	GetAdminUser() string
 
+	// GetAuthToken returns a fixed Authorization header value, or "" to use
+	// the token of the user the cf CLI is logged in as.
+	GetAuthToken() string
+
 	GetSkipSSLValidation() bool
 
 	GetArtifactsDirectory() string
+	// GetFailureTraceExchanges is how many of the last CF API exchanges are
+	// attached to the report of a failed spec; 0 attaches none.
+	GetFailureTraceExchanges() int
+	// GetKeepResourcesOnFailure leaves the resources a failed spec created
+	// through the helpers in place for debugging instead of deleting them.
+	GetKeepResourcesOnFailure() bool
 
 	AsyncServiceOperationTimeoutDuration() time.Duration
 	BrokerStartTimeoutDuration() time.Duration
@@ -39,9 +54,26 @@ type BaraConfig interface {
 
 	GetNamePrefix() string
 
+	GetIncludeDeployments() bool
+	GetIncludeDroplets() bool
+	GetIncludeEvents() bool
+	GetIncludeManifest() bool
+	GetIncludeNginx() bool
+	GetIncludeProcessCommands() bool
+	GetIncludeQuotas() bool
+	GetIncludeRevisions() bool
+	GetIncludeRouting() bool
+	GetIncludeServices() bool
+	GetIncludeSidecars() bool
+	GetIncludeTasks() bool
+	GetIncludeZeroDowntime() bool
+
 	GetReporterConfig() reporterConfig
 
+	RedactedJSON() ([]byte, error)
+
 	Lifecycle() string
+	GetInfrastructure() string
 	GetGcloudProjectName() string
 	GetClusterZone() string
 	GetClusterName() string
@@ -70,3 +102,42 @@ type BaraConfig interface {
 func NewBaraConfig(path string) (BaraConfig, error) {
 	return NewConfig(path)
 }
+
+// NewLocalConfig returns the default config for a Cloud Controller listening
+// at apiURL, e.g. the fake in helpers/fake_cc. It skips validation, which
+// needs DNS, so helper tests can run offline. Each of layers is applied on
+// top in order, as if it were another config file, e.g.
+// {"reporter_config": {"sink": "jsonl"}}.
+func NewLocalConfig(apiURL, authToken string, layers ...map[string]interface{}) (BaraConfig, error) {
+	u, err := url.Parse(apiURL)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := getDefaults()
+	cfg.ApiEndpoint = ptrToString(u.Host)
+	cfg.ApiProtocol = ptrToString(u.Scheme)
+	cfg.AppsDomain = ptrToString(u.Hostname())
+	cfg.AdminUser = ptrToString("admin")
+	cfg.AdminPassword = ptrToString("admin")
+	cfg.AuthToken = ptrToString(authToken)
+	cfg.SkipSSLValidation = ptrToBool(true)
+	cfg.TimeoutScale = ptrToFloat(1.0)
+	cfg.IncludeNginx = ptrToBool(true)
+	cfg.IncludeRouting = ptrToBool(true)
+
+	merged := map[string]interface{}{}
+	for _, layer := range layers {
+		mergeLayer(merged, layer)
+	}
+	raw, err := json.Marshal(merged)
+	if err != nil {
+		return nil, err
+	}
+	errs := Errors{}
+	decodeStrict(raw, reflect.ValueOf(&cfg).Elem(), "", &errs)
+	if !errs.Empty() {
+		return nil, errs
+	}
+	return &cfg, nil
+}
@@ -1,27 +1,41 @@
 package config
 
 import (
-	"encoding/json"
 	"fmt"
 	"net"
 	"net/url"
-	"os"
 	"path/filepath"
+	"regexp"
 	"time"
 
 	. "github.com/cloudfoundry/capi-bara-tests/helpers/validationerrors"
 )
 
+// lookupHost resolves the api and apps_domain hosts during validation.
+var lookupHost = net.LookupHost
+
+// maxNamePrefixLength matches random_name.MaxPrefixLength, which cannot be
+// imported here.
+const maxNamePrefixLength = 20
+
+var namePrefixPattern = regexp.MustCompile(`^[A-Za-z0-9]+(-[A-Za-z0-9]+)*$`)
+
 type config struct {
 	ApiEndpoint *string `json:"api"`
+	ApiProtocol *string `json:"api_protocol"`
 	AppsDomain  *string `json:"apps_domain"`
 
 	AdminPassword *string `json:"admin_password"`
 	AdminUser     *string `json:"admin_user"`
 
+	AuthToken *string `json:"auth_token"`
+
 	SkipSSLValidation *bool `json:"skip_ssl_validation"`
 
-	ArtifactsDirectory *string `json:"artifacts_directory"`
+	ArtifactsDirectory    *string `json:"artifacts_directory"`
+	FailureTraceExchanges *int    `json:"failure_trace_exchanges"`
+
+	KeepResourcesOnFailure *bool `json:"keep_resources_on_failure"`
 
 	AsyncServiceOperationTimeout *int `json:"async_service_operation_timeout"`
 	BrokerStartTimeout           *int `json:"broker_start_timeout"`
@@ -46,7 +60,21 @@ type config struct {
 
 	Infrastructure *string `json:"infrastructure"`
 
-	GcloudProjectName *string `json:"gcloud_project_name""`
+	IncludeDeployments     *bool `json:"include_deployments"`
+	IncludeDroplets        *bool `json:"include_droplets"`
+	IncludeEvents          *bool `json:"include_events"`
+	IncludeManifest        *bool `json:"include_manifest"`
+	IncludeNginx           *bool `json:"include_nginx"`
+	IncludeProcessCommands *bool `json:"include_process_commands"`
+	IncludeQuotas          *bool `json:"include_quotas"`
+	IncludeRevisions       *bool `json:"include_revisions"`
+	IncludeRouting         *bool `json:"include_routing"`
+	IncludeServices        *bool `json:"include_services"`
+	IncludeSidecars        *bool `json:"include_sidecars"`
+	IncludeTasks           *bool `json:"include_tasks"`
+	IncludeZeroDowntime    *bool `json:"include_zero_downtime"`
+
+	GcloudProjectName *string `json:"gcloud_project_name"`
 	ClusterZone       *string `json:"cluster_zone"`
 	ClusterName       *string `json:"cluster_name"`
 
@@ -59,6 +87,11 @@ type reporterConfig struct {
 	CustomTags        map[string]interface{} `json:"custom_tags"`
 	HoneyCombWriteKey string                 `json:"honeycomb_write_key"`
 	HoneyCombDataset  string                 `json:"honeycomb_dataset"`
+	HoneyCombAPIHost  string                 `json:"honeycomb_api_host"`
+
+	// Sink is where per-spec events go: "honeycomb", "jsonl" or "stdout".
+	// Left blank, events go to Honeycomb if a write key and dataset are set.
+	Sink string `json:"sink"`
 }
 
 var defaults = config{}
@@ -71,6 +104,10 @@ func ptrToInt(i int) *int {
 	return &i
 }
 
+func ptrToBool(b bool) *bool {
+	return &b
+}
+
 func ptrToFloat(f float64) *float64 {
 	return &f
 }
@@ -88,6 +125,9 @@ func getDefaults() config {
 
 	defaults.ReporterConfig = &reporterConfig{}
 
+	defaults.ApiProtocol = ptrToString("https")
+	defaults.AuthToken = ptrToString("")
+
 	defaults.AsyncServiceOperationTimeout = ptrToInt(120)
 	defaults.BrokerStartTimeout = ptrToInt(300)
 	defaults.CfPushTimeout = ptrToInt(120)
@@ -101,11 +141,26 @@ func getDefaults() config {
 
 	defaults.Infrastructure = ptrToString("vms")
 
+	defaults.IncludeDeployments = ptrToBool(true)
+	defaults.IncludeDroplets = ptrToBool(true)
+	defaults.IncludeEvents = ptrToBool(true)
+	defaults.IncludeManifest = ptrToBool(true)
+	defaults.IncludeProcessCommands = ptrToBool(true)
+	defaults.IncludeQuotas = ptrToBool(true)
+	defaults.IncludeRevisions = ptrToBool(true)
+	defaults.IncludeServices = ptrToBool(true)
+	defaults.IncludeSidecars = ptrToBool(true)
+	defaults.IncludeTasks = ptrToBool(true)
+	defaults.IncludeZeroDowntime = ptrToBool(true)
+
 	defaults.GcloudProjectName = ptrToString("")
 	defaults.ClusterZone = ptrToString("")
 	defaults.ClusterName = ptrToString("")
 
 	defaults.ArtifactsDirectory = ptrToString(filepath.Join("..", "results"))
+	defaults.FailureTraceExchanges = ptrToInt(10)
+
+	defaults.KeepResourcesOnFailure = ptrToBool(false)
 
 	defaults.NamePrefix = ptrToString("BARA")
 
@@ -146,12 +201,28 @@ func validateConfig(config *config) Errors {
 		errs.Add(err)
 	}
 
+	if config.ApiProtocol == nil {
+		errs.Add(fmt.Errorf("* 'api_protocol' must not be null"))
+	} else if *config.ApiProtocol != "https" && *config.ApiProtocol != "http" {
+		errs.Add(InvalidValueError{Path: "api_protocol", Message: fmt.Sprintf("must be 'https' or 'http' but was set to '%s'", *config.ApiProtocol)})
+	}
+	if config.AuthToken == nil {
+		errs.Add(fmt.Errorf("* 'auth_token' must not be null"))
+	}
 	if config.SkipSSLValidation == nil {
 		errs.Add(fmt.Errorf("* 'skip_ssl_validation' must not be null"))
 	}
 	if config.ArtifactsDirectory == nil {
 		errs.Add(fmt.Errorf("* 'artifacts_directory' must not be null"))
 	}
+	if config.FailureTraceExchanges == nil {
+		errs.Add(fmt.Errorf("* 'failure_trace_exchanges' must not be null"))
+	} else if *config.FailureTraceExchanges < 0 {
+		errs.Add(InvalidValueError{Path: "failure_trace_exchanges", Message: fmt.Sprintf("must not be negative but was set to %d", *config.FailureTraceExchanges)})
+	}
+	if config.KeepResourcesOnFailure == nil {
+		errs.Add(fmt.Errorf("* 'keep_resources_on_failure' must not be null"))
+	}
 	if config.AsyncServiceOperationTimeout == nil {
 		errs.Add(fmt.Errorf("* 'async_service_operation_timeout' must not be null"))
 	}
@@ -208,6 +279,72 @@ func validateConfig(config *config) Errors {
 	}
 	if config.NamePrefix == nil {
 		errs.Add(fmt.Errorf("* 'name_prefix' must not be null"))
+	} else if !namePrefixPattern.MatchString(*config.NamePrefix) || len(*config.NamePrefix) > maxNamePrefixLength {
+		errs.Add(InvalidValueError{Path: "name_prefix", Message: fmt.Sprintf("must be at most %d letters, digits and inner hyphens, so that names are DNS labels, but was set to '%s'", maxNamePrefixLength, *config.NamePrefix)})
+	}
+
+	validatePositive(&errs, "async_service_operation_timeout", config.AsyncServiceOperationTimeout)
+	validatePositive(&errs, "broker_start_timeout", config.BrokerStartTimeout)
+	validatePositive(&errs, "cf_push_timeout", config.CfPushTimeout)
+	validatePositive(&errs, "default_timeout", config.DefaultTimeout)
+	validatePositive(&errs, "detect_timeout", config.DetectTimeout)
+	validatePositive(&errs, "long_curl_timeout", config.LongCurlTimeout)
+	validatePositive(&errs, "sleep_timeout", config.SleepTimeout)
+	validatePositive(&errs, "cc_clock_cycle", config.CcClockCycle)
+	if config.TimeoutScale != nil && *config.TimeoutScale <= 0 {
+		errs.Add(InvalidValueError{Path: "timeout_scale", Message: fmt.Sprintf("must be greater than 0 but was set to %g", *config.TimeoutScale)})
+	}
+
+	if config.ReporterConfig != nil {
+		validateReporterSink(&errs, config.ReporterConfig)
+	}
+
+	validateNotBlank(&errs, "binary_buildpack_name", config.BinaryBuildpackName)
+	validateNotBlank(&errs, "go_buildpack_name", config.GoBuildpackName)
+	validateNotBlank(&errs, "hwc_buildpack_name", config.HwcBuildpackName)
+	validateNotBlank(&errs, "java_buildpack_name", config.JavaBuildpackName)
+	validateNotBlank(&errs, "nodejs_buildpack_name", config.NodejsBuildpackName)
+	validateNotBlank(&errs, "php_buildpack_name", config.PhpBuildpackName)
+	validateNotBlank(&errs, "python_buildpack_name", config.PythonBuildpackName)
+	validateNotBlank(&errs, "ruby_buildpack_name", config.RubyBuildpackName)
+	validateNotBlank(&errs, "staticfile_buildpack_name", config.StaticFileBuildpackName)
+	if config.Infrastructure == nil {
+		errs.Add(fmt.Errorf("* 'infrastructure' must not be null"))
+	} else if *config.Infrastructure != "vms" && *config.Infrastructure != "kubernetes" {
+		errs.Add(InvalidValueError{Path: "infrastructure", Message: fmt.Sprintf("must be 'vms' or 'kubernetes' but was set to '%s'", *config.Infrastructure)})
+	}
+	if config.IncludeDeployments == nil {
+		errs.Add(fmt.Errorf("* 'include_deployments' must not be null"))
+	}
+	if config.IncludeDroplets == nil {
+		errs.Add(fmt.Errorf("* 'include_droplets' must not be null"))
+	}
+	if config.IncludeEvents == nil {
+		errs.Add(fmt.Errorf("* 'include_events' must not be null"))
+	}
+	if config.IncludeManifest == nil {
+		errs.Add(fmt.Errorf("* 'include_manifest' must not be null"))
+	}
+	if config.IncludeProcessCommands == nil {
+		errs.Add(fmt.Errorf("* 'include_process_commands' must not be null"))
+	}
+	if config.IncludeQuotas == nil {
+		errs.Add(fmt.Errorf("* 'include_quotas' must not be null"))
+	}
+	if config.IncludeRevisions == nil {
+		errs.Add(fmt.Errorf("* 'include_revisions' must not be null"))
+	}
+	if config.IncludeServices == nil {
+		errs.Add(fmt.Errorf("* 'include_services' must not be null"))
+	}
+	if config.IncludeSidecars == nil {
+		errs.Add(fmt.Errorf("* 'include_sidecars' must not be null"))
+	}
+	if config.IncludeTasks == nil {
+		errs.Add(fmt.Errorf("* 'include_tasks' must not be null"))
+	}
+	if config.IncludeZeroDowntime == nil {
+		errs.Add(fmt.Errorf("* 'include_zero_downtime' must not be null"))
 	}
 
 	return errs
@@ -222,24 +359,33 @@ func validateApiEndpoint(config *config) error {
 		return fmt.Errorf("* Invalid configuration: 'api' must be a valid Cloud Controller endpoint but was blank")
 	}
 
-	u, err := url.Parse(config.GetApiEndpoint())
+	u, err := url.Parse("https://" + config.GetApiEndpoint())
 	if err != nil {
 		return fmt.Errorf("* Invalid configuration: 'api' must be a valid URL but was set to '%s'", config.GetApiEndpoint())
 	}
 
-	host := u.Host
-	if host == "" {
-		// url.Parse misunderstood our convention and treated the hostname as a URL path
-		host = u.Path
-	}
+	// the endpoint is configured without a scheme but may carry a port
+	host := u.Hostname()
 
-	if _, err = net.LookupHost(host); err != nil {
+	if _, err = lookupHost(host); err != nil {
 		return fmt.Errorf("* Invalid configuration for 'api' <%s>: %s", config.GetApiEndpoint(), err)
 	}
 
 	return nil
 }
 
+func validateReporterSink(errs *Errors, reporterConfig *reporterConfig) {
+	switch reporterConfig.Sink {
+	case "", "jsonl", "stdout":
+	case "honeycomb":
+		if reporterConfig.HoneyCombWriteKey == "" || reporterConfig.HoneyCombDataset == "" {
+			errs.Add(InvalidValueError{Path: "reporter_config.sink", Message: "'honeycomb' requires 'honeycomb_write_key' and 'honeycomb_dataset'"})
+		}
+	default:
+		errs.Add(InvalidValueError{Path: "reporter_config.sink", Message: fmt.Sprintf("must be 'honeycomb', 'jsonl' or 'stdout' but was set to '%s'", reporterConfig.Sink)})
+	}
+}
+
 func validateAppsDomain(config *config) error {
 	if config.AppsDomain == nil {
 		return fmt.Errorf("* 'apps_domain' must not be null")
@@ -257,7 +403,7 @@ func validateAppsDomain(config *config) error {
 		host = u.Path
 	}
 
-	if _, err = net.LookupHost(madeUpAppHostname); err != nil {
+	if _, err = lookupHost(madeUpAppHostname); err != nil {
 		return fmt.Errorf("* Invalid configuration for 'apps_domain' <%s>: %s", config.GetAppsDomain(), err)
 	}
 
@@ -289,34 +435,30 @@ func validateAdminPassword(config *config) error {
 }
 
 func load(path string, config *config) Errors {
-	errs := Errors{}
-	err := loadConfigFromPath(path, config)
+	errs, err := loadConfigFromPath(path, config)
 	if err != nil {
 		errs.Add(fmt.Errorf("* Failed to unmarshal: %s", err))
 		return errs
 	}
 
-	errs = validateConfig(config)
+	errs.AddAll(loadConfigFromEnv(config))
+	errs.AddAll(validateConfig(config))
 	if !errs.Empty() {
 		return errs
 	}
 
-	if *config.TimeoutScale <= 0 {
-		*config.TimeoutScale = 1.0
+	// nginx fronts the Cloud Controller only on VMs, so the nginx group is
+	// off by default on kubernetes unless explicitly included
+	if config.IncludeNginx == nil {
+		config.IncludeNginx = ptrToBool(config.GetInfrastructure() != "kubernetes")
 	}
-
-	return errs
-}
-
-func loadConfigFromPath(path string, config interface{}) error {
-	configFile, err := os.Open(path)
-	if err != nil {
-		return err
+	// the routing group checks weighted route destinations, which gorouter
+	// ignores, so it is off by default on VMs unless explicitly included
+	if config.IncludeRouting == nil {
+		config.IncludeRouting = ptrToBool(config.GetInfrastructure() == "kubernetes")
 	}
-	defer configFile.Close()
 
-	decoder := json.NewDecoder(configFile)
-	return decoder.Decode(config)
+	return errs
 }
 
 func (c config) GetScaledTimeout(timeout time.Duration) time.Duration {
@@ -356,7 +498,11 @@ func (c *config) AsyncServiceOperationTimeoutDuration() time.Duration {
 }
 
 func (c *config) Protocol() string {
-	return "https://"
+	return *c.ApiProtocol + "://"
+}
+
+func (c *config) GetAuthToken() string {
+	return *c.AuthToken
 }
 
 func (c *config) GetAppsDomain() string {
@@ -371,6 +517,14 @@ func (c *config) GetArtifactsDirectory() string {
 	return *c.ArtifactsDirectory
 }
 
+func (c *config) GetFailureTraceExchanges() int {
+	return *c.FailureTraceExchanges
+}
+
+func (c *config) GetKeepResourcesOnFailure() bool {
+	return *c.KeepResourcesOnFailure
+}
+
 func (c *config) GetNamePrefix() string {
 	return *c.NamePrefix
 }
@@ -423,6 +577,62 @@ func (c *config) Lifecycle() string {
 	return "buildpack"
 }
 
+func (c *config) GetInfrastructure() string {
+	return *c.Infrastructure
+}
+
+func (c *config) GetIncludeDeployments() bool {
+	return *c.IncludeDeployments
+}
+
+func (c *config) GetIncludeDroplets() bool {
+	return *c.IncludeDroplets
+}
+
+func (c *config) GetIncludeEvents() bool {
+	return *c.IncludeEvents
+}
+
+func (c *config) GetIncludeManifest() bool {
+	return *c.IncludeManifest
+}
+
+func (c *config) GetIncludeNginx() bool {
+	return *c.IncludeNginx
+}
+
+func (c *config) GetIncludeProcessCommands() bool {
+	return *c.IncludeProcessCommands
+}
+
+func (c *config) GetIncludeQuotas() bool {
+	return *c.IncludeQuotas
+}
+
+func (c *config) GetIncludeRevisions() bool {
+	return *c.IncludeRevisions
+}
+
+func (c *config) GetIncludeRouting() bool {
+	return *c.IncludeRouting
+}
+
+func (c *config) GetIncludeServices() bool {
+	return *c.IncludeServices
+}
+
+func (c *config) GetIncludeSidecars() bool {
+	return *c.IncludeSidecars
+}
+
+func (c *config) GetIncludeTasks() bool {
+	return *c.IncludeTasks
+}
+
+func (c *config) GetIncludeZeroDowntime() bool {
+	return *c.IncludeZeroDowntime
+}
+
 func (c *config) GetGcloudProjectName() string {
 	return *c.GcloudProjectName
 }
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	cfg "github.com/cloudfoundry/capi-bara-tests/helpers/config"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/validationerrors"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -30,6 +31,17 @@ type testConfig struct {
 	SkipSSLValidation *bool   `json:"skip_ssl_validation"`
 	AppsDomain        *string `json:"apps_domain"`
 
+	ApiProtocol *string `json:"api_protocol,omitempty"`
+	AuthToken   *string `json:"auth_token,omitempty"`
+
+	Infrastructure *string `json:"infrastructure,omitempty"`
+
+	NamePrefix *string `json:"name_prefix,omitempty"`
+
+	IncludeNginx    *bool `json:"include_nginx,omitempty"`
+	IncludeRouting  *bool `json:"include_routing,omitempty"`
+	IncludeSidecars *bool `json:"include_sidecars,omitempty"`
+
 	// timeouts
 	DefaultTimeout               *int `json:"default_timeout,omitempty"`
 	CfPushTimeout                *int `json:"cf_push_timeout,omitempty"`
@@ -42,10 +54,6 @@ type testConfig struct {
 
 	TimeoutScale *float64 `json:"timeout_scale,omitempty"`
 
-	// optional
-	UnallocatedIPForSecurityGroup *string `json:"unallocated_ip_for_security_group"`
-	RequireProxiedAppTraffic      *bool   `json:"require_proxied_app_traffic"`
-
 	ReporterConfig *testReporterConfig `json:"reporter_config"`
 }
 
@@ -56,12 +64,6 @@ type allConfig struct {
 	AdminPassword *string `json:"admin_password"`
 	AdminUser     *string `json:"admin_user"`
 
-	ExistingUser         *string `json:"existing_user"`
-	ExistingUserPassword *string `json:"existing_user_password"`
-
-	UseExistingOrganization *bool   `json:"use_existing_organization"`
-	ExistingOrganization    *string `json:"existing_organization"`
-
 	SkipSSLValidation *bool `json:"skip_ssl_validation"`
 
 	ArtifactsDirectory *string `json:"artifacts_directory"`
@@ -87,6 +89,11 @@ type allConfig struct {
 	RubyBuildpackName       *string `json:"ruby_buildpack_name"`
 	StaticFileBuildpackName *string `json:"staticfile_buildpack_name"`
 
+	Infrastructure *string `json:"infrastructure"`
+
+	IncludeDeployments *bool `json:"include_deployments"`
+	IncludeSidecars    *bool `json:"include_sidecars"`
+
 	ReporterConfig *testReporterConfig `json:"reporter_config"`
 
 	NamePrefix *string `json:"name_prefix"`
@@ -96,6 +103,7 @@ type testReporterConfig struct {
 	HoneyCombWriteKey string                 `json:"honeycomb_write_key"`
 	HoneyCombDataset  string                 `json:"honeycomb_dataset"`
 	CustomTags        map[string]interface{} `json:"custom_tags"`
+	Sink              string                 `json:"sink,omitempty"`
 }
 
 var tmpFilePath string
@@ -183,10 +191,28 @@ var _ = Describe("Config", func() {
 		Expect(config.GetScaledTimeout(1)).To(Equal(time.Duration(2)))
 
 		Expect(config.GetArtifactsDirectory()).To(Equal(filepath.Join("..", "results")))
+		Expect(config.GetFailureTraceExchanges()).To(Equal(10))
+		Expect(config.GetKeepResourcesOnFailure()).To(BeFalse())
 
 		Expect(config.GetNamePrefix()).To(Equal("BARA"))
 
 		Expect(config.Protocol()).To(Equal("https://"))
+		Expect(config.GetAuthToken()).To(Equal(""))
+
+		Expect(config.GetInfrastructure()).To(Equal("vms"))
+		Expect(config.GetIncludeDeployments()).To(BeTrue())
+		Expect(config.GetIncludeDroplets()).To(BeTrue())
+		Expect(config.GetIncludeEvents()).To(BeTrue())
+		Expect(config.GetIncludeManifest()).To(BeTrue())
+		Expect(config.GetIncludeNginx()).To(BeTrue())
+		Expect(config.GetIncludeProcessCommands()).To(BeTrue())
+		Expect(config.GetIncludeQuotas()).To(BeTrue())
+		Expect(config.GetIncludeRevisions()).To(BeTrue())
+		Expect(config.GetIncludeRouting()).To(BeFalse())
+		Expect(config.GetIncludeServices()).To(BeTrue())
+		Expect(config.GetIncludeSidecars()).To(BeTrue())
+		Expect(config.GetIncludeTasks()).To(BeTrue())
+		Expect(config.GetIncludeZeroDowntime()).To(BeTrue())
 
 		// undocumented
 		Expect(config.DetectTimeoutDuration()).To(Equal(10 * time.Minute))
@@ -228,6 +254,10 @@ var _ = Describe("Config", func() {
 			Expect(err.Error()).To(ContainSubstring("'staticfile_buildpack_name' must not be null"))
 
 			Expect(err.Error()).To(ContainSubstring("'name_prefix' must not be null"))
+
+			Expect(err.Error()).To(ContainSubstring("'infrastructure' must not be null"))
+			Expect(err.Error()).To(ContainSubstring("'include_deployments' must not be null"))
+			Expect(err.Error()).To(ContainSubstring("'include_sidecars' must not be null"))
 		})
 	})
 
@@ -242,8 +272,6 @@ var _ = Describe("Config", func() {
 			testCfg.SleepTimeout = ptrToInt(101)
 			testCfg.CcClockCycle = ptrToInt(65)
 			testCfg.TimeoutScale = ptrToFloat(1.0)
-			testCfg.UnallocatedIPForSecurityGroup = ptrToString("192.168.0.1")
-			testCfg.RequireProxiedAppTraffic = ptrToBool(true)
 		})
 
 		It("respects the overriden values", func() {
@@ -295,6 +323,190 @@ var _ = Describe("Config", func() {
 				}))
 			})
 		})
+
+		Context("when the sink is unknown", func() {
+			BeforeEach(func() {
+				testCfg.ReporterConfig.Sink = "carrier-pigeon"
+			})
+
+			It("returns an error", func() {
+				_, err := cfg.NewBaraConfig(tmpFilePath)
+				Expect(err).To(MatchError(ContainSubstring("* Invalid configuration for 'reporter_config.sink': must be 'honeycomb', 'jsonl' or 'stdout' but was set to 'carrier-pigeon'")))
+			})
+		})
+
+		Context("when the honeycomb sink is missing its dataset", func() {
+			BeforeEach(func() {
+				testCfg.ReporterConfig.Sink = "honeycomb"
+				testCfg.ReporterConfig.HoneyCombDataset = ""
+			})
+
+			It("returns an error", func() {
+				_, err := cfg.NewBaraConfig(tmpFilePath)
+				Expect(err).To(MatchError(ContainSubstring("'honeycomb' requires 'honeycomb_write_key' and 'honeycomb_dataset'")))
+			})
+		})
+	})
+
+	Describe("strict validation", func() {
+		var path string
+
+		BeforeEach(resolveHostsOffline)
+
+		AfterEach(func() {
+			Expect(os.Remove(path)).To(Succeed())
+		})
+
+		It("reports every unknown key with its JSON path", func() {
+			path = writeConfigFile(map[string]interface{}{
+				"api":                 "api.bosh-lite.com",
+				"apps_domain":         "cf-app.bosh-lite.com",
+				"admin_user":          "admin",
+				"admin_password":      "admin",
+				"skip_ssl_validation": true,
+				"include_sidecar":     false,
+				"reporter_config": map[string]interface{}{
+					"honeycomb_dataset": "some-dataset",
+					"honeycomb_datset":  "typo",
+					"custom_tags":       map[string]interface{}{"anything": "goes"},
+				},
+			})
+
+			_, err := cfg.NewBaraConfig(path)
+			Expect(err).To(HaveOccurred())
+
+			errs := err.(validationerrors.Errors).All()
+			Expect(errs).To(ConsistOf(
+				cfg.UnknownKeyError{Path: "include_sidecar"},
+				cfg.UnknownKeyError{Path: "reporter_config.honeycomb_datset"},
+			))
+			Expect(err.Error()).To(ContainSubstring("* Unknown configuration key 'reporter_config.honeycomb_datset'"))
+		})
+
+		It("reports every badly typed value alongside validation errors", func() {
+			path = writeConfigFile(map[string]interface{}{
+				"api":                 "api.bosh-lite.com",
+				"apps_domain":         "cf-app.bosh-lite.com",
+				"admin_user":          "admin",
+				"skip_ssl_validation": "yes",
+				"cf_push_timeout":     "120",
+				"reporter_config":     map[string]interface{}{"honeycomb_dataset": 7},
+			})
+
+			_, err := cfg.NewBaraConfig(path)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("* Invalid configuration for 'skip_ssl_validation': expected a boolean but got string"))
+			Expect(err.Error()).To(ContainSubstring("* Invalid configuration for 'cf_push_timeout': expected a number but got string"))
+			Expect(err.Error()).To(ContainSubstring("* Invalid configuration for 'reporter_config.honeycomb_dataset': expected a string but got number"))
+			Expect(err.Error()).To(ContainSubstring("'admin_password' must not be null"))
+		})
+
+		It("validates ranges", func() {
+			path = writeConfigFile(map[string]interface{}{
+				"api":                 "api.bosh-lite.com",
+				"apps_domain":         "cf-app.bosh-lite.com",
+				"admin_user":          "admin",
+				"admin_password":      "admin",
+				"skip_ssl_validation": true,
+				"default_timeout":     0,
+				"cc_clock_cycle":      -5,
+				"timeout_scale":       0,
+				"ruby_buildpack_name": "",
+				"name_prefix":         "my_pipeline",
+			})
+
+			_, err := cfg.NewBaraConfig(path)
+			Expect(err).To(HaveOccurred())
+			Expect(err.(validationerrors.Errors).All()).To(ConsistOf(
+				cfg.InvalidValueError{Path: "default_timeout", Message: "must be greater than 0 but was set to 0"},
+				cfg.InvalidValueError{Path: "cc_clock_cycle", Message: "must be greater than 0 but was set to -5"},
+				cfg.InvalidValueError{Path: "timeout_scale", Message: "must be greater than 0 but was set to 0"},
+				cfg.InvalidValueError{Path: "ruby_buildpack_name", Message: "must not be blank"},
+				cfg.InvalidValueError{Path: "name_prefix", Message: "must be at most 20 letters, digits and inner hyphens, so that names are DNS labels, but was set to 'my_pipeline'"},
+			))
+		})
+
+		It("fails on malformed JSON", func() {
+			configFile, err := ioutil.TempFile("", "cf-test-helpers-config")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = configFile.WriteString(`{"api": `)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(configFile.Close()).To(Succeed())
+			path = configFile.Name()
+
+			_, err = cfg.NewBaraConfig(path)
+			Expect(err).To(MatchError(ContainSubstring("* Failed to unmarshal: " + path + ": unexpected EOF")))
+		})
+	})
+
+	Describe("layered config files", func() {
+		var basePath, overlayPath string
+
+		BeforeEach(resolveHostsOffline)
+
+		writeFile := func(name, contents string) string {
+			path := filepath.Join(GinkgoT().TempDir(), name)
+			Expect(os.WriteFile(path, []byte(contents), 0644)).To(Succeed())
+			return path
+		}
+
+		BeforeEach(func() {
+			basePath = writeFile("base.json", `{
+				"api": "api.bosh-lite.com",
+				"apps_domain": "cf-app.bosh-lite.com",
+				"admin_user": "admin",
+				"skip_ssl_validation": true,
+				"default_timeout": 12,
+				"reporter_config": {
+					"honeycomb_dataset": "base-dataset",
+					"custom_tags": {"team": "capi", "env": "base"}
+				}
+			}`)
+			overlayPath = writeFile("overlay.yml", `
+admin_password: admin
+default_timeout: 34
+include_nginx: false
+reporter_config:
+  custom_tags:
+    env: staging
+    pipeline: bara
+`)
+		})
+
+		It("merges the files in order with later files winning", func() {
+			c, err := cfg.NewBaraConfig(basePath + string(filepath.ListSeparator) + overlayPath)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(c.GetAdminPassword()).To(Equal("admin"))
+			Expect(c.DefaultTimeoutDuration()).To(Equal(68 * time.Second))
+			Expect(c.GetIncludeNginx()).To(BeFalse())
+
+			Expect(c.GetReporterConfig().HoneyCombDataset).To(Equal("base-dataset"))
+			Expect(c.GetReporterConfig().CustomTags).To(Equal(map[string]interface{}{
+				"team":     "capi",
+				"env":      "staging",
+				"pipeline": "bara",
+			}))
+		})
+
+		It("validates the merged result rather than each file", func() {
+			_, err := cfg.NewBaraConfig(basePath)
+			Expect(err).To(MatchError(ContainSubstring("'admin_password' must not be null")))
+		})
+
+		It("reports unknown keys from YAML files", func() {
+			typoPath := writeFile("typo.yaml", "admin_pasword: admin\n")
+
+			_, err := cfg.NewBaraConfig(basePath + string(filepath.ListSeparator) + overlayPath + string(filepath.ListSeparator) + typoPath)
+			Expect(err).To(MatchError(ContainSubstring("* Unknown configuration key 'admin_pasword'")))
+		})
+
+		It("names the file that fails to parse", func() {
+			brokenPath := writeFile("broken.yml", "admin_password: [admin\n")
+
+			_, err := cfg.NewBaraConfig(basePath + string(filepath.ListSeparator) + brokenPath)
+			Expect(err).To(MatchError(ContainSubstring("* Failed to unmarshal: " + brokenPath + ": yaml:")))
+		})
 	})
 
 	Describe("error aggregation", func() {
@@ -486,4 +698,234 @@ var _ = Describe("Config", func() {
 			})
 		})
 	})
+
+	Describe("Protocol", func() {
+		BeforeEach(resolveHostsOffline)
+
+		Context("when api_protocol is http", func() {
+			BeforeEach(func() {
+				testCfg.ApiProtocol = ptrToString("http")
+			})
+
+			It("returns http://", func() {
+				c, err := cfg.NewBaraConfig(tmpFilePath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(c.Protocol()).To(Equal("http://"))
+			})
+		})
+
+		Context("when api_protocol is not http or https", func() {
+			BeforeEach(func() {
+				testCfg.ApiProtocol = ptrToString("ftp")
+			})
+
+			It("returns an error", func() {
+				_, err := cfg.NewBaraConfig(tmpFilePath)
+				Expect(err).To(MatchError(ContainSubstring("* Invalid configuration for 'api_protocol': must be 'https' or 'http' but was set to 'ftp'")))
+				Expect(err.(validationerrors.Errors).All()).To(ContainElement(
+					cfg.InvalidValueError{Path: "api_protocol", Message: "must be 'https' or 'http' but was set to 'ftp'"},
+				))
+			})
+		})
+	})
+
+	Describe("GetNamePrefix", func() {
+		BeforeEach(resolveHostsOffline)
+
+		Context("when the name prefix is too long for resource names", func() {
+			BeforeEach(func() {
+				testCfg.NamePrefix = ptrToString("ACCEPTANCE-PIPELINE-STAGING")
+			})
+
+			It("returns an error", func() {
+				_, err := cfg.NewBaraConfig(tmpFilePath)
+				Expect(err.(validationerrors.Errors).All()).To(ContainElement(
+					cfg.InvalidValueError{Path: "name_prefix", Message: "must be at most 20 letters, digits and inner hyphens, so that names are DNS labels, but was set to 'ACCEPTANCE-PIPELINE-STAGING'"},
+				))
+			})
+		})
+	})
+
+	Describe("GetAuthToken", func() {
+		BeforeEach(resolveHostsOffline)
+
+		BeforeEach(func() {
+			testCfg.AuthToken = ptrToString("bearer some-token")
+		})
+
+		It("returns the configured token", func() {
+			c, err := cfg.NewBaraConfig(tmpFilePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.GetAuthToken()).To(Equal("bearer some-token"))
+		})
+	})
+
+	Describe("include_* toggles", func() {
+		BeforeEach(resolveHostsOffline)
+
+		BeforeEach(func() {
+			testCfg.IncludeSidecars = ptrToBool(false)
+		})
+
+		It("disables the group", func() {
+			c, err := cfg.NewBaraConfig(tmpFilePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.GetIncludeSidecars()).To(BeFalse())
+		})
+
+		Context("when the infrastructure is kubernetes", func() {
+			BeforeEach(func() {
+				testCfg.Infrastructure = ptrToString("kubernetes")
+			})
+
+			It("excludes nginx by default", func() {
+				c, err := cfg.NewBaraConfig(tmpFilePath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(c.GetIncludeNginx()).To(BeFalse())
+			})
+
+			It("includes routing by default", func() {
+				c, err := cfg.NewBaraConfig(tmpFilePath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(c.GetIncludeRouting()).To(BeTrue())
+			})
+
+			Context("when nginx is explicitly included", func() {
+				BeforeEach(func() {
+					testCfg.IncludeNginx = ptrToBool(true)
+				})
+
+				It("includes nginx", func() {
+					c, err := cfg.NewBaraConfig(tmpFilePath)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(c.GetIncludeNginx()).To(BeTrue())
+				})
+			})
+		})
+
+		Context("when routing is explicitly included on VMs", func() {
+			BeforeEach(func() {
+				testCfg.IncludeRouting = ptrToBool(true)
+			})
+
+			It("includes routing", func() {
+				c, err := cfg.NewBaraConfig(tmpFilePath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(c.GetIncludeRouting()).To(BeTrue())
+			})
+		})
+
+		Context("when the infrastructure is unknown", func() {
+			BeforeEach(func() {
+				testCfg.Infrastructure = ptrToString("mainframe")
+			})
+
+			It("returns an error", func() {
+				_, err := cfg.NewBaraConfig(tmpFilePath)
+				Expect(err).To(MatchError(ContainSubstring("* Invalid configuration for 'infrastructure': must be 'vms' or 'kubernetes' but was set to 'mainframe'")))
+				Expect(err.(validationerrors.Errors).All()).To(ContainElement(
+					cfg.InvalidValueError{Path: "infrastructure", Message: "must be 'vms' or 'kubernetes' but was set to 'mainframe'"},
+				))
+			})
+		})
+	})
+
+	Describe("NewLocalConfig", func() {
+		It("points at the given Cloud Controller without resolving it", func() {
+			c, err := cfg.NewLocalConfig("http://127.0.0.1:4567", "bearer some-token")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.Protocol() + c.GetApiEndpoint()).To(Equal("http://127.0.0.1:4567"))
+			Expect(c.GetAuthToken()).To(Equal("bearer some-token"))
+			Expect(c.DefaultTimeoutDuration()).To(Equal(30 * time.Second))
+		})
+
+		It("applies the given layers on top of the defaults", func() {
+			c, err := cfg.NewLocalConfig("http://127.0.0.1:4567", "", map[string]interface{}{
+				"artifacts_directory": "/tmp/results",
+				"reporter_config":     map[string]interface{}{"sink": "jsonl"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.GetArtifactsDirectory()).To(Equal("/tmp/results"))
+			Expect(c.GetReporterConfig().Sink).To(Equal("jsonl"))
+		})
+
+		It("rejects unknown keys in the layers", func() {
+			_, err := cfg.NewLocalConfig("http://127.0.0.1:4567", "", map[string]interface{}{"artifact_directory": "/tmp/results"})
+			Expect(err.(validationerrors.Errors).All()).To(ConsistOf(cfg.UnknownKeyError{Path: "artifact_directory"}))
+		})
+	})
+
+	Describe("BARA_* environment overrides", func() {
+		BeforeEach(resolveHostsOffline)
+
+		setEnv := func(name, value string) {
+			Expect(os.Setenv(name, value)).To(Succeed())
+			DeferCleanup(os.Unsetenv, name)
+		}
+
+		It("overrides file values with typed values", func() {
+			setEnv("BARA_API", "10.244.0.34")
+			setEnv("BARA_CF_PUSH_TIMEOUT", "30")
+			setEnv("BARA_TIMEOUT_SCALE", "1.5")
+			setEnv("BARA_SKIP_SSL_VALIDATION", "false")
+			setEnv("BARA_NAME_PREFIX", "PIPELINE")
+			setEnv("BARA_REPORTER_CONFIG_HONEYCOMB_DATASET", "some-dataset")
+			setEnv("BARA_REPORTER_CONFIG_CUSTOM_TAGS", `{"env":"ci"}`)
+
+			c, err := cfg.NewBaraConfig(tmpFilePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.GetApiEndpoint()).To(Equal("10.244.0.34"))
+			Expect(c.CfPushTimeoutDuration()).To(Equal(45 * time.Second))
+			Expect(c.GetSkipSSLValidation()).To(BeFalse())
+			Expect(c.GetNamePrefix()).To(Equal("PIPELINE"))
+			Expect(c.GetReporterConfig().HoneyCombDataset).To(Equal("some-dataset"))
+			Expect(c.GetReporterConfig().CustomTags).To(HaveKeyWithValue("env", "ci"))
+		})
+
+		It("reports values that do not parse", func() {
+			setEnv("BARA_CF_PUSH_TIMEOUT", "soon")
+			setEnv("BARA_SKIP_SSL_VALIDATION", "maybe")
+
+			_, err := cfg.NewBaraConfig(tmpFilePath)
+			Expect(err).To(MatchError(ContainSubstring("* Invalid value for 'cf_push_timeout' from BARA_CF_PUSH_TIMEOUT: 'soon' is not an integer")))
+			Expect(err).To(MatchError(ContainSubstring("* Invalid value for 'skip_ssl_validation' from BARA_SKIP_SSL_VALIDATION: 'maybe' is not a boolean")))
+		})
+
+		It("validates overridden values", func() {
+			setEnv("BARA_ADMIN_USER", "")
+
+			_, err := cfg.NewBaraConfig(tmpFilePath)
+			Expect(err).To(MatchError(ContainSubstring("'admin_user' must be provided")))
+		})
+	})
+
+	Describe("RedactedJSON", func() {
+		BeforeEach(resolveHostsOffline)
+
+		BeforeEach(func() {
+			testCfg.AuthToken = ptrToString("bearer some-token")
+			testCfg.ReporterConfig = &testReporterConfig{HoneyCombWriteKey: "some-write-key"}
+		})
+
+		It("prints the effective config without secrets", func() {
+			c, err := cfg.NewBaraConfig(tmpFilePath)
+			Expect(err).NotTo(HaveOccurred())
+
+			redacted, err := c.RedactedJSON()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(redacted)).To(ContainSubstring(`"admin_user": "admin"`))
+			Expect(string(redacted)).To(ContainSubstring(`"admin_password": "[REDACTED]"`))
+			Expect(string(redacted)).To(ContainSubstring(`"auth_token": "[REDACTED]"`))
+			Expect(string(redacted)).To(ContainSubstring(`"honeycomb_write_key": "[REDACTED]"`))
+			Expect(string(redacted)).NotTo(ContainSubstring("some-write-key"))
+		})
+	})
 })
+
+// resolveHostsOffline makes validation resolve every host for the rest of
+// the spec, so specs that are not about DNS pass without network access.
+func resolveHostsOffline() {
+	DeferCleanup(cfg.StubLookupHost(func(string) ([]string, error) {
+		return []string{"10.244.0.34"}, nil
+	}))
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	. "github.com/cloudfoundry/capi-bara-tests/helpers/validationerrors"
+)
+
+// EnvPrefix is prepended to the upper-cased JSON key of a config field to
+// get the environment variable overriding it, e.g. BARA_CF_PUSH_TIMEOUT for
+// cf_push_timeout. Keys of nested objects are joined with an underscore,
+// e.g. BARA_REPORTER_CONFIG_HONEYCOMB_DATASET.
+const EnvPrefix = "BARA_"
+
+func loadConfigFromEnv(config interface{}) Errors {
+	errs := Errors{}
+	applyEnv(reflect.ValueOf(config).Elem(), EnvPrefix, os.LookupEnv, &errs)
+	return errs
+}
+
+func applyEnv(value reflect.Value, prefix string, lookup func(string) (string, bool), errs *Errors) {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		key := jsonKey(field)
+		if key == "" {
+			continue
+		}
+		envName := prefix + strings.ToUpper(key)
+		fieldValue := value.Field(i)
+
+		if isNestedConfig(field.Type) {
+			if fieldValue.IsNil() {
+				fieldValue.Set(reflect.New(field.Type.Elem()))
+			}
+			applyEnv(fieldValue.Elem(), envName+"_", lookup, errs)
+			continue
+		}
+
+		raw, ok := lookup(envName)
+		if !ok {
+			continue
+		}
+
+		if err := setFromString(fieldValue, raw); err != nil {
+			errs.Add(fmt.Errorf("* Invalid value for '%s' from %s: %s", key, envName, err))
+		}
+	}
+}
+
+func setFromString(field reflect.Value, raw string) error {
+	target := field
+	if field.Kind() == reflect.Ptr {
+		target = reflect.New(field.Type().Elem()).Elem()
+	}
+
+	switch target.Kind() {
+	case reflect.String:
+		target.SetString(raw)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("'%s' is not a boolean", raw)
+		}
+		target.SetBool(parsed)
+	case reflect.Int:
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("'%s' is not an integer", raw)
+		}
+		target.SetInt(int64(parsed))
+	case reflect.Float64:
+		parsed, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("'%s' is not a number", raw)
+		}
+		target.SetFloat(parsed)
+	default:
+		if err := json.Unmarshal([]byte(raw), target.Addr().Interface()); err != nil {
+			return fmt.Errorf("'%s' is not valid JSON: %s", raw, err)
+		}
+	}
+
+	if field.Kind() == reflect.Ptr {
+		field.Set(target.Addr())
+	}
+	return nil
+}
+
+func isNestedConfig(t reflect.Type) bool {
+	return t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct
+}
+
+func jsonKey(field reflect.StructField) string {
+	key := strings.Split(field.Tag.Get("json"), ",")[0]
+	if key == "-" {
+		return ""
+	}
+	return key
+}
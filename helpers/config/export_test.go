@@ -0,0 +1,9 @@
+package config
+
+// StubLookupHost replaces the host lookup validation uses and returns a
+// function restoring the previous one.
+func StubLookupHost(lookup func(host string) ([]string, error)) func() {
+	previous := lookupHost
+	lookupHost = lookup
+	return func() { lookupHost = previous }
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// readLayers reads every file in the colon-separated list paths, JSON or
+// YAML by extension, and deep-merges them in order so that later files win.
+// Nested objects such as reporter_config.custom_tags are merged key by key.
+func readLayers(paths string) ([]byte, error) {
+	merged := map[string]interface{}{}
+	for _, path := range filepath.SplitList(paths) {
+		layer, err := readLayer(path)
+		if err != nil {
+			return nil, err
+		}
+		mergeLayer(merged, layer)
+	}
+	return json.Marshal(merged)
+}
+
+func readLayer(path string) (map[string]interface{}, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	layer := map[string]interface{}{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yml", ".yaml":
+		err = yaml.Unmarshal(contents, &layer)
+	default:
+		decoder := json.NewDecoder(bytes.NewReader(contents))
+		decoder.UseNumber()
+		err = decoder.Decode(&layer)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return layer, nil
+}
+
+func mergeLayer(base, layer map[string]interface{}) {
+	for key, value := range layer {
+		baseObject, baseIsObject := base[key].(map[string]interface{})
+		layerObject, layerIsObject := value.(map[string]interface{})
+		if baseIsObject && layerIsObject {
+			mergeLayer(baseObject, layerObject)
+			continue
+		}
+		base[key] = value
+	}
+}
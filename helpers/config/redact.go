@@ -0,0 +1,49 @@
+package config
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+var secretKeyFragments = []string{"password", "secret", "write_key", "token"}
+
+// RedactedJSON returns the effective config, after environment overrides,
+// as indented JSON with every secret value replaced.
+func (c *config) RedactedJSON() ([]byte, error) {
+	raw, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+
+	var values map[string]interface{}
+	if err := json.Unmarshal(raw, &values); err != nil {
+		return nil, err
+	}
+
+	return json.MarshalIndent(redact(values), "", "  ")
+}
+
+func redact(values map[string]interface{}) map[string]interface{} {
+	for key, value := range values {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			values[key] = redact(v)
+		case string:
+			if v != "" && isSecretKey(key) {
+				values[key] = redacted
+			}
+		}
+	}
+	return values
+}
+
+func isSecretKey(key string) bool {
+	for _, fragment := range secretKeyFragments {
+		if strings.Contains(key, fragment) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	. "github.com/cloudfoundry/capi-bara-tests/helpers/validationerrors"
+)
+
+// UnknownKeyError is reported for a config key that BARAS does not read,
+// usually a typo. Path is the dotted JSON path, e.g. reporter_config.dataset.
+type UnknownKeyError struct {
+	Path string
+}
+
+func (e UnknownKeyError) Error() string {
+	return fmt.Sprintf("* Unknown configuration key '%s'", e.Path)
+}
+
+// InvalidValueError is reported for a config value of the wrong type or out
+// of range. Path is the dotted JSON path of the offending value.
+type InvalidValueError struct {
+	Path    string
+	Message string
+}
+
+func (e InvalidValueError) Error() string {
+	return fmt.Sprintf("* Invalid configuration for '%s': %s", e.Path, e.Message)
+}
+
+// loadConfigFromPath merges the config files listed in path and decodes the
+// result into config one key at a time, so that every unknown key and badly
+// typed value is reported rather than just the first. The error is set only
+// if a file cannot be read or parsed at all.
+func loadConfigFromPath(path string, config interface{}) (Errors, error) {
+	errs := Errors{}
+
+	merged, err := readLayers(path)
+	if err != nil {
+		return errs, err
+	}
+
+	decodeStrict(merged, reflect.ValueOf(config).Elem(), "", &errs)
+	return errs, nil
+}
+
+func decodeStrict(raw json.RawMessage, value reflect.Value, prefix string, errs *Errors) {
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &values); err != nil {
+		errs.Add(InvalidValueError{Path: prefix, Message: "must be an object"})
+		return
+	}
+
+	fields := map[string]int{}
+	for i := 0; i < value.NumField(); i++ {
+		if key := jsonKey(value.Type().Field(i)); key != "" {
+			fields[key] = i
+		}
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		path := prefix + key
+		i, ok := fields[key]
+		if !ok {
+			errs.Add(UnknownKeyError{Path: path})
+			continue
+		}
+
+		fieldValue := value.Field(i)
+		if isNestedConfig(fieldValue.Type()) && string(values[key]) != "null" {
+			if fieldValue.IsNil() {
+				fieldValue.Set(reflect.New(fieldValue.Type().Elem()))
+			}
+			decodeStrict(values[key], fieldValue.Elem(), path+".", errs)
+			continue
+		}
+
+		if err := json.Unmarshal(values[key], fieldValue.Addr().Interface()); err != nil {
+			errs.Add(InvalidValueError{Path: path, Message: typeErrorMessage(err)})
+		}
+	}
+}
+
+func typeErrorMessage(err error) string {
+	if typeErr, ok := err.(*json.UnmarshalTypeError); ok {
+		return fmt.Sprintf("expected %s but got %s", typeName(typeErr.Type), typeErr.Value)
+	}
+	return err.Error()
+}
+
+func typeName(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	}
+	return t.String()
+}
+
+func validatePositive(errs *Errors, key string, value *int) {
+	if value != nil && *value <= 0 {
+		errs.Add(InvalidValueError{Path: key, Message: fmt.Sprintf("must be greater than 0 but was set to %d", *value)})
+	}
+}
+
+func validateNotBlank(errs *Errors, key string, value *string) {
+	if value != nil && *value == "" {
+		errs.Add(InvalidValueError{Path: key, Message: "must not be blank"})
+	}
+}
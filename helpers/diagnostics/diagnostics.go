@@ -0,0 +1,163 @@
+// Package diagnostics collects the Cloud Controller's view of the apps a
+// failed spec created into a bundle in the artifacts directory.
+package diagnostics
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/cloudfoundry/capi-bara-tests/helpers/capi_client"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/trace"
+)
+
+var (
+	trackedMutex sync.Mutex
+	trackedApps  []string
+)
+
+// TrackApp records an app created by the running spec so that it is
+// included in the bundle if the spec fails.
+func TrackApp(appGUID string) {
+	trackedMutex.Lock()
+	defer trackedMutex.Unlock()
+	trackedApps = append(trackedApps, appGUID)
+}
+
+func TrackedApps() []string {
+	trackedMutex.Lock()
+	defer trackedMutex.Unlock()
+	return append([]string{}, trackedApps...)
+}
+
+func ResetTrackedApps() {
+	trackedMutex.Lock()
+	defer trackedMutex.Unlock()
+	trackedApps = nil
+}
+
+// BundleDirectory returns the bundle for a spec run on parallelProcess,
+// e.g. <artifacts>/diagnostics/BARA-2/sidecars-starts-the-sidecar-1a2b3c4d.
+func BundleDirectory(artifactsDirectory, componentName string, parallelProcess int, specText string) string {
+	return filepath.Join(
+		artifactsDirectory,
+		"diagnostics",
+		fmt.Sprintf("%s-%d", componentName, parallelProcess),
+		trace.SpecFileName(specText),
+	)
+}
+
+type Collector struct {
+	Client *capi_client.Client
+
+	// RecentLogs returns an app's recent logs. Logs are skipped if nil.
+	RecentLogs func(appGUID string) (string, error)
+}
+
+// Collect writes one subdirectory per app under directory. Anything that
+// cannot be fetched is recorded in <name>.error.txt instead, so one missing
+// resource does not hide the rest.
+func (c Collector) Collect(directory string, appGUIDs []string) error {
+	seen := map[string]bool{}
+	for _, appGUID := range appGUIDs {
+		if seen[appGUID] {
+			continue
+		}
+		seen[appGUID] = true
+
+		if err := c.collectApp(filepath.Join(directory, appGUID), appGUID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c Collector) collectApp(directory, appGUID string) error {
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		return err
+	}
+
+	appPath := "/v3/apps/" + appGUID
+	byApp := capi_client.ListOptions{}.Filter("app_guids", appGUID)
+
+	items := []struct {
+		name  string
+		fetch func() (interface{}, error)
+	}{
+		{"app", c.get(appPath)},
+		{"processes", c.list(appPath+"/processes", capi_client.ListOptions{})},
+		{"process_stats", func() (interface{}, error) { return c.processStats(appGUID) }},
+		{"current_droplet", c.get(appPath + "/droplets/current")},
+		{"builds", c.list("/v3/builds", byApp)},
+		{"deployments", c.list("/v3/deployments", byApp)},
+		{"revisions", c.list(appPath+"/revisions", capi_client.ListOptions{})},
+		{"sidecars", c.list(appPath+"/sidecars", capi_client.ListOptions{})},
+		{"audit_events", c.list("/v3/audit_events", capi_client.ListOptions{OrderBy: "created_at"}.Filter("target_guids", appGUID))},
+	}
+
+	for _, item := range items {
+		value, err := item.fetch()
+		if err := writeItem(directory, item.name, value, err); err != nil {
+			return err
+		}
+	}
+
+	if c.RecentLogs != nil {
+		logs, err := c.RecentLogs(appGUID)
+		if err != nil {
+			return writeError(directory, "recent_logs", err)
+		}
+		return os.WriteFile(filepath.Join(directory, "recent_logs.txt"), []byte(logs), 0644)
+	}
+	return nil
+}
+
+func (c Collector) get(path string) func() (interface{}, error) {
+	return func() (interface{}, error) {
+		var raw json.RawMessage
+		err := c.Client.Get(path, &raw)
+		return raw, err
+	}
+}
+
+func (c Collector) list(path string, options capi_client.ListOptions) func() (interface{}, error) {
+	return func() (interface{}, error) {
+		return capi_client.List[json.RawMessage](c.Client, path, options)
+	}
+}
+
+// processStats maps each process GUID to its instances' stats.
+func (c Collector) processStats(appGUID string) (interface{}, error) {
+	processes, err := c.Client.ListAppProcesses(appGUID, capi_client.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	stats := map[string]json.RawMessage{}
+	for _, process := range processes {
+		var raw json.RawMessage
+		if err := c.Client.Get("/v3/processes/"+process.GUID+"/stats", &raw); err != nil {
+			return stats, err
+		}
+		stats[process.GUID] = raw
+	}
+	return stats, nil
+}
+
+func writeItem(directory, name string, value interface{}, fetchErr error) error {
+	if fetchErr != nil {
+		return writeError(directory, name, fetchErr)
+	}
+
+	contents, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return writeError(directory, name, err)
+	}
+	return os.WriteFile(filepath.Join(directory, name+".json"), append(contents, '\n'), 0644)
+}
+
+func writeError(directory, name string, err error) error {
+	return os.WriteFile(filepath.Join(directory, name+".error.txt"), []byte(err.Error()+"\n"), 0644)
+}
@@ -0,0 +1,154 @@
+package diagnostics_test
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry/capi-bara-tests/helpers/capi_client"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/diagnostics"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/fake_cc"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Diagnostics", func() {
+	Describe("tracked apps", func() {
+		BeforeEach(func() {
+			diagnostics.ResetTrackedApps()
+		})
+
+		It("remembers apps until reset", func() {
+			diagnostics.TrackApp("app-1")
+			diagnostics.TrackApp("app-2")
+			Expect(diagnostics.TrackedApps()).To(Equal([]string{"app-1", "app-2"}))
+
+			diagnostics.ResetTrackedApps()
+			Expect(diagnostics.TrackedApps()).To(BeEmpty())
+		})
+	})
+
+	It("names the bundle after the process and spec", func() {
+		directory := diagnostics.BundleDirectory("/results", "BARA", 3, "[deployments] deployments rolls back")
+		Expect(filepath.Dir(directory)).To(Equal("/results/diagnostics/BARA-3"))
+		Expect(filepath.Base(directory)).To(MatchRegexp(`^deployments-deployments-rolls-back-[0-9a-f]{8}$`))
+	})
+
+	Describe("Collector", func() {
+		var (
+			fakeCC    *fake_cc.FakeCC
+			client    *capi_client.Client
+			appGUID   string
+			directory string
+		)
+
+		readJSON := func(name string, value interface{}) {
+			contents, err := os.ReadFile(filepath.Join(directory, appGUID, name))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(json.Unmarshal(contents, value)).To(Succeed())
+		}
+
+		BeforeEach(func() {
+			fakeCC = fake_cc.New()
+			fakeCC.PollsPerTransition = 0
+			client = fakeCC.Client()
+			directory = filepath.Join(GinkgoT().TempDir(), "bundle")
+
+			app, err := client.CreateApp(capi_client.CreateAppRequest{
+				Name:          "some-app",
+				Relationships: capi_client.AppRelationships{Space: capi_client.NewRelationship("space-guid")},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			appGUID = app.GUID
+
+			zipPath := filepath.Join(GinkgoT().TempDir(), "app.zip")
+			Expect(os.WriteFile(zipPath, []byte("not really a zip"), 0644)).To(Succeed())
+			pkg, err := client.CreatePackage(appGUID)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = client.UploadPackageBits(pkg.GUID, zipPath)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = client.GetPackage(pkg.GUID)
+			Expect(err).NotTo(HaveOccurred())
+
+			build, err := client.CreateBuild(capi_client.CreateBuildRequest{Package: capi_client.GUIDRef{GUID: pkg.GUID}})
+			Expect(err).NotTo(HaveOccurred())
+			build, err = client.GetBuild(build.GUID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(client.SetAppCurrentDroplet(appGUID, build.Droplet.GUID)).To(Succeed())
+			_, err = client.StartApp(appGUID)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			fakeCC.Close()
+		})
+
+		It("writes the app's resources, logs and the errors it hit", func() {
+			collector := diagnostics.Collector{
+				Client:     client,
+				RecentLogs: func(guid string) (string, error) { return "logs for " + guid, nil },
+			}
+			Expect(collector.Collect(directory, []string{appGUID, appGUID})).To(Succeed())
+
+			entries, err := os.ReadDir(directory)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries).To(HaveLen(1))
+
+			var app capi_client.App
+			readJSON("app.json", &app)
+			Expect(app.Name).To(Equal("some-app"))
+
+			var processes []capi_client.Process
+			readJSON("processes.json", &processes)
+			Expect(processes).To(HaveLen(1))
+
+			var stats map[string]map[string]interface{}
+			readJSON("process_stats.json", &stats)
+			Expect(stats).To(HaveKey(processes[0].GUID))
+			Expect(stats[processes[0].GUID]).To(HaveKey("resources"))
+
+			var droplet capi_client.Droplet
+			readJSON("current_droplet.json", &droplet)
+			Expect(droplet.State).To(Equal(capi_client.DropletStateStaged))
+
+			var builds []capi_client.Build
+			readJSON("builds.json", &builds)
+			Expect(builds).To(HaveLen(1))
+
+			var deployments []capi_client.Deployment
+			readJSON("deployments.json", &deployments)
+			Expect(deployments).To(BeEmpty())
+
+			var revisions []capi_client.Revision
+			readJSON("revisions.json", &revisions)
+			Expect(revisions).To(HaveLen(1))
+
+			// the fake has no audit events
+			auditEventsError, err := os.ReadFile(filepath.Join(directory, appGUID, "audit_events.error.txt"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(auditEventsError)).To(ContainSubstring("404"))
+
+			logs, err := os.ReadFile(filepath.Join(directory, appGUID, "recent_logs.txt"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(logs)).To(Equal("logs for " + appGUID))
+		})
+
+		It("records apps that no longer exist instead of failing", func() {
+			collector := diagnostics.Collector{
+				Client:     client,
+				RecentLogs: func(string) (string, error) { return "", errors.New("log cache unavailable") },
+			}
+			Expect(collector.Collect(directory, []string{"deleted-app"})).To(Succeed())
+
+			appError, err := os.ReadFile(filepath.Join(directory, "deleted-app", "app.error.txt"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(appError)).To(ContainSubstring("CF-ResourceNotFound"))
+
+			logsError, err := os.ReadFile(filepath.Join(directory, "deleted-app", "recent_logs.error.txt"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(logsError)).To(Equal("log cache unavailable\n"))
+		})
+	})
+})
@@ -0,0 +1,271 @@
+package fake_cc
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/cloudfoundry/capi-bara-tests/helpers/capi_client"
+)
+
+type app struct {
+	capi_client.App
+	environmentVariables map[string]string
+	currentDroplet       string
+	features             map[string]bool
+}
+
+func (f *FakeCC) registerApps() {
+	f.router.HandleFunc("/v3/apps", f.listApps).Methods(http.MethodGet)
+	f.router.HandleFunc("/v3/apps", f.createApp).Methods(http.MethodPost)
+	f.router.HandleFunc("/v3/apps/{guid}", f.getApp).Methods(http.MethodGet)
+	f.router.HandleFunc("/v3/apps/{guid}", f.deleteApp).Methods(http.MethodDelete)
+	f.router.HandleFunc("/v3/apps/{guid}/actions/{action}", f.appAction).Methods(http.MethodPost)
+	f.router.HandleFunc("/v3/apps/{guid}/droplets/current", f.getCurrentDroplet).Methods(http.MethodGet)
+	f.router.HandleFunc("/v3/apps/{guid}/relationships/current_droplet", f.setCurrentDroplet).Methods(http.MethodPatch)
+	f.router.HandleFunc("/v3/apps/{guid}/environment_variables", f.getEnvironmentVariables).Methods(http.MethodGet)
+	f.router.HandleFunc("/v3/apps/{guid}/environment_variables", f.updateEnvironmentVariables).Methods(http.MethodPatch)
+	f.router.HandleFunc("/v3/apps/{guid}/features/{name}", f.updateFeature).Methods(http.MethodPatch)
+	f.router.HandleFunc("/v3/apps/{guid}/processes", f.listAppProcesses).Methods(http.MethodGet)
+	f.router.HandleFunc("/v3/apps/{guid}/processes/{type}/actions/scale", f.scaleAppProcess).Methods(http.MethodPost)
+	f.router.HandleFunc("/v3/apps/{guid}/revisions", f.listAppRevisions).Methods(http.MethodGet)
+	f.router.HandleFunc("/v3/apps/{guid}/routes", f.listAppRoutes).Methods(http.MethodGet)
+}
+
+func (f *FakeCC) listApps(w http.ResponseWriter, r *http.Request) {
+	spaceGUIDs := filterValues(r, "space_guids")
+	names := filterValues(r, "names")
+
+	apps := []capi_client.App{}
+	for _, a := range f.apps {
+		if (spaceGUIDs == nil || contains(spaceGUIDs, a.Relationships.Space.GUID())) && (names == nil || contains(names, a.Name)) {
+			apps = append(apps, a.App)
+		}
+	}
+	sortByCreatedAt(apps, func(a capi_client.App) capi_client.Resource { return a.Resource })
+	writeList(w, r, apps)
+}
+
+func (f *FakeCC) createApp(w http.ResponseWriter, r *http.Request) {
+	var request capi_client.CreateAppRequest
+	if !decode(w, r, &request) {
+		return
+	}
+
+	if request.Name == "" {
+		writeUnprocessable(w, "Name can't be blank")
+		return
+	}
+	spaceGUID := request.Relationships.Space.GUID()
+	if spaceGUID == "" {
+		writeUnprocessable(w, "Relationships Space can't be blank")
+		return
+	}
+	for _, existing := range f.apps {
+		if existing.Name == request.Name && existing.Relationships.Space.GUID() == spaceGUID {
+			writeError(w, http.StatusUnprocessableEntity, 10016, "CF-UniquenessError", fmt.Sprintf("App with the name '%s' already exists.", request.Name))
+			return
+		}
+	}
+
+	a := &app{
+		App: capi_client.App{
+			Resource:      f.newResource(),
+			Name:          request.Name,
+			State:         capi_client.AppStateStopped,
+			Lifecycle:     capi_client.Lifecycle{Type: "buildpack"},
+			Relationships: request.Relationships,
+		},
+		environmentVariables: map[string]string{},
+		features:             map[string]bool{"revisions": true},
+	}
+	if request.Lifecycle != nil {
+		a.Lifecycle = *request.Lifecycle
+	}
+	if request.Metadata != nil {
+		a.Metadata = *request.Metadata
+	}
+	for name, value := range request.EnvironmentVariables {
+		a.environmentVariables[name] = value
+	}
+	f.apps[a.GUID] = a
+
+	f.createProcess(a.GUID, "web", "")
+
+	writeJSON(w, http.StatusCreated, a.App)
+}
+
+func (f *FakeCC) getApp(w http.ResponseWriter, r *http.Request) {
+	a, ok := f.findApp(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, a.App)
+}
+
+func (f *FakeCC) deleteApp(w http.ResponseWriter, r *http.Request) {
+	a, ok := f.findApp(w, r)
+	if !ok {
+		return
+	}
+
+	for guid, p := range f.processes {
+		if p.Relationships.App.GUID() == a.GUID {
+			delete(f.processes, guid)
+		}
+	}
+	for guid, t := range f.tasks {
+		if t.Relationships.App.GUID() == a.GUID {
+			delete(f.tasks, guid)
+		}
+	}
+	for _, route := range f.routes {
+		route.Destinations = withoutApp(route.Destinations, a.GUID)
+	}
+	delete(f.apps, a.GUID)
+
+	f.writeJob(w, "app.delete")
+}
+
+func (f *FakeCC) appAction(w http.ResponseWriter, r *http.Request) {
+	a, ok := f.findApp(w, r)
+	if !ok {
+		return
+	}
+
+	switch mux.Vars(r)["action"] {
+	case "start", "restart":
+		if a.currentDroplet == "" {
+			writeUnprocessable(w, "Assign a droplet before starting this app.")
+			return
+		}
+		a.State = capi_client.AppStateStarted
+		f.ensureRevision(a, "Initial revision.")
+	case "stop":
+		a.State = capi_client.AppStateStopped
+	default:
+		writeNotFound(w, "Action")
+		return
+	}
+
+	f.touch(&a.Resource)
+	writeJSON(w, http.StatusOK, a.App)
+}
+
+func (f *FakeCC) getCurrentDroplet(w http.ResponseWriter, r *http.Request) {
+	a, ok := f.findApp(w, r)
+	if !ok {
+		return
+	}
+
+	d, ok := f.droplets[a.currentDroplet]
+	if !ok {
+		writeNotFound(w, "Droplet")
+		return
+	}
+	writeJSON(w, http.StatusOK, d.Droplet)
+}
+
+func (f *FakeCC) setCurrentDroplet(w http.ResponseWriter, r *http.Request) {
+	a, ok := f.findApp(w, r)
+	if !ok {
+		return
+	}
+
+	var request capi_client.Relationship
+	if !decode(w, r, &request) {
+		return
+	}
+
+	d, ok := f.droplets[request.GUID()]
+	if !ok || d.Relationships.App.GUID() != a.GUID || d.State != capi_client.DropletStateStaged {
+		writeUnprocessable(w, "Unable to assign current droplet. Ensure the droplet exists and belongs to this app.")
+		return
+	}
+
+	f.assignDroplet(a, d)
+
+	writeJSON(w, http.StatusOK, struct {
+		Data capi_client.RelationshipData `json:"data"`
+	}{capi_client.RelationshipData{GUID: d.GUID}})
+}
+
+func (f *FakeCC) getEnvironmentVariables(w http.ResponseWriter, r *http.Request) {
+	a, ok := f.findApp(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, environmentVariables(a.environmentVariables))
+}
+
+func (f *FakeCC) updateEnvironmentVariables(w http.ResponseWriter, r *http.Request) {
+	a, ok := f.findApp(w, r)
+	if !ok {
+		return
+	}
+
+	var request capi_client.EnvironmentVariables
+	if !decode(w, r, &request) {
+		return
+	}
+
+	for name, value := range request.Var {
+		if value == nil {
+			delete(a.environmentVariables, name)
+		} else {
+			a.environmentVariables[name] = *value
+		}
+	}
+	writeJSON(w, http.StatusOK, environmentVariables(a.environmentVariables))
+}
+
+func (f *FakeCC) updateFeature(w http.ResponseWriter, r *http.Request) {
+	a, ok := f.findApp(w, r)
+	if !ok {
+		return
+	}
+
+	var request struct {
+		Enabled bool `json:"enabled"`
+	}
+	if !decode(w, r, &request) {
+		return
+	}
+
+	name := mux.Vars(r)["name"]
+	a.features[name] = request.Enabled
+	writeJSON(w, http.StatusOK, map[string]interface{}{"name": name, "enabled": request.Enabled})
+}
+
+func (f *FakeCC) findApp(w http.ResponseWriter, r *http.Request) (*app, bool) {
+	a, ok := f.apps[mux.Vars(r)["guid"]]
+	if !ok {
+		writeNotFound(w, "App")
+	}
+	return a, ok
+}
+
+// assignDroplet makes the droplet current and gives every process type it
+// declares a process running that type's command.
+func (f *FakeCC) assignDroplet(a *app, d *droplet) {
+	a.currentDroplet = d.GUID
+	f.touch(&a.Resource)
+
+	for processType, command := range d.ProcessTypes {
+		if p := f.appProcess(a.GUID, processType); p != nil {
+			p.Command = command
+			continue
+		}
+		f.createProcess(a.GUID, processType, command)
+	}
+}
+
+func environmentVariables(vars map[string]string) capi_client.EnvironmentVariables {
+	result := capi_client.EnvironmentVariables{Var: map[string]*string{}}
+	for name, value := range vars {
+		value := value
+		result.Var[name] = &value
+	}
+	return result
+}
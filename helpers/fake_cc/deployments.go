@@ -0,0 +1,435 @@
+package fake_cc
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/cloudfoundry/capi-bara-tests/helpers/capi_client"
+)
+
+type deployment struct {
+	capi_client.Deployment
+	transition
+	// instances is the number of web instances the app had when the
+	// deployment was created, which the new web process scales up to.
+	instances int
+	// continued is set once a paused canary deployment is continued.
+	continued bool
+	// starting is how many of the new web process's instances, the last
+	// ones, have been started but are not yet healthy.
+	starting int
+}
+
+type revision struct {
+	capi_client.Revision
+	appGUID              string
+	environmentVariables map[string]string
+}
+
+func (f *FakeCC) registerDeployments() {
+	f.router.HandleFunc("/v3/deployments", f.listDeployments).Methods(http.MethodGet)
+	f.router.HandleFunc("/v3/deployments", f.createDeployment).Methods(http.MethodPost)
+	f.router.HandleFunc("/v3/deployments/{guid}", f.getDeployment).Methods(http.MethodGet)
+	f.router.HandleFunc("/v3/deployments/{guid}/actions/cancel", f.cancelDeployment).Methods(http.MethodPost)
+	f.router.HandleFunc("/v3/deployments/{guid}/actions/continue", f.continueDeployment).Methods(http.MethodPost)
+	f.router.HandleFunc("/v3/revisions/{guid}", f.getRevision).Methods(http.MethodGet)
+	f.router.HandleFunc("/v3/revisions/{guid}/environment_variables", f.getRevisionEnvironmentVariables).Methods(http.MethodGet)
+}
+
+func (f *FakeCC) createDeployment(w http.ResponseWriter, r *http.Request) {
+	var request capi_client.CreateDeploymentRequest
+	if !decode(w, r, &request) {
+		return
+	}
+
+	a, ok := f.apps[request.Relationships.App.GUID()]
+	if !ok {
+		writeUnprocessable(w, "Unable to use app. Ensure that the app exists and you have access to it.")
+		return
+	}
+
+	dropletGUID := a.currentDroplet
+	description := "New droplet deployed."
+	if request.Droplet != nil {
+		dropletGUID = request.Droplet.GUID
+	}
+	if request.Revision != nil {
+		rollbackTo, ok := f.revisions[request.Revision.GUID]
+		if !ok || rollbackTo.appGUID != a.GUID {
+			writeUnprocessable(w, "The revision must belong to the app.")
+			return
+		}
+		dropletGUID = rollbackTo.Droplet.GUID
+		description = fmt.Sprintf("Rolled back to revision %d.", rollbackTo.Version)
+	}
+	if _, ok := f.droplets[dropletGUID]; !ok {
+		writeUnprocessable(w, "Invalid droplet. Please specify a droplet in the request or set a current droplet for the app.")
+		return
+	}
+
+	maxInFlight := 1
+	if request.Options != nil && request.Options.MaxInFlight != nil {
+		maxInFlight = *request.Options.MaxInFlight
+	}
+	if maxInFlight < 1 {
+		writeUnprocessable(w, "Max in flight must be an integer greater than 0")
+		return
+	}
+
+	for _, existing := range f.deployments {
+		if existing.Relationships.App.GUID() == a.GUID && existing.Status.Value == capi_client.DeploymentStatusValueActive {
+			f.setDeploymentStatus(existing, capi_client.DeploymentStatusValueFinalized, capi_client.DeploymentStatusReasonSuperseded)
+		}
+	}
+
+	rev := f.newRevision(a, dropletGUID, description)
+	web := f.appProcess(a.GUID, "web")
+	newWeb := f.createProcess(a.GUID, "web", f.droplets[dropletGUID].ProcessTypes["web"])
+	instances := 1
+	if web != nil {
+		instances = web.Instances
+		newWeb.MemoryInMB = web.MemoryInMB
+		newWeb.DiskInMB = web.DiskInMB
+		newWeb.HealthCheck = web.HealthCheck
+	}
+
+	strategy := request.Strategy
+	if strategy == "" {
+		strategy = capi_client.DeploymentStrategyRolling
+	}
+
+	d := &deployment{Deployment: capi_client.Deployment{
+		Resource:        f.newResource(),
+		Strategy:        strategy,
+		Options:         capi_client.DeploymentOptions{MaxInFlight: &maxInFlight},
+		Droplet:         capi_client.GUIDRef{GUID: dropletGUID},
+		PreviousDroplet: capi_client.GUIDRef{GUID: a.currentDroplet},
+		NewProcesses:    []capi_client.DeploymentProcess{{GUID: newWeb.GUID, Type: newWeb.Type}},
+		Revision:        &capi_client.DeploymentRevision{GUID: rev.GUID, Version: rev.Version},
+		Relationships:   capi_client.DeploymentRelationships{App: capi_client.NewRelationship(a.GUID)},
+	}, instances: instances}
+	newWeb.Instances = min(maxInFlight, instances)
+	if strategy == capi_client.DeploymentStrategyCanary {
+		newWeb.Instances = min(1, instances)
+	}
+	d.starting = newWeb.Instances
+	f.setDeploymentStatus(d, capi_client.DeploymentStatusValueActive, capi_client.DeploymentStatusReasonDeploying)
+	f.deployments[d.GUID] = d
+
+	a.currentDroplet = dropletGUID
+	a.State = capi_client.AppStateStarted
+	f.touch(&a.Resource)
+
+	writeJSON(w, http.StatusCreated, d.Deployment)
+}
+
+func (f *FakeCC) listDeployments(w http.ResponseWriter, r *http.Request) {
+	appGUIDs := filterValues(r, "app_guids")
+
+	deployments := []capi_client.Deployment{}
+	for _, d := range f.deployments {
+		if appGUIDs == nil || contains(appGUIDs, d.Relationships.App.GUID()) {
+			deployments = append(deployments, d.Deployment)
+		}
+	}
+	sortByCreatedAt(deployments, func(d capi_client.Deployment) capi_client.Resource { return d.Resource })
+	writeList(w, r, deployments)
+}
+
+func (f *FakeCC) getDeployment(w http.ResponseWriter, r *http.Request) {
+	d, ok := f.findDeployment(w, r)
+	if !ok {
+		return
+	}
+
+	if d.Status.Value == capi_client.DeploymentStatusValueActive && f.advance(&d.transition) {
+		f.stepDeployment(d)
+	}
+	writeJSON(w, http.StatusOK, d.Deployment)
+}
+
+func (f *FakeCC) cancelDeployment(w http.ResponseWriter, r *http.Request) {
+	d, ok := f.findDeployment(w, r)
+	if !ok {
+		return
+	}
+
+	if d.Status.Value != capi_client.DeploymentStatusValueActive ||
+		(d.Status.Reason != capi_client.DeploymentStatusReasonDeploying && d.Status.Reason != capi_client.DeploymentStatusReasonPaused) {
+		writeUnprocessable(w, fmt.Sprintf("Cannot cancel a deployment with status: %s and reason: %s", d.Status.Value, d.Status.Reason))
+		return
+	}
+
+	d.transition = transition{}
+	f.setDeploymentStatus(d, capi_client.DeploymentStatusValueActive, capi_client.DeploymentStatusReasonCanceling)
+	writeJSON(w, http.StatusOK, d.Deployment)
+}
+
+func (f *FakeCC) continueDeployment(w http.ResponseWriter, r *http.Request) {
+	d, ok := f.findDeployment(w, r)
+	if !ok {
+		return
+	}
+
+	if d.Status.Value != capi_client.DeploymentStatusValueActive || d.Status.Reason != capi_client.DeploymentStatusReasonPaused {
+		writeUnprocessable(w, fmt.Sprintf("Cannot continue a deployment with status: %s and reason: %s", d.Status.Value, d.Status.Reason))
+		return
+	}
+
+	d.transition = transition{}
+	d.continued = true
+	f.setDeploymentStatus(d, capi_client.DeploymentStatusValueActive, capi_client.DeploymentStatusReasonDeploying)
+	writeJSON(w, http.StatusOK, d.Deployment)
+}
+
+// stepDeployment rolls a deploying deployment forward, as Cloud Controller
+// does once the instances it started are healthy: the old web processes are
+// scaled down by as many instances as the new one runs, and up to
+// max_in_flight more new instances are started. The deployment finishes once
+// the new process runs every instance. A canary deployment pauses once its
+// canary instance is healthy, until it is continued.
+func (f *FakeCC) stepDeployment(d *deployment) {
+	if d.Status.Reason == capi_client.DeploymentStatusReasonPaused {
+		return
+	}
+	d.starting = 0
+	if d.Status.Reason == capi_client.DeploymentStatusReasonDeploying && d.Strategy == capi_client.DeploymentStrategyCanary && !d.continued {
+		f.setDeploymentStatus(d, capi_client.DeploymentStatusValueActive, capi_client.DeploymentStatusReasonPaused)
+		return
+	}
+
+	newWeb := f.processes[d.NewProcesses[0].GUID]
+	if d.Status.Reason != capi_client.DeploymentStatusReasonDeploying || newWeb == nil {
+		f.finishDeployment(d)
+		return
+	}
+
+	f.scaleDownOldWebProcesses(d, d.instances-newWeb.Instances)
+	if newWeb.Instances >= d.instances {
+		f.finishDeployment(d)
+		return
+	}
+
+	d.starting = min(*d.Options.MaxInFlight, d.instances-newWeb.Instances)
+	newWeb.Instances += d.starting
+	f.touch(&newWeb.Resource)
+}
+
+// scaleDownOldWebProcesses removes instances from the oldest old web
+// processes first until they run at most instances between them.
+func (f *FakeCC) scaleDownOldWebProcesses(d *deployment, instances int) {
+	old := f.oldWebProcesses(d)
+	excess := -max(instances, 0)
+	for _, p := range old {
+		excess += p.Instances
+	}
+
+	for _, p := range old {
+		if excess <= 0 {
+			return
+		}
+		removed := min(excess, p.Instances)
+		p.Instances -= removed
+		excess -= removed
+		f.touch(&p.Resource)
+	}
+}
+
+// startingInstances returns how many of a process's instances a deployment
+// has started that are not yet healthy.
+func (f *FakeCC) startingInstances(processGUID string) int {
+	for _, d := range f.deployments {
+		if d.Status.Value == capi_client.DeploymentStatusValueActive && d.NewProcesses[0].GUID == processGUID {
+			return d.starting
+		}
+	}
+	return 0
+}
+
+// oldWebProcesses returns the app's web processes the deployment is
+// replacing, oldest first.
+func (f *FakeCC) oldWebProcesses(d *deployment) []*capi_client.Process {
+	newProcesses := map[string]bool{}
+	for _, p := range d.NewProcesses {
+		newProcesses[p.GUID] = true
+	}
+
+	processes := []*capi_client.Process{}
+	for _, p := range f.appProcesses(d.Relationships.App.GUID()) {
+		if p.Type == "web" && !newProcesses[p.GUID] {
+			processes = append(processes, p)
+		}
+	}
+	return processes
+}
+
+// finishDeployment moves an active deployment to FINALIZED. A deploying
+// deployment replaces the app's web process with the new one; a canceling
+// deployment throws the new process away, scales the oldest web process
+// back up and restores the app's previous droplet.
+func (f *FakeCC) finishDeployment(d *deployment) {
+	appGUID := d.Relationships.App.GUID()
+
+	if d.Status.Reason == capi_client.DeploymentStatusReasonCanceling {
+		for _, p := range d.NewProcesses {
+			delete(f.processes, p.GUID)
+		}
+		if old := f.oldWebProcesses(d); len(old) > 0 {
+			old[0].Instances = d.instances
+			f.touch(&old[0].Resource)
+		}
+		if a, ok := f.apps[appGUID]; ok {
+			a.currentDroplet = d.PreviousDroplet.GUID
+			f.touch(&a.Resource)
+		}
+		f.setDeploymentStatus(d, capi_client.DeploymentStatusValueFinalized, capi_client.DeploymentStatusReasonCanceled)
+		return
+	}
+
+	for _, p := range f.oldWebProcesses(d) {
+		delete(f.processes, p.GUID)
+	}
+	if a, ok := f.apps[appGUID]; ok {
+		a.currentDroplet = d.Droplet.GUID
+		f.touch(&a.Resource)
+	}
+	f.setDeploymentStatus(d, capi_client.DeploymentStatusValueFinalized, capi_client.DeploymentStatusReasonDeployed)
+}
+
+func (f *FakeCC) setDeploymentStatus(d *deployment, value, reason string) {
+	f.touch(&d.Resource)
+	changedAt := d.UpdatedAt
+
+	d.Status.Value = value
+	d.Status.Reason = reason
+	d.Status.Details.LastStatusChange = &changedAt
+	if reason == capi_client.DeploymentStatusReasonDeployed {
+		d.Status.Details.LastSuccessfulHealthcheck = &changedAt
+	}
+
+	switch reason {
+	case capi_client.DeploymentStatusReasonSuperseded:
+		d.State = capi_client.DeploymentStatusReasonDeployed
+	default:
+		d.State = reason
+	}
+}
+
+func (f *FakeCC) findDeployment(w http.ResponseWriter, r *http.Request) (*deployment, bool) {
+	d, ok := f.deployments[mux.Vars(r)["guid"]]
+	if !ok {
+		writeNotFound(w, "Deployment")
+	}
+	return d, ok
+}
+
+// ensureRevision records a revision when the app runs a droplet its latest
+// revision does not, as Cloud Controller does on start.
+func (f *FakeCC) ensureRevision(a *app, description string) {
+	if !a.features["revisions"] {
+		return
+	}
+	if latest := f.latestRevision(a.GUID); latest != nil && latest.Droplet.GUID == a.currentDroplet {
+		return
+	}
+
+	rev := f.newRevision(a, a.currentDroplet, description)
+	for _, p := range f.appProcesses(a.GUID) {
+		p.Relationships.Revision = capi_client.NewRelationship(rev.GUID)
+	}
+}
+
+func (f *FakeCC) newRevision(a *app, dropletGUID, description string) *revision {
+	version := 1
+	if latest := f.latestRevision(a.GUID); latest != nil {
+		version = latest.Version + 1
+	}
+
+	rev := &revision{
+		Revision: capi_client.Revision{
+			Resource:    f.newResource(),
+			Version:     version,
+			Description: description,
+			Deployable:  true,
+			Droplet:     capi_client.GUIDRef{GUID: dropletGUID},
+			Processes:   map[string]capi_client.RevisionProcess{},
+			Sidecars:    []capi_client.RevisionSidecar{},
+		},
+		appGUID:              a.GUID,
+		environmentVariables: map[string]string{},
+	}
+	if d, ok := f.droplets[dropletGUID]; ok {
+		for processType, command := range d.ProcessTypes {
+			rev.Processes[processType] = capi_client.RevisionProcess{Command: command}
+		}
+	}
+	for _, p := range f.appProcesses(a.GUID) {
+		if p.Command != "" {
+			rev.Processes[p.Type] = capi_client.RevisionProcess{Command: p.Command}
+		}
+	}
+	for name, value := range a.environmentVariables {
+		rev.environmentVariables[name] = value
+	}
+
+	f.revisions[rev.GUID] = rev
+	return rev
+}
+
+func (f *FakeCC) appRevisions(appGUID string) []*revision {
+	revisions := []*revision{}
+	for _, rev := range f.revisions {
+		if rev.appGUID == appGUID {
+			revisions = append(revisions, rev)
+		}
+	}
+	sortByCreatedAt(revisions, func(rev *revision) capi_client.Resource { return rev.Resource })
+	return revisions
+}
+
+func (f *FakeCC) latestRevision(appGUID string) *revision {
+	revisions := f.appRevisions(appGUID)
+	if len(revisions) == 0 {
+		return nil
+	}
+	return revisions[len(revisions)-1]
+}
+
+func (f *FakeCC) listAppRevisions(w http.ResponseWriter, r *http.Request) {
+	a, ok := f.findApp(w, r)
+	if !ok {
+		return
+	}
+
+	revisions := []capi_client.Revision{}
+	for _, rev := range f.appRevisions(a.GUID) {
+		revisions = append(revisions, rev.Revision)
+	}
+	writeList(w, r, revisions)
+}
+
+func (f *FakeCC) getRevision(w http.ResponseWriter, r *http.Request) {
+	rev, ok := f.findRevision(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, rev.Revision)
+}
+
+func (f *FakeCC) getRevisionEnvironmentVariables(w http.ResponseWriter, r *http.Request) {
+	rev, ok := f.findRevision(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, environmentVariables(rev.environmentVariables))
+}
+
+func (f *FakeCC) findRevision(w http.ResponseWriter, r *http.Request) (*revision, bool) {
+	rev, ok := f.revisions[mux.Vars(r)["guid"]]
+	if !ok {
+		writeNotFound(w, "Revision")
+	}
+	return rev, ok
+}
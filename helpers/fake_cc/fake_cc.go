@@ -0,0 +1,341 @@
+package fake_cc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/cloudfoundry/capi-bara-tests/helpers/capi_client"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/config"
+)
+
+const AuthToken = "bearer fake-cc-token"
+
+// FakeCC is an in-memory Cloud Controller serving the subset of the v3 API
+// the helpers use. Asynchronous resources (packages, builds, droplets,
+// deployments, tasks, jobs and operations of asynchronous service plans)
+// move to their next state after being read PollsPerTransition times, so
+// helpers that poll see the same sequence of states they would against a
+// real foundation.
+type FakeCC struct {
+	PollsPerTransition int
+
+	server *httptest.Server
+	router *mux.Router
+	clock  time.Time
+
+	mu       sync.Mutex
+	requests []Request
+
+	apps        map[string]*app
+	packages    map[string]*pkg
+	builds      map[string]*build
+	droplets    map[string]*droplet
+	processes   map[string]*capi_client.Process
+	deployments map[string]*deployment
+	revisions   map[string]*revision
+	routes      map[string]*capi_client.Route
+	tasks       map[string]*task
+	jobs        map[string]*job
+	named       map[string]map[string]*named
+	orgQuotas   map[string]string
+
+	serviceOfferings          map[string]*capi_client.ServiceOffering
+	servicePlans              map[string]*servicePlan
+	serviceInstances          map[string]*serviceInstance
+	serviceCredentialBindings map[string]*serviceCredentialBinding
+
+	stagingFailures map[string]string
+	taskFailures    map[string]string
+
+	serviceInstanceFailures map[string]string
+}
+
+type Request struct {
+	Method string
+	Path   string
+	Query  url.Values
+}
+
+type job struct {
+	capi_client.Job
+	transition
+	// operation is the service operation the job waits on, if any.
+	operation *serviceOperation
+}
+
+// transition counts the reads of a resource in its current state.
+type transition struct {
+	polls int
+}
+
+func New() *FakeCC {
+	f := &FakeCC{
+		PollsPerTransition: 1,
+
+		clock: time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC),
+
+		apps:        map[string]*app{},
+		packages:    map[string]*pkg{},
+		builds:      map[string]*build{},
+		droplets:    map[string]*droplet{},
+		processes:   map[string]*capi_client.Process{},
+		deployments: map[string]*deployment{},
+		revisions:   map[string]*revision{},
+		routes:      map[string]*capi_client.Route{},
+		tasks:       map[string]*task{},
+		jobs:        map[string]*job{},
+		named:       map[string]map[string]*named{},
+		orgQuotas:   map[string]string{},
+
+		serviceOfferings:          map[string]*capi_client.ServiceOffering{},
+		servicePlans:              map[string]*servicePlan{},
+		serviceInstances:          map[string]*serviceInstance{},
+		serviceCredentialBindings: map[string]*serviceCredentialBinding{},
+
+		stagingFailures: map[string]string{},
+		taskFailures:    map[string]string{},
+
+		serviceInstanceFailures: map[string]string{},
+	}
+
+	f.router = mux.NewRouter()
+	f.registerApps()
+	f.registerPackages()
+	f.registerProcesses()
+	f.registerDeployments()
+	f.registerRoutes()
+	f.registerTasks()
+	f.registerServices()
+	f.registerServiceInstances()
+	f.registerNamed()
+	f.router.HandleFunc("/v3/jobs/{guid}", f.getJob).Methods(http.MethodGet)
+	f.router.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, 10000, "CF-NotFound", "Unknown request")
+	})
+
+	f.server = httptest.NewServer(f)
+	return f
+}
+
+func (f *FakeCC) URL() string {
+	return f.server.URL
+}
+
+func (f *FakeCC) Close() {
+	f.server.Close()
+}
+
+// Config returns a BaraConfig pointing at the fake, for assigning to
+// bara_suite_helpers.Config in helper tests.
+func (f *FakeCC) Config() config.BaraConfig {
+	cfg, err := config.NewLocalConfig(f.URL(), AuthToken)
+	if err != nil {
+		panic(err)
+	}
+	return cfg
+}
+
+func (f *FakeCC) Client() *capi_client.Client {
+	return capi_client.NewClient(f.URL(), func() string { return AuthToken }, false)
+}
+
+// Requests returns every request received so far, in order.
+func (f *FakeCC) Requests() []Request {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Request{}, f.requests...)
+}
+
+// FailStaging makes builds of the package fail with the given error.
+func (f *FakeCC) FailStaging(packageGUID, reason string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stagingFailures[packageGUID] = reason
+}
+
+func (f *FakeCC) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requests = append(f.requests, Request{Method: r.Method, Path: r.URL.Path, Query: r.URL.Query()})
+
+	if r.Header.Get("Authorization") != AuthToken {
+		writeError(w, http.StatusUnauthorized, 1000, "CF-InvalidAuthToken", "Invalid Auth Token")
+		return
+	}
+
+	f.router.ServeHTTP(w, r)
+}
+
+func (f *FakeCC) advance(t *transition) bool {
+	if t.polls < f.PollsPerTransition {
+		t.polls++
+		return false
+	}
+	t.polls = 0
+	return true
+}
+
+func (f *FakeCC) newResource() capi_client.Resource {
+	f.clock = f.clock.Add(time.Second)
+	return capi_client.Resource{
+		GUID:      uuid.NewV4().String(),
+		CreatedAt: f.clock,
+		UpdatedAt: f.clock,
+	}
+}
+
+func (f *FakeCC) touch(resource *capi_client.Resource) {
+	f.clock = f.clock.Add(time.Second)
+	resource.UpdatedAt = f.clock
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status, code int, title, detail string) {
+	writeJSON(w, status, map[string][]capi_client.Error{
+		"errors": {{Code: code, Title: title, Detail: detail}},
+	})
+}
+
+func writeNotFound(w http.ResponseWriter, resource string) {
+	writeError(w, http.StatusNotFound, 10010, "CF-ResourceNotFound", fmt.Sprintf("%s not found", resource))
+}
+
+func writeUnprocessable(w http.ResponseWriter, detail string) {
+	writeError(w, http.StatusUnprocessableEntity, 10008, "CF-UnprocessableEntity", detail)
+}
+
+func decode(w http.ResponseWriter, r *http.Request, body interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+		writeError(w, http.StatusBadRequest, 1001, "CF-MessageParseError", "Request invalid due to parse error: "+err.Error())
+		return false
+	}
+	return true
+}
+
+func (f *FakeCC) newJob(operation string) *job {
+	j := &job{Job: capi_client.Job{Resource: f.newResource(), Operation: operation, State: capi_client.JobStateProcessing}}
+	f.jobs[j.GUID] = j
+	return j
+}
+
+func (f *FakeCC) jobURL(j *job) string {
+	return fmt.Sprintf("%s/v3/jobs/%s", f.URL(), j.GUID)
+}
+
+func (f *FakeCC) writeJob(w http.ResponseWriter, operation string) {
+	w.Header().Set("Location", f.jobURL(f.newJob(operation)))
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// writeList renders one page of resources, honouring per_page and page and
+// linking to the next page the way Cloud Controller does.
+func writeList[T any](w http.ResponseWriter, r *http.Request, resources []T) {
+	query := r.URL.Query()
+	perPage, err := strconv.Atoi(query.Get("per_page"))
+	if err != nil || perPage <= 0 {
+		perPage = 50
+	}
+	pageNumber, err := strconv.Atoi(query.Get("page"))
+	if err != nil || pageNumber <= 0 {
+		pageNumber = 1
+	}
+
+	totalPages := (len(resources) + perPage - 1) / perPage
+	if totalPages == 0 {
+		totalPages = 1
+	}
+
+	pageLink := func(number int) *capi_client.Link {
+		linkQuery := url.Values{}
+		for key, values := range query {
+			linkQuery[key] = values
+		}
+		linkQuery.Set("page", strconv.Itoa(number))
+		linkQuery.Set("per_page", strconv.Itoa(perPage))
+		return &capi_client.Link{Href: fmt.Sprintf("http://%s%s?%s", r.Host, r.URL.Path, linkQuery.Encode())}
+	}
+
+	pagination := capi_client.Pagination{
+		TotalResults: len(resources),
+		TotalPages:   totalPages,
+		First:        pageLink(1),
+		Last:         pageLink(totalPages),
+	}
+	if pageNumber < totalPages {
+		pagination.Next = pageLink(pageNumber + 1)
+	}
+	if pageNumber > 1 {
+		pagination.Previous = pageLink(pageNumber - 1)
+	}
+
+	start := (pageNumber - 1) * perPage
+	if start > len(resources) {
+		start = len(resources)
+	}
+	end := start + perPage
+	if end > len(resources) {
+		end = len(resources)
+	}
+
+	writeJSON(w, http.StatusOK, struct {
+		Pagination capi_client.Pagination `json:"pagination"`
+		Resources  []T                    `json:"resources"`
+	}{pagination, resources[start:end]})
+}
+
+// filterValues returns the comma separated values of a list filter.
+func filterValues(r *http.Request, name string) []string {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func sortByCreatedAt[T any](resources []T, resource func(T) capi_client.Resource) {
+	sort.Slice(resources, func(i, j int) bool {
+		return resource(resources[i]).CreatedAt.Before(resource(resources[j]).CreatedAt)
+	})
+}
+
+func (f *FakeCC) getJob(w http.ResponseWriter, r *http.Request) {
+	j, ok := f.jobs[mux.Vars(r)["guid"]]
+	if !ok {
+		writeNotFound(w, "Job")
+		return
+	}
+
+	if j.operation != nil {
+		f.followServiceOperation(j)
+	} else if j.State == capi_client.JobStateProcessing && f.advance(&j.transition) {
+		j.State = capi_client.JobStateComplete
+		f.touch(&j.Resource)
+	}
+	writeJSON(w, http.StatusOK, j.Job)
+}
@@ -0,0 +1,212 @@
+package fake_cc_test
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cloudfoundry/capi-bara-tests/helpers/capi_client"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/fake_cc"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FakeCC", func() {
+	var (
+		fakeCC  *fake_cc.FakeCC
+		client  *capi_client.Client
+		appGUID string
+		zipPath string
+	)
+
+	stage := func() string {
+		pkg, err := client.CreatePackage(appGUID)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = client.UploadPackageBits(pkg.GUID, zipPath)
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(func() (string, error) {
+			pkg, err := client.GetPackage(pkg.GUID)
+			return pkg.State, err
+		}).Should(Equal(capi_client.PackageStateReady))
+
+		build, err := client.CreateBuild(capi_client.CreateBuildRequest{Package: capi_client.GUIDRef{GUID: pkg.GUID}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(build.State).To(Equal(capi_client.BuildStateStaging))
+
+		Eventually(func() (string, error) {
+			build, err = client.GetBuild(build.GUID)
+			return build.State, err
+		}).Should(Equal(capi_client.BuildStateStaged))
+		return build.Droplet.GUID
+	}
+
+	BeforeEach(func() {
+		fakeCC = fake_cc.New()
+		client = fakeCC.Client()
+
+		zipPath = filepath.Join(GinkgoT().TempDir(), "app.zip")
+		Expect(os.WriteFile(zipPath, []byte("not really a zip"), 0644)).To(Succeed())
+
+		app, err := client.CreateApp(capi_client.CreateAppRequest{
+			Name:          "some-app",
+			Relationships: capi_client.AppRelationships{Space: capi_client.NewRelationship("space-guid")},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		appGUID = app.GUID
+	})
+
+	AfterEach(func() {
+		fakeCC.Close()
+	})
+
+	It("rejects requests without the fake's auth token", func() {
+		unauthenticated := capi_client.NewClient(fakeCC.URL(), func() string { return "bearer wrong" }, false)
+		_, err := unauthenticated.GetApp(appGUID)
+		Expect(err).To(MatchError(ContainSubstring("CF-InvalidAuthToken")))
+	})
+
+	It("returns Cloud Controller style errors for unknown resources", func() {
+		_, err := client.GetApp("no-such-app")
+		Expect(capi_client.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("stages packages through STAGING to STAGED", func() {
+		dropletGUID := stage()
+
+		droplet, err := client.GetDroplet(dropletGUID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(droplet.State).To(Equal(capi_client.DropletStateStaged))
+		Expect(droplet.Relationships.App.GUID()).To(Equal(appGUID))
+	})
+
+	It("fails builds of packages configured to fail", func() {
+		pkg, err := client.CreatePackage(appGUID)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = client.UploadPackageBits(pkg.GUID, zipPath)
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(func() (string, error) {
+			pkg, err := client.GetPackage(pkg.GUID)
+			return pkg.State, err
+		}).Should(Equal(capi_client.PackageStateReady))
+
+		fakeCC.FailStaging(pkg.GUID, "NoAppDetectedError")
+		build, err := client.CreateBuild(capi_client.CreateBuildRequest{Package: capi_client.GUIDRef{GUID: pkg.GUID}})
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(func() (string, error) {
+			build, err = client.GetBuild(build.GUID)
+			return build.State, err
+		}).Should(Equal(capi_client.BuildStateFailed))
+		Expect(build.Error).To(Equal("NoAppDetectedError"))
+	})
+
+	It("finalizes deployments and replaces the web process", func() {
+		dropletGUID := stage()
+		Expect(client.SetAppCurrentDroplet(appGUID, dropletGUID)).To(Succeed())
+		_, err := client.StartApp(appGUID)
+		Expect(err).NotTo(HaveOccurred())
+
+		oldWeb, err := client.ListAppProcesses(appGUID, capi_client.ListOptions{}.Filter("types", "web"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(oldWeb).To(HaveLen(1))
+
+		deployment, err := client.CreateDeployment(capi_client.CreateDeploymentRequest{
+			Relationships: capi_client.DeploymentRelationships{App: capi_client.NewRelationship(appGUID)},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(deployment.Status.Value).To(Equal(capi_client.DeploymentStatusValueActive))
+		Expect(deployment.Status.Reason).To(Equal(capi_client.DeploymentStatusReasonDeploying))
+		Expect(deployment.Revision.Version).To(Equal(2))
+
+		Eventually(func() (string, error) {
+			deployment, err = client.GetDeployment(deployment.GUID)
+			return deployment.Status.Reason, err
+		}).Should(Equal(capi_client.DeploymentStatusReasonDeployed))
+		Expect(deployment.Status.Value).To(Equal(capi_client.DeploymentStatusValueFinalized))
+
+		web, err := client.ListAppProcesses(appGUID, capi_client.ListOptions{}.Filter("types", "web"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(web).To(HaveLen(1))
+		Expect(web[0].GUID).To(Equal(deployment.NewProcesses[0].GUID))
+		Expect(web[0].Relationships.Revision.GUID()).To(Equal(deployment.Revision.GUID))
+	})
+
+	It("cancels deployments", func() {
+		dropletGUID := stage()
+		Expect(client.SetAppCurrentDroplet(appGUID, dropletGUID)).To(Succeed())
+
+		deployment, err := client.CreateDeployment(capi_client.CreateDeploymentRequest{
+			Relationships: capi_client.DeploymentRelationships{App: capi_client.NewRelationship(appGUID)},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(client.CancelDeployment(deployment.GUID)).To(Succeed())
+
+		Eventually(func() (string, error) {
+			deployment, err = client.GetDeployment(deployment.GUID)
+			return deployment.Status.Reason, err
+		}).Should(Equal(capi_client.DeploymentStatusReasonCanceled))
+
+		Expect(client.CancelDeployment(deployment.GUID)).To(MatchError(ContainSubstring("Cannot cancel a deployment")))
+	})
+
+	It("completes asynchronous deletes through a job", func() {
+		jobPath, err := client.DeleteApp(appGUID)
+		Expect(err).NotTo(HaveOccurred())
+
+		poll, err := client.WaitForJob(jobPath, capi_client.JobStateComplete, time.Second, time.Millisecond)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(poll.States).To(Equal([]string{capi_client.JobStateProcessing, capi_client.JobStateComplete}))
+
+		_, err = client.GetApp(appGUID)
+		Expect(capi_client.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("paginates list endpoints", func() {
+		dropletGUID := stage()
+		Expect(client.SetAppCurrentDroplet(appGUID, dropletGUID)).To(Succeed())
+		_, err := client.StartApp(appGUID)
+		Expect(err).NotTo(HaveOccurred())
+		for i := 0; i < 2; i++ {
+			_, err := client.CreateDeployment(capi_client.CreateDeploymentRequest{
+				Relationships: capi_client.DeploymentRelationships{App: capi_client.NewRelationship(appGUID)},
+			})
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		revisions, err := client.ListAppRevisions(appGUID, capi_client.ListOptions{PerPage: 1})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(revisions).To(HaveLen(3))
+		Expect([]int{revisions[0].Version, revisions[1].Version, revisions[2].Version}).To(Equal([]int{1, 2, 3}))
+
+		revisionRequests := 0
+		for _, request := range fakeCC.Requests() {
+			if request.Path == "/v3/apps/"+appGUID+"/revisions" {
+				revisionRequests++
+			}
+		}
+		Expect(revisionRequests).To(Equal(3))
+	})
+
+	It("maps routes to app processes", func() {
+		route, err := client.CreateRoute(capi_client.CreateRouteRequest{
+			Host: "some-host",
+			Relationships: capi_client.RouteRelationships{
+				Space:  capi_client.NewRelationship("space-guid"),
+				Domain: capi_client.NewRelationship("domain-guid"),
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = client.InsertRouteDestinations(route.GUID, []capi_client.Destination{{App: capi_client.DestinationApp{GUID: appGUID}}})
+		Expect(err).NotTo(HaveOccurred())
+
+		routes, err := client.ListAppRoutes(appGUID, capi_client.ListOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(routes).To(HaveLen(1))
+		Expect(routes[0].Destinations[0].App.Process.Type).To(Equal("web"))
+
+		_, err = client.ReplaceRouteDestinations(route.GUID, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(client.ListAppRoutes(appGUID, capi_client.ListOptions{})).To(BeEmpty())
+	})
+})
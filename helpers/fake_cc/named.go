@@ -0,0 +1,130 @@
+package fake_cc
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/cloudfoundry/capi-bara-tests/helpers/capi_client"
+)
+
+// namedCollections lists the collections the fake can only list and delete,
+// mapped to the operation of the job deleting a member, or "" if members are
+// deleted straight away. Tests fill them with AddNamed, standing in for the
+// orgs, domains, quotas, brokers and buildpacks a run creates through the
+// cf CLI.
+var namedCollections = map[string]string{
+	"organizations":       "organization.delete",
+	"spaces":              "space.delete",
+	"domains":             "domain.delete",
+	"organization_quotas": "organization_quota.delete",
+	"space_quotas":        "space_quota.delete",
+	"service_brokers":     "service_broker.delete",
+	"buildpacks":          "buildpack.delete",
+}
+
+type named struct {
+	capi_client.Resource
+	Name string `json:"name"`
+}
+
+func (f *FakeCC) registerNamed() {
+	for collection, operation := range namedCollections {
+		collection, operation := collection, operation
+		f.named[collection] = map[string]*named{}
+
+		f.router.HandleFunc("/v3/"+collection, func(w http.ResponseWriter, r *http.Request) {
+			f.listNamed(w, r, collection)
+		}).Methods(http.MethodGet)
+		f.router.HandleFunc("/v3/"+collection+"/{guid}", func(w http.ResponseWriter, r *http.Request) {
+			f.deleteNamed(w, r, collection, operation)
+		}).Methods(http.MethodDelete)
+	}
+	f.router.HandleFunc("/v3/organization_quotas/{guid}/relationships/organizations", f.applyOrgQuota).Methods(http.MethodPost)
+}
+
+// AddNamed adds a resource created at createdAt to one of the collections
+// the fake can only list and delete, e.g. "organizations", and returns its
+// GUID.
+func (f *FakeCC) AddNamed(collection, name string, createdAt time.Time) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	members, ok := f.named[collection]
+	if !ok {
+		panic("fake_cc has no named collection " + collection)
+	}
+
+	resource := &named{Resource: f.newResource(), Name: name}
+	resource.CreatedAt = createdAt
+	resource.UpdatedAt = createdAt
+	members[resource.GUID] = resource
+	return resource.GUID
+}
+
+// Named returns the names of the members of a collection.
+func (f *FakeCC) Named(collection string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var names []string
+	for _, resource := range f.named[collection] {
+		names = append(names, resource.Name)
+	}
+	return names
+}
+
+// AppliedOrgQuota returns the GUID of the quota last applied to the
+// organization, or "" if none was.
+func (f *FakeCC) AppliedOrgQuota(orgGUID string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.orgQuotas[orgGUID]
+}
+
+func (f *FakeCC) listNamed(w http.ResponseWriter, r *http.Request, collection string) {
+	names := filterValues(r, "names")
+
+	resources := []named{}
+	for _, resource := range f.named[collection] {
+		if names == nil || contains(names, resource.Name) {
+			resources = append(resources, *resource)
+		}
+	}
+	sortByCreatedAt(resources, func(n named) capi_client.Resource { return n.Resource })
+	writeList(w, r, resources)
+}
+
+func (f *FakeCC) deleteNamed(w http.ResponseWriter, r *http.Request, collection, operation string) {
+	guid := mux.Vars(r)["guid"]
+	if _, ok := f.named[collection][guid]; !ok {
+		writeNotFound(w, collection)
+		return
+	}
+
+	delete(f.named[collection], guid)
+	if operation == "" {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	f.writeJob(w, operation)
+}
+
+func (f *FakeCC) applyOrgQuota(w http.ResponseWriter, r *http.Request) {
+	quotaGUID := mux.Vars(r)["guid"]
+	if _, ok := f.named["organization_quotas"][quotaGUID]; !ok {
+		writeNotFound(w, "organization_quotas")
+		return
+	}
+
+	var organizations capi_client.ToManyRelationship
+	if !decode(w, r, &organizations) {
+		return
+	}
+	for _, org := range organizations.Data {
+		f.orgQuotas[org.GUID] = quotaGUID
+	}
+	writeJSON(w, http.StatusOK, organizations)
+}
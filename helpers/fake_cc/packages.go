@@ -0,0 +1,230 @@
+package fake_cc
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/cloudfoundry/capi-bara-tests/helpers/capi_client"
+)
+
+type pkg struct {
+	capi_client.Package
+	transition
+}
+
+type build struct {
+	capi_client.Build
+	transition
+	appGUID string
+}
+
+type droplet struct {
+	capi_client.Droplet
+	transition
+}
+
+func (f *FakeCC) registerPackages() {
+	f.router.HandleFunc("/v3/packages", f.createPackage).Methods(http.MethodPost)
+	f.router.HandleFunc("/v3/packages/{guid}", f.getPackage).Methods(http.MethodGet)
+	f.router.HandleFunc("/v3/packages/{guid}/upload", f.uploadPackage).Methods(http.MethodPost)
+	f.router.HandleFunc("/v3/builds", f.listBuilds).Methods(http.MethodGet)
+	f.router.HandleFunc("/v3/builds", f.createBuild).Methods(http.MethodPost)
+	f.router.HandleFunc("/v3/builds/{guid}", f.getBuild).Methods(http.MethodGet)
+	f.router.HandleFunc("/v3/droplets", f.createDroplet).Methods(http.MethodPost)
+	f.router.HandleFunc("/v3/droplets/{guid}", f.getDroplet).Methods(http.MethodGet)
+	f.router.HandleFunc("/v3/droplets/{guid}/upload", f.uploadDroplet).Methods(http.MethodPost)
+}
+
+func (f *FakeCC) createPackage(w http.ResponseWriter, r *http.Request) {
+	var request capi_client.CreatePackageRequest
+	if !decode(w, r, &request) {
+		return
+	}
+
+	if _, ok := f.apps[request.Relationships.App.GUID()]; !ok {
+		writeUnprocessable(w, "App must exist")
+		return
+	}
+
+	p := &pkg{Package: capi_client.Package{
+		Resource:      f.newResource(),
+		Type:          request.Type,
+		State:         capi_client.PackageStateAwaitingUpload,
+		Relationships: request.Relationships,
+	}}
+	f.packages[p.GUID] = p
+
+	writeJSON(w, http.StatusCreated, p.Package)
+}
+
+func (f *FakeCC) getPackage(w http.ResponseWriter, r *http.Request) {
+	p, ok := f.packages[mux.Vars(r)["guid"]]
+	if !ok {
+		writeNotFound(w, "Package")
+		return
+	}
+
+	if p.State == capi_client.PackageStateProcessingUpload && f.advance(&p.transition) {
+		p.State = capi_client.PackageStateReady
+		f.touch(&p.Resource)
+	}
+	writeJSON(w, http.StatusOK, p.Package)
+}
+
+func (f *FakeCC) uploadPackage(w http.ResponseWriter, r *http.Request) {
+	p, ok := f.packages[mux.Vars(r)["guid"]]
+	if !ok {
+		writeNotFound(w, "Package")
+		return
+	}
+	if _, _, err := r.FormFile("bits"); err != nil {
+		writeUnprocessable(w, "Bits must be uploaded as multipart form field 'bits'")
+		return
+	}
+
+	p.State = capi_client.PackageStateProcessingUpload
+	f.touch(&p.Resource)
+	writeJSON(w, http.StatusOK, p.Package)
+}
+
+func (f *FakeCC) createBuild(w http.ResponseWriter, r *http.Request) {
+	var request capi_client.CreateBuildRequest
+	if !decode(w, r, &request) {
+		return
+	}
+
+	p, ok := f.packages[request.Package.GUID]
+	if !ok {
+		writeUnprocessable(w, "Unable to use package. Ensure that the package exists and you have access to it.")
+		return
+	}
+	if p.State != capi_client.PackageStateReady {
+		writeError(w, http.StatusUnprocessableEntity, 150002, "CF-InvalidPackageState", "Package must be in READY state to stage")
+		return
+	}
+
+	b := &build{
+		Build: capi_client.Build{
+			Resource:  f.newResource(),
+			State:     capi_client.BuildStateStaging,
+			Lifecycle: f.apps[p.Relationships.App.GUID()].Lifecycle,
+			Package:   capi_client.GUIDRef{GUID: p.GUID},
+		},
+		appGUID: p.Relationships.App.GUID(),
+	}
+	if request.Lifecycle != nil {
+		b.Lifecycle = *request.Lifecycle
+	}
+	f.builds[b.GUID] = b
+
+	writeJSON(w, http.StatusCreated, b.Build)
+}
+
+func (f *FakeCC) listBuilds(w http.ResponseWriter, r *http.Request) {
+	appGUIDs := filterValues(r, "app_guids")
+
+	builds := []capi_client.Build{}
+	for _, b := range f.builds {
+		if appGUIDs == nil || contains(appGUIDs, b.appGUID) {
+			builds = append(builds, b.Build)
+		}
+	}
+	sortByCreatedAt(builds, func(b capi_client.Build) capi_client.Resource { return b.Resource })
+	writeList(w, r, builds)
+}
+
+func (f *FakeCC) getBuild(w http.ResponseWriter, r *http.Request) {
+	b, ok := f.builds[mux.Vars(r)["guid"]]
+	if !ok {
+		writeNotFound(w, "Build")
+		return
+	}
+
+	if b.State == capi_client.BuildStateStaging && f.advance(&b.transition) {
+		f.finishStaging(b)
+	}
+	writeJSON(w, http.StatusOK, b.Build)
+}
+
+func (f *FakeCC) finishStaging(b *build) {
+	f.touch(&b.Resource)
+
+	if reason, failed := f.stagingFailures[b.Package.GUID]; failed {
+		b.State = capi_client.BuildStateFailed
+		b.Error = reason
+		return
+	}
+
+	d := &droplet{Droplet: capi_client.Droplet{
+		Resource:      f.newResource(),
+		State:         capi_client.DropletStateStaged,
+		Lifecycle:     b.Lifecycle,
+		ProcessTypes:  map[string]string{"web": "bundle exec rackup"},
+		Relationships: capi_client.DropletRelationships{App: capi_client.NewRelationship(b.appGUID)},
+	}}
+	f.droplets[d.GUID] = d
+
+	b.State = capi_client.BuildStateStaged
+	b.Droplet = &capi_client.GUIDRef{GUID: d.GUID}
+}
+
+func (f *FakeCC) createDroplet(w http.ResponseWriter, r *http.Request) {
+	var request capi_client.CreateDropletRequest
+	if !decode(w, r, &request) {
+		return
+	}
+
+	a, ok := f.apps[request.Relationships.App.GUID()]
+	if !ok {
+		writeUnprocessable(w, "App must exist")
+		return
+	}
+
+	d := &droplet{Droplet: capi_client.Droplet{
+		Resource:      f.newResource(),
+		State:         capi_client.DropletStateAwaitingUpload,
+		Lifecycle:     a.Lifecycle,
+		ProcessTypes:  request.ProcessTypes,
+		Relationships: request.Relationships,
+	}}
+	f.droplets[d.GUID] = d
+
+	writeJSON(w, http.StatusCreated, d.Droplet)
+}
+
+func (f *FakeCC) getDroplet(w http.ResponseWriter, r *http.Request) {
+	d, ok := f.droplets[mux.Vars(r)["guid"]]
+	if !ok {
+		writeNotFound(w, "Droplet")
+		return
+	}
+
+	if d.State == capi_client.DropletStateProcessingUpload && f.advance(&d.transition) {
+		d.State = capi_client.DropletStateStaged
+		f.touch(&d.Resource)
+	}
+	writeJSON(w, http.StatusOK, d.Droplet)
+}
+
+func (f *FakeCC) uploadDroplet(w http.ResponseWriter, r *http.Request) {
+	d, ok := f.droplets[mux.Vars(r)["guid"]]
+	if !ok {
+		writeNotFound(w, "Droplet")
+		return
+	}
+	if d.State != capi_client.DropletStateAwaitingUpload {
+		writeUnprocessable(w, "Droplet may be uploaded only once.")
+		return
+	}
+	if _, _, err := r.FormFile("bits"); err != nil {
+		writeUnprocessable(w, "Bits must be uploaded as multipart form field 'bits'")
+		return
+	}
+
+	d.State = capi_client.DropletStateProcessingUpload
+	f.touch(&d.Resource)
+
+	w.Header().Set("Location", f.jobURL(f.newJob("droplet.upload")))
+	writeJSON(w, http.StatusAccepted, d.Droplet)
+}
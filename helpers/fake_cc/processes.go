@@ -0,0 +1,206 @@
+package fake_cc
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/cloudfoundry/capi-bara-tests/helpers/capi_client"
+)
+
+func (f *FakeCC) registerProcesses() {
+	f.router.HandleFunc("/v3/processes/{guid}", f.getProcess).Methods(http.MethodGet)
+	f.router.HandleFunc("/v3/processes/{guid}", f.updateProcess).Methods(http.MethodPatch)
+	f.router.HandleFunc("/v3/processes/{guid}/stats", f.getProcessStats).Methods(http.MethodGet)
+}
+
+func (f *FakeCC) createProcess(appGUID, processType, command string) *capi_client.Process {
+	instances := 0
+	if processType == "web" {
+		instances = 1
+	}
+
+	p := &capi_client.Process{
+		Resource:      f.newResource(),
+		Type:          processType,
+		Command:       command,
+		Instances:     instances,
+		MemoryInMB:    1024,
+		DiskInMB:      1024,
+		HealthCheck:   capi_client.HealthCheck{Type: "port"},
+		Relationships: capi_client.ProcessRelationships{App: capi_client.NewRelationship(appGUID)},
+	}
+	if latest := f.latestRevision(appGUID); latest != nil {
+		p.Relationships.Revision = capi_client.NewRelationship(latest.GUID)
+	}
+	f.processes[p.GUID] = p
+	return p
+}
+
+// appProcess returns the oldest process of the given type, which is the one
+// Cloud Controller keeps once a deployment finishes.
+func (f *FakeCC) appProcess(appGUID, processType string) *capi_client.Process {
+	for _, p := range f.appProcesses(appGUID) {
+		if p.Type == processType {
+			return p
+		}
+	}
+	return nil
+}
+
+func (f *FakeCC) appProcesses(appGUID string) []*capi_client.Process {
+	processes := []*capi_client.Process{}
+	for _, p := range f.processes {
+		if p.Relationships.App.GUID() == appGUID {
+			processes = append(processes, p)
+		}
+	}
+	sortByCreatedAt(processes, func(p *capi_client.Process) capi_client.Resource { return p.Resource })
+	return processes
+}
+
+func (f *FakeCC) listAppProcesses(w http.ResponseWriter, r *http.Request) {
+	a, ok := f.findApp(w, r)
+	if !ok {
+		return
+	}
+
+	types := filterValues(r, "types")
+	processes := []capi_client.Process{}
+	for _, p := range f.appProcesses(a.GUID) {
+		if types == nil || contains(types, p.Type) {
+			processes = append(processes, *p)
+		}
+	}
+	writeList(w, r, processes)
+}
+
+func (f *FakeCC) scaleAppProcess(w http.ResponseWriter, r *http.Request) {
+	a, ok := f.findApp(w, r)
+	if !ok {
+		return
+	}
+
+	p := f.appProcess(a.GUID, mux.Vars(r)["type"])
+	if p == nil {
+		writeNotFound(w, "Process")
+		return
+	}
+
+	var request capi_client.ScaleProcessRequest
+	if !decode(w, r, &request) {
+		return
+	}
+
+	if request.Instances != nil {
+		p.Instances = *request.Instances
+	}
+	if request.MemoryInMB != nil {
+		p.MemoryInMB = *request.MemoryInMB
+	}
+	if request.DiskInMB != nil {
+		p.DiskInMB = *request.DiskInMB
+	}
+	f.touch(&p.Resource)
+
+	writeJSON(w, http.StatusAccepted, *p)
+}
+
+func (f *FakeCC) getProcess(w http.ResponseWriter, r *http.Request) {
+	p, ok := f.findProcess(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, *p)
+}
+
+func (f *FakeCC) updateProcess(w http.ResponseWriter, r *http.Request) {
+	p, ok := f.findProcess(w, r)
+	if !ok {
+		return
+	}
+
+	var request capi_client.UpdateProcessRequest
+	if !decode(w, r, &request) {
+		return
+	}
+
+	if request.Command != nil {
+		p.Command = *request.Command
+	}
+	if request.HealthCheck != nil {
+		if request.HealthCheck.Type != "" {
+			p.HealthCheck.Type = request.HealthCheck.Type
+		}
+		if request.HealthCheck.Data.Timeout != nil {
+			p.HealthCheck.Data.Timeout = request.HealthCheck.Data.Timeout
+		}
+		if request.HealthCheck.Data.InvocationTimeout != nil {
+			p.HealthCheck.Data.InvocationTimeout = request.HealthCheck.Data.InvocationTimeout
+		}
+		if request.HealthCheck.Data.Endpoint != "" {
+			p.HealthCheck.Data.Endpoint = request.HealthCheck.Data.Endpoint
+		}
+	}
+	f.touch(&p.Resource)
+
+	writeJSON(w, http.StatusOK, *p)
+}
+
+// getProcessStats reports every instance as RUNNING while the app is
+// started and DOWN otherwise, except for the instances a deployment has just
+// started, which are STARTING until it next moves on. Running instances are
+// routable, use a fixed share of their quotas and have been up since the
+// process was created.
+func (f *FakeCC) getProcessStats(w http.ResponseWriter, r *http.Request) {
+	p, ok := f.findProcess(w, r)
+	if !ok {
+		return
+	}
+
+	state := capi_client.InstanceStateDown
+	if a, ok := f.apps[p.Relationships.App.GUID()]; ok && a.State == capi_client.AppStateStarted {
+		state = capi_client.InstanceStateRunning
+	}
+
+	starting := 0
+	if state == capi_client.InstanceStateRunning {
+		starting = f.startingInstances(p.GUID)
+	}
+
+	stats := []capi_client.ProcessInstanceStats{}
+	for index := 0; index < p.Instances; index++ {
+		instance := capi_client.ProcessInstanceStats{Type: p.Type, Index: index, State: state}
+		if index >= p.Instances-starting {
+			instance.State = capi_client.InstanceStateStarting
+		}
+
+		routable := instance.State == capi_client.InstanceStateRunning
+		instance.Routable = &routable
+		if instance.State != capi_client.InstanceStateDown {
+			memQuota := int64(p.MemoryInMB) * 1024 * 1024
+			diskQuota := int64(p.DiskInMB) * 1024 * 1024
+			instance.Host = fmt.Sprintf("10.0.0.%d", index+1)
+			instance.MemQuota = &memQuota
+			instance.DiskQuota = &diskQuota
+			instance.FdsQuota = 16384
+			instance.InstancePorts = []capi_client.InstancePort{{External: 61000 + index, Internal: 8080}}
+		}
+		if routable {
+			now := f.clock
+			instance.Uptime = int64(f.clock.Sub(p.CreatedAt).Seconds())
+			instance.Usage = capi_client.InstanceUsage{Time: &now, CPU: 0.01, Mem: *instance.MemQuota / 4, Disk: *instance.DiskQuota / 4}
+		}
+		stats = append(stats, instance)
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"resources": stats})
+}
+
+func (f *FakeCC) findProcess(w http.ResponseWriter, r *http.Request) (*capi_client.Process, bool) {
+	p, ok := f.processes[mux.Vars(r)["guid"]]
+	if !ok {
+		writeNotFound(w, "Process")
+	}
+	return p, ok
+}
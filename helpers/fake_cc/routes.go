@@ -0,0 +1,149 @@
+package fake_cc
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	uuid "github.com/satori/go.uuid"
+
+	"github.com/cloudfoundry/capi-bara-tests/helpers/capi_client"
+)
+
+func (f *FakeCC) registerRoutes() {
+	f.router.HandleFunc("/v3/routes", f.createRoute).Methods(http.MethodPost)
+	f.router.HandleFunc("/v3/routes/{guid}", f.getRoute).Methods(http.MethodGet)
+	f.router.HandleFunc("/v3/routes/{guid}", f.deleteRoute).Methods(http.MethodDelete)
+	f.router.HandleFunc("/v3/routes/{guid}/destinations", f.listRouteDestinations).Methods(http.MethodGet)
+	f.router.HandleFunc("/v3/routes/{guid}/destinations", f.insertRouteDestinations).Methods(http.MethodPost)
+	f.router.HandleFunc("/v3/routes/{guid}/destinations", f.replaceRouteDestinations).Methods(http.MethodPatch)
+}
+
+func (f *FakeCC) createRoute(w http.ResponseWriter, r *http.Request) {
+	var request capi_client.CreateRouteRequest
+	if !decode(w, r, &request) {
+		return
+	}
+
+	if request.Relationships.Space.GUID() == "" || request.Relationships.Domain.GUID() == "" {
+		writeUnprocessable(w, "Relationships must include space and domain")
+		return
+	}
+
+	route := &capi_client.Route{
+		Resource:      f.newResource(),
+		Host:          request.Host,
+		Path:          request.Path,
+		URL:           request.Host + "." + r.Host + request.Path,
+		Destinations:  []capi_client.Destination{},
+		Relationships: request.Relationships,
+	}
+	f.routes[route.GUID] = route
+
+	writeJSON(w, http.StatusCreated, route)
+}
+
+func (f *FakeCC) getRoute(w http.ResponseWriter, r *http.Request) {
+	route, ok := f.findRoute(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, route)
+}
+
+func (f *FakeCC) deleteRoute(w http.ResponseWriter, r *http.Request) {
+	route, ok := f.findRoute(w, r)
+	if !ok {
+		return
+	}
+
+	delete(f.routes, route.GUID)
+	f.writeJob(w, "route.delete")
+}
+
+func (f *FakeCC) listAppRoutes(w http.ResponseWriter, r *http.Request) {
+	a, ok := f.findApp(w, r)
+	if !ok {
+		return
+	}
+
+	routes := []capi_client.Route{}
+	for _, route := range f.routes {
+		for _, destination := range route.Destinations {
+			if destination.App.GUID == a.GUID {
+				routes = append(routes, *route)
+				break
+			}
+		}
+	}
+	sortByCreatedAt(routes, func(route capi_client.Route) capi_client.Resource { return route.Resource })
+	writeList(w, r, routes)
+}
+
+func (f *FakeCC) listRouteDestinations(w http.ResponseWriter, r *http.Request) {
+	route, ok := f.findRoute(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, capi_client.Destinations{Destinations: route.Destinations})
+}
+
+func (f *FakeCC) insertRouteDestinations(w http.ResponseWriter, r *http.Request) {
+	f.updateRouteDestinations(w, r, false)
+}
+
+func (f *FakeCC) replaceRouteDestinations(w http.ResponseWriter, r *http.Request) {
+	f.updateRouteDestinations(w, r, true)
+}
+
+func (f *FakeCC) updateRouteDestinations(w http.ResponseWriter, r *http.Request, replace bool) {
+	route, ok := f.findRoute(w, r)
+	if !ok {
+		return
+	}
+
+	var request capi_client.Destinations
+	if !decode(w, r, &request) {
+		return
+	}
+
+	destinations := []capi_client.Destination{}
+	if !replace {
+		destinations = append(destinations, route.Destinations...)
+	}
+	for _, destination := range request.Destinations {
+		if _, ok := f.apps[destination.App.GUID]; !ok {
+			writeUnprocessable(w, "App(s) with guid(s) \""+destination.App.GUID+"\" do not exist or you do not have access.")
+			return
+		}
+		if destination.App.Process == nil {
+			destination.App.Process = &capi_client.DestinationProcess{Type: "web"}
+		}
+		if destination.Port == 0 {
+			destination.Port = 8080
+		}
+		destination.GUID = uuid.NewV4().String()
+		destinations = append(destinations, destination)
+	}
+
+	route.Destinations = destinations
+	f.touch(&route.Resource)
+	writeJSON(w, http.StatusOK, capi_client.Destinations{Destinations: route.Destinations})
+}
+
+func (f *FakeCC) findRoute(w http.ResponseWriter, r *http.Request) (*capi_client.Route, bool) {
+	route, ok := f.routes[mux.Vars(r)["guid"]]
+	if !ok {
+		writeNotFound(w, "Route")
+	}
+	return route, ok
+}
+
+func withoutApp(destinations []capi_client.Destination, appGUID string) []capi_client.Destination {
+	remaining := []capi_client.Destination{}
+	for _, destination := range destinations {
+		if destination.App.GUID != appGUID {
+			remaining = append(remaining, destination)
+		}
+	}
+	return remaining
+}
@@ -0,0 +1,550 @@
+package fake_cc
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/cloudfoundry/capi-bara-tests/helpers/capi_client"
+)
+
+type serviceInstance struct {
+	capi_client.ServiceInstance
+	operation   *serviceOperation
+	parameters  map[string]interface{}
+	credentials map[string]interface{}
+}
+
+func (si *serviceInstance) planGUID() string {
+	if si.Relationships.ServicePlan == nil {
+		return ""
+	}
+	return si.Relationships.ServicePlan.GUID()
+}
+
+type serviceCredentialBinding struct {
+	capi_client.ServiceCredentialBinding
+	operation   *serviceOperation
+	parameters  map[string]interface{}
+	credentials map[string]interface{}
+}
+
+// serviceOperation is a broker operation on a service instance or binding.
+// Operations of synchronous plans finish as they start; those of
+// asynchronous plans stay in progress until polled PollsPerTransition
+// times, through the resource or the job waiting on the operation.
+type serviceOperation struct {
+	transition
+	async         bool
+	lastOperation *capi_client.LastOperation
+	// failure is why the broker fails the operation, if it does.
+	failure string
+	// succeeded applies the operation's outcome, e.g. removing what it
+	// deleted.
+	succeeded func()
+}
+
+func (f *FakeCC) registerServiceInstances() {
+	f.router.HandleFunc("/v3/service_instances", f.listServiceInstances).Methods(http.MethodGet)
+	f.router.HandleFunc("/v3/service_instances", f.createServiceInstance).Methods(http.MethodPost)
+	f.router.HandleFunc("/v3/service_instances/{guid}", f.getServiceInstance).Methods(http.MethodGet)
+	f.router.HandleFunc("/v3/service_instances/{guid}", f.updateServiceInstance).Methods(http.MethodPatch)
+	f.router.HandleFunc("/v3/service_instances/{guid}", f.deleteServiceInstance).Methods(http.MethodDelete)
+	f.router.HandleFunc("/v3/service_instances/{guid}/parameters", f.getServiceInstanceParameters).Methods(http.MethodGet)
+	f.router.HandleFunc("/v3/service_instances/{guid}/credentials", f.getServiceInstanceCredentials).Methods(http.MethodGet)
+	f.router.HandleFunc("/v3/service_credential_bindings", f.listServiceCredentialBindings).Methods(http.MethodGet)
+	f.router.HandleFunc("/v3/service_credential_bindings", f.createServiceCredentialBinding).Methods(http.MethodPost)
+	f.router.HandleFunc("/v3/service_credential_bindings/{guid}", f.getServiceCredentialBinding).Methods(http.MethodGet)
+	f.router.HandleFunc("/v3/service_credential_bindings/{guid}", f.deleteServiceCredentialBinding).Methods(http.MethodDelete)
+	f.router.HandleFunc("/v3/service_credential_bindings/{guid}/details", f.getServiceCredentialBindingDetails).Methods(http.MethodGet)
+	f.router.HandleFunc("/v3/service_credential_bindings/{guid}/parameters", f.getServiceCredentialBindingParameters).Methods(http.MethodGet)
+}
+
+// FailServiceInstance makes the broker fail every operation on the managed
+// service instance with the given name, with the given description.
+func (f *FakeCC) FailServiceInstance(name, description string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.serviceInstanceFailures[name] = description
+}
+
+func (f *FakeCC) listServiceInstances(w http.ResponseWriter, r *http.Request) {
+	names := filterValues(r, "names")
+	spaceGUIDs := filterValues(r, "space_guids")
+	planGUIDs := filterValues(r, "service_plan_guids")
+
+	instances := []capi_client.ServiceInstance{}
+	for _, si := range f.serviceInstances {
+		if (names == nil || contains(names, si.Name)) &&
+			(spaceGUIDs == nil || contains(spaceGUIDs, si.Relationships.Space.GUID())) &&
+			(planGUIDs == nil || contains(planGUIDs, si.planGUID())) {
+			instances = append(instances, si.ServiceInstance)
+		}
+	}
+	sortByCreatedAt(instances, func(si capi_client.ServiceInstance) capi_client.Resource { return si.Resource })
+	writeList(w, r, instances)
+}
+
+func (f *FakeCC) createServiceInstance(w http.ResponseWriter, r *http.Request) {
+	var request capi_client.CreateServiceInstanceRequest
+	if !decode(w, r, &request) {
+		return
+	}
+
+	if request.Name == "" {
+		writeUnprocessable(w, "Name can't be blank")
+		return
+	}
+	spaceGUID := request.Relationships.Space.GUID()
+	if spaceGUID == "" {
+		writeUnprocessable(w, "Relationships Space can't be blank")
+		return
+	}
+	for _, existing := range f.serviceInstances {
+		if existing.Name == request.Name && existing.Relationships.Space.GUID() == spaceGUID {
+			writeError(w, http.StatusUnprocessableEntity, 60002, "CF-ServiceInstanceNameTaken", fmt.Sprintf("The service instance name is taken: %s.", request.Name))
+			return
+		}
+	}
+
+	si := &serviceInstance{
+		ServiceInstance: capi_client.ServiceInstance{
+			Resource:      f.newResource(),
+			Name:          request.Name,
+			Type:          request.Type,
+			Tags:          request.Tags,
+			Relationships: capi_client.ServiceInstanceRelationships{Space: request.Relationships.Space},
+		},
+		parameters: request.Parameters,
+	}
+
+	switch request.Type {
+	case capi_client.ServiceInstanceTypeUserProvided:
+		si.credentials = request.Credentials
+		si.SyslogDrainURL = request.SyslogDrainURL
+		si.RouteServiceURL = request.RouteServiceURL
+		si.LastOperation = f.finishedOperation(capi_client.LastOperationTypeCreate)
+		f.serviceInstances[si.GUID] = si
+		writeJSON(w, http.StatusCreated, si.ServiceInstance)
+
+	case capi_client.ServiceInstanceTypeManaged:
+		if request.Relationships.ServicePlan == nil {
+			writeUnprocessable(w, "Relationships Service plan can't be blank")
+			return
+		}
+		p, ok := f.servicePlans[request.Relationships.ServicePlan.GUID()]
+		if !ok {
+			writeUnprocessable(w, "Invalid service plan. Ensure that the service plan exists, is available, and you have access to it.")
+			return
+		}
+		si.Relationships.ServicePlan = request.Relationships.ServicePlan
+		si.MaintenanceInfo = p.MaintenanceInfo
+		f.serviceInstances[si.GUID] = si
+
+		f.startInstanceOperation(si, p, capi_client.LastOperationTypeCreate, nil)
+		f.writeOperationJob(w, "service_instance.create", si.operation)
+
+	default:
+		writeUnprocessable(w, "Type must be one of 'managed', 'user-provided'")
+	}
+}
+
+func (f *FakeCC) getServiceInstance(w http.ResponseWriter, r *http.Request) {
+	si, ok := f.findServiceInstance(w, r)
+	if !ok {
+		return
+	}
+	f.pollServiceOperation(si.operation)
+	writeJSON(w, http.StatusOK, si.ServiceInstance)
+}
+
+// updateServiceInstance involves the broker, and so answers with a job, only
+// when a managed instance's plan or parameters change.
+func (f *FakeCC) updateServiceInstance(w http.ResponseWriter, r *http.Request) {
+	si, ok := f.findServiceInstance(w, r)
+	if !ok {
+		return
+	}
+
+	var request capi_client.UpdateServiceInstanceRequest
+	if !decode(w, r, &request) {
+		return
+	}
+
+	if si.operation != nil && si.LastOperation.State == capi_client.LastOperationStateInProgress {
+		writeError(w, http.StatusConflict, 60016, "CF-AsyncServiceInstanceOperationInProgress",
+			fmt.Sprintf("An operation for service instance %s is in progress.", si.Name))
+		return
+	}
+
+	if request.Name != nil {
+		si.Name = *request.Name
+	}
+	if request.Tags != nil {
+		si.Tags = request.Tags
+	}
+
+	if si.Type == capi_client.ServiceInstanceTypeUserProvided {
+		if request.Credentials != nil {
+			si.credentials = request.Credentials
+		}
+		si.LastOperation = f.finishedOperation(capi_client.LastOperationTypeUpdate)
+		writeJSON(w, http.StatusOK, si.ServiceInstance)
+		return
+	}
+
+	if request.Parameters == nil && request.Relationships == nil && request.MaintenanceInfo == nil {
+		f.touch(&si.Resource)
+		writeJSON(w, http.StatusOK, si.ServiceInstance)
+		return
+	}
+
+	planGUID := si.planGUID()
+	if request.Relationships != nil {
+		planGUID = request.Relationships.ServicePlan.GUID()
+	}
+	p, ok := f.servicePlans[planGUID]
+	if !ok {
+		writeUnprocessable(w, "Invalid service plan. Ensure that the service plan exists, is available, and you have access to it.")
+		return
+	}
+	if request.MaintenanceInfo != nil {
+		if p.MaintenanceInfo.Version == "" {
+			writeError(w, http.StatusUnprocessableEntity, 10012, "CF-MaintenanceInfoNotSupported",
+				"The service broker does not support upgrades for service instances created from this plan.")
+			return
+		}
+		if request.MaintenanceInfo.Version != p.MaintenanceInfo.Version {
+			writeError(w, http.StatusUnprocessableEntity, 10013, "CF-MaintenanceInfoConflict",
+				"maintenance_info.version requested is invalid. Please ensure the catalog is up to date and you are providing a version supported by this service plan.")
+			return
+		}
+	}
+
+	f.startInstanceOperation(si, p, capi_client.LastOperationTypeUpdate, func() {
+		si.Relationships.ServicePlan = &capi_client.Relationship{Data: &capi_client.RelationshipData{GUID: p.GUID}}
+		for name, value := range request.Parameters {
+			if si.parameters == nil {
+				si.parameters = map[string]interface{}{}
+			}
+			si.parameters[name] = value
+		}
+		if request.MaintenanceInfo != nil || request.Relationships != nil {
+			si.MaintenanceInfo = p.MaintenanceInfo
+		}
+		f.checkUpgradeAvailable(si)
+	})
+	f.writeOperationJob(w, "service_instance.update", si.operation)
+}
+
+func (f *FakeCC) deleteServiceInstance(w http.ResponseWriter, r *http.Request) {
+	si, ok := f.findServiceInstance(w, r)
+	if !ok {
+		return
+	}
+
+	if si.Type == capi_client.ServiceInstanceTypeUserProvided {
+		f.removeServiceInstance(si)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	f.startInstanceOperation(si, f.servicePlans[si.planGUID()], capi_client.LastOperationTypeDelete, func() {
+		f.removeServiceInstance(si)
+	})
+	f.writeOperationJob(w, "service_instance.delete", si.operation)
+}
+
+func (f *FakeCC) getServiceInstanceParameters(w http.ResponseWriter, r *http.Request) {
+	si, ok := f.findServiceInstance(w, r)
+	if !ok {
+		return
+	}
+
+	if si.Type == capi_client.ServiceInstanceTypeUserProvided {
+		writeError(w, http.StatusBadRequest, 60028, "CF-ServiceFetchInstanceParametersNotSupported",
+			"This service does not support fetching service instance parameters.")
+		return
+	}
+	writeJSON(w, http.StatusOK, nonNilParameters(si.parameters))
+}
+
+func (f *FakeCC) getServiceInstanceCredentials(w http.ResponseWriter, r *http.Request) {
+	si, ok := f.findServiceInstance(w, r)
+	if !ok {
+		return
+	}
+
+	if si.Type != capi_client.ServiceInstanceTypeUserProvided {
+		writeNotFound(w, "Service instance")
+		return
+	}
+	writeJSON(w, http.StatusOK, nonNilParameters(si.credentials))
+}
+
+func (f *FakeCC) listServiceCredentialBindings(w http.ResponseWriter, r *http.Request) {
+	names := filterValues(r, "names")
+	instanceGUIDs := filterValues(r, "service_instance_guids")
+	appGUIDs := filterValues(r, "app_guids")
+	types := filterValues(r, "type")
+
+	bindings := []capi_client.ServiceCredentialBinding{}
+	for _, b := range f.serviceCredentialBindings {
+		appGUID := ""
+		if b.Relationships.App != nil {
+			appGUID = b.Relationships.App.GUID()
+		}
+		if (names == nil || contains(names, b.Name)) &&
+			(instanceGUIDs == nil || contains(instanceGUIDs, b.Relationships.ServiceInstance.GUID())) &&
+			(appGUIDs == nil || contains(appGUIDs, appGUID)) &&
+			(types == nil || contains(types, b.Type)) {
+			bindings = append(bindings, b.ServiceCredentialBinding)
+		}
+	}
+	sortByCreatedAt(bindings, func(b capi_client.ServiceCredentialBinding) capi_client.Resource { return b.Resource })
+	writeList(w, r, bindings)
+}
+
+func (f *FakeCC) createServiceCredentialBinding(w http.ResponseWriter, r *http.Request) {
+	var request capi_client.CreateServiceCredentialBindingRequest
+	if !decode(w, r, &request) {
+		return
+	}
+
+	si, ok := f.serviceInstances[request.Relationships.ServiceInstance.GUID()]
+	if !ok {
+		writeUnprocessable(w, "The service instance could not be found.")
+		return
+	}
+
+	switch request.Type {
+	case capi_client.ServiceCredentialBindingTypeApp:
+		if request.Relationships.App == nil {
+			writeUnprocessable(w, "Relationships App can't be blank")
+			return
+		}
+		if _, ok := f.apps[request.Relationships.App.GUID()]; !ok {
+			writeUnprocessable(w, "The app could not be found.")
+			return
+		}
+		for _, existing := range f.serviceCredentialBindings {
+			if existing.Relationships.App != nil && existing.Relationships.App.GUID() == request.Relationships.App.GUID() &&
+				existing.Relationships.ServiceInstance.GUID() == si.GUID {
+				writeUnprocessable(w, "The app is already bound to the service instance.")
+				return
+			}
+		}
+	case capi_client.ServiceCredentialBindingTypeKey:
+		if request.Name == "" {
+			writeUnprocessable(w, "Name can't be blank")
+			return
+		}
+		if si.Type == capi_client.ServiceInstanceTypeUserProvided {
+			writeUnprocessable(w, "Service credential bindings of type 'key' are not supported for user-provided service instances.")
+			return
+		}
+	default:
+		writeUnprocessable(w, "Type must be one of 'app', 'key'")
+		return
+	}
+
+	b := &serviceCredentialBinding{
+		ServiceCredentialBinding: capi_client.ServiceCredentialBinding{
+			Resource:      f.newResource(),
+			Name:          request.Name,
+			Type:          request.Type,
+			Relationships: request.Relationships,
+		},
+		parameters: request.Parameters,
+	}
+	f.serviceCredentialBindings[b.GUID] = b
+
+	if si.Type == capi_client.ServiceInstanceTypeUserProvided {
+		b.credentials = si.credentials
+		b.LastOperation = f.finishedOperation(capi_client.LastOperationTypeCreate)
+		writeJSON(w, http.StatusCreated, b.ServiceCredentialBinding)
+		return
+	}
+
+	b.credentials = map[string]interface{}{"username": "user-" + b.GUID[:8], "password": "password-" + b.GUID[:8]}
+	b.operation = f.startServiceOperation(&b.LastOperation, f.servicePlans[si.planGUID()], si.Name, capi_client.LastOperationTypeCreate, nil)
+	f.writeOperationJob(w, "service_bindings.create", b.operation)
+}
+
+func (f *FakeCC) getServiceCredentialBinding(w http.ResponseWriter, r *http.Request) {
+	b, ok := f.findServiceCredentialBinding(w, r)
+	if !ok {
+		return
+	}
+	f.pollServiceOperation(b.operation)
+	writeJSON(w, http.StatusOK, b.ServiceCredentialBinding)
+}
+
+func (f *FakeCC) deleteServiceCredentialBinding(w http.ResponseWriter, r *http.Request) {
+	b, ok := f.findServiceCredentialBinding(w, r)
+	if !ok {
+		return
+	}
+
+	si := f.serviceInstances[b.Relationships.ServiceInstance.GUID()]
+	if si.Type == capi_client.ServiceInstanceTypeUserProvided {
+		delete(f.serviceCredentialBindings, b.GUID)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	b.operation = f.startServiceOperation(&b.LastOperation, f.servicePlans[si.planGUID()], si.Name, capi_client.LastOperationTypeDelete, func() {
+		delete(f.serviceCredentialBindings, b.GUID)
+	})
+	f.writeOperationJob(w, "service_bindings.delete", b.operation)
+}
+
+func (f *FakeCC) getServiceCredentialBindingDetails(w http.ResponseWriter, r *http.Request) {
+	b, ok := f.findServiceCredentialBinding(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, capi_client.ServiceCredentialBindingDetails{Credentials: nonNilParameters(b.credentials)})
+}
+
+func (f *FakeCC) getServiceCredentialBindingParameters(w http.ResponseWriter, r *http.Request) {
+	b, ok := f.findServiceCredentialBinding(w, r)
+	if !ok {
+		return
+	}
+
+	if f.serviceInstances[b.Relationships.ServiceInstance.GUID()].Type == capi_client.ServiceInstanceTypeUserProvided {
+		writeError(w, http.StatusBadRequest, 90004, "CF-ServiceBindingParametersNotSupported",
+			"This service does not support fetching service binding parameters.")
+		return
+	}
+	writeJSON(w, http.StatusOK, nonNilParameters(b.parameters))
+}
+
+func (f *FakeCC) startInstanceOperation(si *serviceInstance, p *servicePlan, operationType string, succeeded func()) {
+	si.operation = f.startServiceOperation(&si.LastOperation, p, si.Name, operationType, succeeded)
+	f.touch(&si.Resource)
+}
+
+// startServiceOperation starts an operation of the plan's broker on the
+// instance with the given name, or one of its bindings.
+func (f *FakeCC) startServiceOperation(lastOperation *capi_client.LastOperation, p *servicePlan, instanceName, operationType string, succeeded func()) *serviceOperation {
+	*lastOperation = f.finishedOperation(operationType)
+	lastOperation.State = capi_client.LastOperationStateInProgress
+
+	op := &serviceOperation{
+		async:         p != nil && p.async,
+		lastOperation: lastOperation,
+		failure:       f.serviceInstanceFailures[instanceName],
+		succeeded:     succeeded,
+	}
+	if !op.async {
+		f.finishServiceOperation(op)
+	}
+	return op
+}
+
+func (f *FakeCC) pollServiceOperation(op *serviceOperation) {
+	if op == nil || op.lastOperation.State != capi_client.LastOperationStateInProgress {
+		return
+	}
+	if f.advance(&op.transition) {
+		f.finishServiceOperation(op)
+	}
+}
+
+func (f *FakeCC) finishServiceOperation(op *serviceOperation) {
+	f.clock = f.clock.Add(time.Second)
+	op.lastOperation.UpdatedAt = f.clock
+
+	if op.failure != "" {
+		op.lastOperation.State = capi_client.LastOperationStateFailed
+		op.lastOperation.Description = op.failure
+		return
+	}
+	op.lastOperation.State = capi_client.LastOperationStateSucceeded
+	if op.succeeded != nil {
+		op.succeeded()
+	}
+}
+
+func (f *FakeCC) finishedOperation(operationType string) capi_client.LastOperation {
+	return capi_client.LastOperation{
+		Type:      operationType,
+		State:     capi_client.LastOperationStateSucceeded,
+		CreatedAt: f.clock,
+		UpdatedAt: f.clock,
+	}
+}
+
+// writeOperationJob answers with a job that is POLLING while the operation
+// is in progress and completes or fails with it.
+func (f *FakeCC) writeOperationJob(w http.ResponseWriter, operation string, op *serviceOperation) {
+	j := f.newJob(operation)
+	j.operation = op
+	w.Header().Set("Location", f.jobURL(j))
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// followServiceOperation polls the operation a job waits on and moves the
+// job to the matching state.
+func (f *FakeCC) followServiceOperation(j *job) {
+	if j.IsTerminal() {
+		return
+	}
+	f.pollServiceOperation(j.operation)
+
+	switch j.operation.lastOperation.State {
+	case capi_client.LastOperationStateInProgress:
+		j.State = capi_client.JobStatePolling
+	case capi_client.LastOperationStateSucceeded:
+		j.State = capi_client.JobStateComplete
+	case capi_client.LastOperationStateFailed:
+		j.State = capi_client.JobStateFailed
+		j.Errors = []capi_client.Error{{
+			Code:   10009,
+			Title:  "CF-UnableToPerform",
+			Detail: fmt.Sprintf("%s could not be completed: %s", j.operation.lastOperation.Type, j.operation.failure),
+		}}
+	}
+	f.touch(&j.Resource)
+}
+
+func (f *FakeCC) checkUpgradeAvailable(si *serviceInstance) {
+	p, ok := f.servicePlans[si.planGUID()]
+	si.UpgradeAvailable = ok && p.MaintenanceInfo.Version != "" && p.MaintenanceInfo.Version != si.MaintenanceInfo.Version
+}
+
+func (f *FakeCC) removeServiceInstance(si *serviceInstance) {
+	for guid, b := range f.serviceCredentialBindings {
+		if b.Relationships.ServiceInstance.GUID() == si.GUID {
+			delete(f.serviceCredentialBindings, guid)
+		}
+	}
+	delete(f.serviceInstances, si.GUID)
+}
+
+func (f *FakeCC) findServiceInstance(w http.ResponseWriter, r *http.Request) (*serviceInstance, bool) {
+	si, ok := f.serviceInstances[mux.Vars(r)["guid"]]
+	if !ok {
+		writeNotFound(w, "Service instance")
+	}
+	return si, ok
+}
+
+func (f *FakeCC) findServiceCredentialBinding(w http.ResponseWriter, r *http.Request) (*serviceCredentialBinding, bool) {
+	b, ok := f.serviceCredentialBindings[mux.Vars(r)["guid"]]
+	if !ok {
+		writeNotFound(w, "Service credential binding")
+	}
+	return b, ok
+}
+
+func nonNilParameters(parameters map[string]interface{}) map[string]interface{} {
+	if parameters == nil {
+		return map[string]interface{}{}
+	}
+	return parameters
+}
@@ -0,0 +1,302 @@
+package fake_cc
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/cloudfoundry/capi-bara-tests/helpers/capi_client"
+)
+
+// ServicePlan is a plan of an offering added with AddServiceOffering.
+// Operations on instances of an Async plan, and on their bindings, stay in
+// progress until they are polled PollsPerTransition times. Instances of a
+// plan with a MaintenanceInfoVersion can be upgraded.
+type ServicePlan struct {
+	Name                   string
+	Async                  bool
+	MaintenanceInfoVersion string
+}
+
+type servicePlan struct {
+	capi_client.ServicePlan
+	async      bool
+	visibility capi_client.ServicePlanVisibility
+}
+
+func (f *FakeCC) registerServices() {
+	f.router.HandleFunc("/v3/service_offerings", f.listServiceOfferings).Methods(http.MethodGet)
+	f.router.HandleFunc("/v3/service_offerings/{guid}", f.getServiceOffering).Methods(http.MethodGet)
+	f.router.HandleFunc("/v3/service_offerings/{guid}", f.deleteServiceOffering).Methods(http.MethodDelete)
+	f.router.HandleFunc("/v3/service_plans", f.listServicePlans).Methods(http.MethodGet)
+	f.router.HandleFunc("/v3/service_plans/{guid}", f.getServicePlan).Methods(http.MethodGet)
+	f.router.HandleFunc("/v3/service_plans/{guid}/visibility", f.getServicePlanVisibility).Methods(http.MethodGet)
+	f.router.HandleFunc("/v3/service_plans/{guid}/visibility", f.updateServicePlanVisibility).Methods(http.MethodPatch, http.MethodPost)
+	f.router.HandleFunc("/v3/service_plans/{guid}/visibility/{org_guid}", f.removeServicePlanVisibilityOrg).Methods(http.MethodDelete)
+}
+
+// AddServiceOffering adds an offering of the broker with the given plans,
+// standing in for Cloud Controller fetching the broker's catalog, and
+// returns the offering's GUID. Its plans are visible to admins only.
+func (f *FakeCC) AddServiceOffering(brokerGUID, name string, plans ...ServicePlan) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	offering := &capi_client.ServiceOffering{
+		Resource:  f.newResource(),
+		Name:      name,
+		Available: true,
+		Shareable: true,
+		BrokerCatalog: capi_client.ServiceOfferingBrokerCatalog{
+			ID: name + "-id",
+			Features: capi_client.ServiceOfferingFeatures{
+				PlanUpdateable:       true,
+				Bindable:             true,
+				InstancesRetrievable: true,
+				BindingsRetrievable:  true,
+			},
+		},
+		Relationships: capi_client.ServiceOfferingRelationships{ServiceBroker: capi_client.NewRelationship(brokerGUID)},
+	}
+	f.serviceOfferings[offering.GUID] = offering
+
+	for _, plan := range plans {
+		p := &servicePlan{
+			ServicePlan: capi_client.ServicePlan{
+				Resource:        f.newResource(),
+				Name:            plan.Name,
+				Free:            true,
+				Available:       true,
+				VisibilityType:  capi_client.ServicePlanVisibilityAdmin,
+				MaintenanceInfo: capi_client.MaintenanceInfo{Version: plan.MaintenanceInfoVersion},
+				BrokerCatalog:   capi_client.ServicePlanBrokerCatalog{ID: plan.Name + "-id"},
+				Relationships:   capi_client.ServicePlanRelationships{ServiceOffering: capi_client.NewRelationship(offering.GUID)},
+			},
+			async:      plan.Async,
+			visibility: capi_client.ServicePlanVisibility{Type: capi_client.ServicePlanVisibilityAdmin},
+		}
+		f.servicePlans[p.GUID] = p
+	}
+
+	return offering.GUID
+}
+
+// SetServicePlanMaintenanceInfo changes the plan's version, standing in for
+// Cloud Controller fetching a catalog the broker has changed. Instances of
+// the plan at another version have an upgrade available.
+func (f *FakeCC) SetServicePlanMaintenanceInfo(planGUID, version string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	p := f.servicePlans[planGUID]
+	p.MaintenanceInfo = capi_client.MaintenanceInfo{Version: version}
+	f.touch(&p.Resource)
+	for _, si := range f.serviceInstances {
+		if si.planGUID() == planGUID {
+			f.checkUpgradeAvailable(si)
+		}
+	}
+}
+
+func (f *FakeCC) listServiceOfferings(w http.ResponseWriter, r *http.Request) {
+	names := filterValues(r, "names")
+	brokerGUIDs := filterValues(r, "service_broker_guids")
+
+	offerings := []capi_client.ServiceOffering{}
+	for _, offering := range f.serviceOfferings {
+		if (names == nil || contains(names, offering.Name)) &&
+			(brokerGUIDs == nil || contains(brokerGUIDs, offering.Relationships.ServiceBroker.GUID())) {
+			offerings = append(offerings, *offering)
+		}
+	}
+	sortByCreatedAt(offerings, func(o capi_client.ServiceOffering) capi_client.Resource { return o.Resource })
+	writeList(w, r, offerings)
+}
+
+func (f *FakeCC) getServiceOffering(w http.ResponseWriter, r *http.Request) {
+	offering, ok := f.findServiceOffering(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, offering)
+}
+
+// deleteServiceOffering only supports purging, which removes the plans,
+// instances and bindings along with the offering.
+func (f *FakeCC) deleteServiceOffering(w http.ResponseWriter, r *http.Request) {
+	offering, ok := f.findServiceOffering(w, r)
+	if !ok {
+		return
+	}
+
+	if r.URL.Query().Get("purge") != "true" {
+		for _, p := range f.servicePlans {
+			if p.Relationships.ServiceOffering.GUID() == offering.GUID && f.planHasInstances(p.GUID) {
+				writeUnprocessable(w, "Service offering must not have any service instances in order to be deleted. Purge it instead.")
+				return
+			}
+		}
+	}
+
+	for guid, p := range f.servicePlans {
+		if p.Relationships.ServiceOffering.GUID() != offering.GUID {
+			continue
+		}
+		for _, si := range f.serviceInstances {
+			if si.planGUID() == p.GUID {
+				f.removeServiceInstance(si)
+			}
+		}
+		delete(f.servicePlans, guid)
+	}
+	delete(f.serviceOfferings, offering.GUID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (f *FakeCC) listServicePlans(w http.ResponseWriter, r *http.Request) {
+	names := filterValues(r, "names")
+	offeringGUIDs := filterValues(r, "service_offering_guids")
+
+	plans := []capi_client.ServicePlan{}
+	for _, p := range f.servicePlans {
+		if (names == nil || contains(names, p.Name)) &&
+			(offeringGUIDs == nil || contains(offeringGUIDs, p.Relationships.ServiceOffering.GUID())) {
+			plans = append(plans, p.ServicePlan)
+		}
+	}
+	sortByCreatedAt(plans, func(p capi_client.ServicePlan) capi_client.Resource { return p.Resource })
+	writeList(w, r, plans)
+}
+
+func (f *FakeCC) getServicePlan(w http.ResponseWriter, r *http.Request) {
+	p, ok := f.findServicePlan(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, p.ServicePlan)
+}
+
+func (f *FakeCC) getServicePlanVisibility(w http.ResponseWriter, r *http.Request) {
+	p, ok := f.findServicePlan(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, p.visibility)
+}
+
+// updateServicePlanVisibility replaces the visibility on PATCH and appends
+// organizations to it on POST.
+func (f *FakeCC) updateServicePlanVisibility(w http.ResponseWriter, r *http.Request) {
+	p, ok := f.findServicePlan(w, r)
+	if !ok {
+		return
+	}
+
+	var request capi_client.ServicePlanVisibility
+	if !decode(w, r, &request) {
+		return
+	}
+
+	switch request.Type {
+	case capi_client.ServicePlanVisibilityPublic, capi_client.ServicePlanVisibilityAdmin:
+		if r.Method == http.MethodPost {
+			writeUnprocessable(w, fmt.Sprintf("Type must be 'organization' to append organizations, not '%s'", request.Type))
+			return
+		}
+		if len(request.Organizations) > 0 {
+			writeUnprocessable(w, fmt.Sprintf("Organizations can only be given for type 'organization', not '%s'", request.Type))
+			return
+		}
+	case capi_client.ServicePlanVisibilityOrganization:
+		if len(request.Organizations) == 0 {
+			writeUnprocessable(w, "Organizations can't be blank")
+			return
+		}
+	default:
+		writeUnprocessable(w, "Type must be one of 'public', 'admin', 'organization'")
+		return
+	}
+
+	visibility := capi_client.ServicePlanVisibility{Type: request.Type}
+	if r.Method == http.MethodPost && p.visibility.Type == capi_client.ServicePlanVisibilityOrganization {
+		visibility.Organizations = p.visibility.Organizations
+	}
+	for _, org := range request.Organizations {
+		if !f.visibleInOrg(visibility, org.GUID) {
+			visibility.Organizations = append(visibility.Organizations, f.visibilityOrg(org.GUID))
+		}
+	}
+
+	p.visibility = visibility
+	p.VisibilityType = visibility.Type
+	f.touch(&p.Resource)
+	writeJSON(w, http.StatusOK, p.visibility)
+}
+
+func (f *FakeCC) removeServicePlanVisibilityOrg(w http.ResponseWriter, r *http.Request) {
+	p, ok := f.findServicePlan(w, r)
+	if !ok {
+		return
+	}
+
+	orgGUID := mux.Vars(r)["org_guid"]
+	if p.visibility.Type != capi_client.ServicePlanVisibilityOrganization || !f.visibleInOrg(p.visibility, orgGUID) {
+		writeNotFound(w, "Service plan visibility")
+		return
+	}
+
+	remaining := []capi_client.ServicePlanVisibilityTarget{}
+	for _, org := range p.visibility.Organizations {
+		if org.GUID != orgGUID {
+			remaining = append(remaining, org)
+		}
+	}
+	p.visibility.Organizations = remaining
+	f.touch(&p.Resource)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (f *FakeCC) visibleInOrg(visibility capi_client.ServicePlanVisibility, orgGUID string) bool {
+	for _, org := range visibility.Organizations {
+		if org.GUID == orgGUID {
+			return true
+		}
+	}
+	return false
+}
+
+// visibilityOrg names the organization if it was added with AddNamed.
+func (f *FakeCC) visibilityOrg(orgGUID string) capi_client.ServicePlanVisibilityTarget {
+	org := capi_client.ServicePlanVisibilityTarget{GUID: orgGUID}
+	if named, ok := f.named["organizations"][orgGUID]; ok {
+		org.Name = named.Name
+	}
+	return org
+}
+
+func (f *FakeCC) planHasInstances(planGUID string) bool {
+	for _, si := range f.serviceInstances {
+		if si.planGUID() == planGUID {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *FakeCC) findServiceOffering(w http.ResponseWriter, r *http.Request) (*capi_client.ServiceOffering, bool) {
+	offering, ok := f.serviceOfferings[mux.Vars(r)["guid"]]
+	if !ok {
+		writeNotFound(w, "Service offering")
+	}
+	return offering, ok
+}
+
+func (f *FakeCC) findServicePlan(w http.ResponseWriter, r *http.Request) (*servicePlan, bool) {
+	p, ok := f.servicePlans[mux.Vars(r)["guid"]]
+	if !ok {
+		writeNotFound(w, "Service plan")
+	}
+	return p, ok
+}
@@ -0,0 +1,179 @@
+package fake_cc
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/cloudfoundry/capi-bara-tests/helpers/capi_client"
+)
+
+type task struct {
+	capi_client.Task
+	transition
+}
+
+func (f *FakeCC) registerTasks() {
+	f.router.HandleFunc("/v3/apps/{guid}/tasks", f.listAppTasks).Methods(http.MethodGet)
+	f.router.HandleFunc("/v3/apps/{guid}/tasks", f.createTask).Methods(http.MethodPost)
+	f.router.HandleFunc("/v3/tasks/{guid}", f.getTask).Methods(http.MethodGet)
+	f.router.HandleFunc("/v3/tasks/{guid}/actions/cancel", f.cancelTask).Methods(http.MethodPost)
+}
+
+// FailTask makes tasks running the command fail with the given reason.
+func (f *FakeCC) FailTask(command, reason string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.taskFailures[command] = reason
+}
+
+func (f *FakeCC) createTask(w http.ResponseWriter, r *http.Request) {
+	a, ok := f.findApp(w, r)
+	if !ok {
+		return
+	}
+
+	var request capi_client.CreateTaskRequest
+	if !decode(w, r, &request) {
+		return
+	}
+
+	t := &task{Task: capi_client.Task{
+		Resource:                     f.newResource(),
+		Name:                         request.Name,
+		Command:                      request.Command,
+		State:                        capi_client.TaskStateRunning,
+		MemoryInMB:                   1024,
+		DiskInMB:                     1024,
+		LogRateLimitInBytesPerSecond: -1,
+		DropletGUID:                  a.currentDroplet,
+		Relationships:                capi_client.TaskRelationships{App: capi_client.NewRelationship(a.GUID)},
+	}}
+
+	if request.Template != nil {
+		p, ok := f.processes[request.Template.Process.GUID]
+		if !ok || p.Relationships.App.GUID() != a.GUID {
+			writeUnprocessable(w, "Template process must belong to the app.")
+			return
+		}
+		if t.Command == "" {
+			t.Command = p.Command
+		}
+		t.MemoryInMB = p.MemoryInMB
+		t.DiskInMB = p.DiskInMB
+	}
+	if t.Command == "" {
+		writeUnprocessable(w, "Task must have a command")
+		return
+	}
+	if request.DropletGUID != "" {
+		d, ok := f.droplets[request.DropletGUID]
+		if !ok || d.Relationships.App.GUID() != a.GUID {
+			writeUnprocessable(w, "Droplet must belong to the app.")
+			return
+		}
+		t.DropletGUID = d.GUID
+	}
+	if t.DropletGUID == "" {
+		writeUnprocessable(w, "Task must have a droplet. Assign current droplet to app.")
+		return
+	}
+	if request.MemoryInMB != nil {
+		t.MemoryInMB = *request.MemoryInMB
+	}
+	if request.DiskInMB != nil {
+		t.DiskInMB = *request.DiskInMB
+	}
+	if request.LogRateLimitInBytesPerSecond != nil {
+		t.LogRateLimitInBytesPerSecond = *request.LogRateLimitInBytesPerSecond
+	}
+
+	for _, existing := range f.tasks {
+		if existing.Relationships.App.GUID() == a.GUID {
+			t.SequenceID++
+		}
+	}
+	t.SequenceID++
+	if t.Name == "" {
+		t.Name = t.GUID[:8]
+	}
+	f.tasks[t.GUID] = t
+
+	writeJSON(w, http.StatusAccepted, t.Task)
+}
+
+func (f *FakeCC) getTask(w http.ResponseWriter, r *http.Request) {
+	t, ok := f.findTask(w, r)
+	if !ok {
+		return
+	}
+
+	switch t.State {
+	case capi_client.TaskStateRunning:
+		if f.advance(&t.transition) {
+			f.finishTask(t)
+		}
+	case capi_client.TaskStateCanceling:
+		if f.advance(&t.transition) {
+			f.failTask(t, "task was cancelled")
+		}
+	}
+	writeJSON(w, http.StatusOK, t.Task)
+}
+
+func (f *FakeCC) cancelTask(w http.ResponseWriter, r *http.Request) {
+	t, ok := f.findTask(w, r)
+	if !ok {
+		return
+	}
+
+	if t.State != capi_client.TaskStatePending && t.State != capi_client.TaskStateRunning {
+		writeError(w, http.StatusUnprocessableEntity, 170019, "CF-InvalidTaskRequest", "Task state is "+t.State+" and therefore cannot be canceled")
+		return
+	}
+
+	t.transition = transition{}
+	t.State = capi_client.TaskStateCanceling
+	f.touch(&t.Resource)
+	writeJSON(w, http.StatusAccepted, t.Task)
+}
+
+func (f *FakeCC) listAppTasks(w http.ResponseWriter, r *http.Request) {
+	a, ok := f.findApp(w, r)
+	if !ok {
+		return
+	}
+	states := filterValues(r, "states")
+
+	tasks := []capi_client.Task{}
+	for _, t := range f.tasks {
+		if t.Relationships.App.GUID() == a.GUID && (states == nil || contains(states, t.State)) {
+			tasks = append(tasks, t.Task)
+		}
+	}
+	sortByCreatedAt(tasks, func(t capi_client.Task) capi_client.Resource { return t.Resource })
+	writeList(w, r, tasks)
+}
+
+func (f *FakeCC) finishTask(t *task) {
+	if reason, failed := f.taskFailures[t.Command]; failed {
+		f.failTask(t, reason)
+		return
+	}
+	t.State = capi_client.TaskStateSucceeded
+	f.touch(&t.Resource)
+}
+
+func (f *FakeCC) failTask(t *task, reason string) {
+	t.State = capi_client.TaskStateFailed
+	t.Result.FailureReason = &reason
+	f.touch(&t.Resource)
+}
+
+func (f *FakeCC) findTask(w http.ResponseWriter, r *http.Request) (*task, bool) {
+	t, ok := f.tasks[mux.Vars(r)["guid"]]
+	if !ok {
+		writeNotFound(w, "Task")
+	}
+	return t, ok
+}
@@ -0,0 +1,275 @@
+// Package prober sends a steady stream of requests to an app while an
+// operation runs and reports every outage, however brief, along with which
+// instances answered.
+package prober
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxBodyLength caps the body recorded per response. Endpoints worth
+// probing answer with a short identifier, e.g. catnip's /id.
+const maxBodyLength = 256
+
+type Prober struct {
+	// URL is requested with GET. Pointing it at an endpoint that answers
+	// with the instance's identity, like catnip's /id, which returns
+	// CF_INSTANCE_GUID, makes Report.Distribution per instance.
+	URL string
+	// Interval is the time between the starts of consecutive requests. A
+	// request that takes longer delays the next one.
+	Interval time.Duration
+	// Client defaults to one timing requests out after a second.
+	Client *http.Client
+
+	// Now defaults to time.Now.
+	Now func() time.Time
+}
+
+// Result is the outcome of one request. Err is set if no response arrived.
+type Result struct {
+	At      time.Time
+	Status  int
+	Latency time.Duration
+	Body    string
+	Err     error
+}
+
+func (r Result) Succeeded() bool {
+	return r.Err == nil && r.Status >= 200 && r.Status < 300
+}
+
+type Report struct {
+	Started time.Time
+	Stopped time.Time
+	Results []Result
+}
+
+// Probe is a prober running in the background.
+type Probe struct {
+	prober Prober
+	stop   chan struct{}
+	done   chan struct{}
+
+	mu     sync.Mutex
+	report Report
+}
+
+// Start sends the first request straight away and keeps going until Stop.
+func (p Prober) Start() *Probe {
+	p = p.withDefaults()
+	probe := &Probe{
+		prober: p,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+		report: Report{Started: p.Now()},
+	}
+	go probe.run()
+	return probe
+}
+
+// Stop waits for the request in flight, if any, and returns every result.
+// Stopping twice returns the same report.
+func (p *Probe) Stop() Report {
+	p.mu.Lock()
+	select {
+	case <-p.stop:
+	default:
+		close(p.stop)
+	}
+	p.mu.Unlock()
+	<-p.done
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.report
+}
+
+// Report returns the results so far without stopping the probe, e.g. to
+// wait until enough requests have succeeded. Until Stop, the report ends now.
+func (p *Probe) Report() Report {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	report := p.report
+	report.Results = append([]Result(nil), p.report.Results...)
+	if report.Stopped.IsZero() {
+		report.Stopped = p.prober.Now()
+	}
+	return report
+}
+
+// Send sends requests one after the other, ignoring Interval, and returns
+// their results.
+func (p Prober) Send(requests int) Report {
+	p = p.withDefaults()
+
+	report := Report{Started: p.Now()}
+	for i := 0; i < requests; i++ {
+		report.Results = append(report.Results, p.probe())
+	}
+	report.Stopped = p.Now()
+	return report
+}
+
+func (p Prober) withDefaults() Prober {
+	if p.Client == nil {
+		p.Client = &http.Client{Timeout: time.Second}
+	}
+	if p.Now == nil {
+		p.Now = time.Now
+	}
+	return p
+}
+
+func (p *Probe) run() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.prober.Interval)
+	defer ticker.Stop()
+
+	for {
+		result := p.prober.probe()
+
+		p.mu.Lock()
+		p.report.Results = append(p.report.Results, result)
+		p.mu.Unlock()
+
+		select {
+		case <-p.stop:
+			p.mu.Lock()
+			p.report.Stopped = p.prober.Now()
+			p.mu.Unlock()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p Prober) probe() Result {
+	result := Result{At: p.Now()}
+
+	response, err := p.Client.Get(p.URL)
+	if err != nil {
+		result.Latency = p.Now().Sub(result.At)
+		result.Err = err
+		return result
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxBodyLength))
+	result.Latency = p.Now().Sub(result.At)
+	result.Status = response.StatusCode
+	result.Body = strings.TrimSpace(string(body))
+	result.Err = err
+	return result
+}
+
+// Errors counts the requests that got no response or a non-2xx one.
+func (r Report) Errors() int {
+	errors := 0
+	for _, result := range r.Results {
+		if !result.Succeeded() {
+			errors++
+		}
+	}
+	return errors
+}
+
+// ErrorsByStatus counts failed requests by status code, 0 for requests that
+// got no response.
+func (r Report) ErrorsByStatus() map[int]int {
+	errors := map[int]int{}
+	for _, result := range r.Results {
+		if !result.Succeeded() {
+			errors[result.Status]++
+		}
+	}
+	return errors
+}
+
+// MaxGap is the longest the app went without answering a request
+// successfully: between the start of the probe, the completions of
+// successful requests and the end of the probe.
+func (r Report) MaxGap() time.Duration {
+	last := r.Started
+	maxGap := time.Duration(0)
+	for _, result := range r.Results {
+		if !result.Succeeded() {
+			continue
+		}
+		completed := result.At.Add(result.Latency)
+		if gap := completed.Sub(last); gap > maxGap {
+			maxGap = gap
+		}
+		last = completed
+	}
+	if gap := r.Stopped.Sub(last); gap > maxGap {
+		maxGap = gap
+	}
+	return maxGap
+}
+
+// MaxLatency is the longest any request took.
+func (r Report) MaxLatency() time.Duration {
+	maxLatency := time.Duration(0)
+	for _, result := range r.Results {
+		if result.Latency > maxLatency {
+			maxLatency = result.Latency
+		}
+	}
+	return maxLatency
+}
+
+// Distribution counts successful responses by body, which against catnip's
+// /id is the responding instance's GUID.
+func (r Report) Distribution() map[string]int {
+	return r.DistributionBy(func(body string) string { return body })
+}
+
+// DistributionBy counts successful responses by the key of their body, e.g.
+// the app an instance GUID belongs to.
+func (r Report) DistributionBy(key func(body string) string) map[string]int {
+	distribution := map[string]int{}
+	for _, result := range r.Results {
+		if result.Succeeded() {
+			distribution[key(result.Body)]++
+		}
+	}
+	return distribution
+}
+
+// String summarises the report for failure messages.
+func (r Report) String() string {
+	var summary strings.Builder
+	fmt.Fprintf(&summary, "%d request(s) over %s, %d failed, max gap %s, max latency %s\n",
+		len(r.Results), r.Stopped.Sub(r.Started), r.Errors(), r.MaxGap(), r.MaxLatency())
+
+	distribution := r.Distribution()
+	bodies := make([]string, 0, len(distribution))
+	for body := range distribution {
+		bodies = append(bodies, body)
+	}
+	sort.Strings(bodies)
+	for _, body := range bodies {
+		fmt.Fprintf(&summary, "  %q: %d\n", body, distribution[body])
+	}
+
+	for _, result := range r.Results {
+		if result.Succeeded() {
+			continue
+		}
+		outcome := fmt.Sprintf("status %d", result.Status)
+		if result.Err != nil {
+			outcome = result.Err.Error()
+		}
+		fmt.Fprintf(&summary, "  failed at %s after %s: %s\n", result.At.Format(time.RFC3339Nano), result.Latency, outcome)
+	}
+	return summary.String()
+}
@@ -0,0 +1,13 @@
+package prober_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestProber(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Prober Suite")
+}
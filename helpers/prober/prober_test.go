@@ -0,0 +1,148 @@
+package prober_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudfoundry/capi-bara-tests/helpers/prober"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Prober", func() {
+	var (
+		server   *httptest.Server
+		requests atomic.Int32
+		failing  atomic.Bool
+	)
+
+	BeforeEach(func() {
+		requests.Store(0)
+		failing.Store(false)
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			n := requests.Add(1)
+			if failing.Load() {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			fmt.Fprintf(w, "instance-%d\n", n%2)
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("records every request until stopped", func() {
+		waitForRequests := func(n int32) {
+			seen := requests.Load()
+			Eventually(requests.Load).Should(BeNumerically(">=", seen+n))
+		}
+
+		probe := prober.Prober{URL: server.URL, Interval: time.Millisecond}.Start()
+		waitForRequests(5)
+		failing.Store(true)
+		waitForRequests(5)
+		failing.Store(false)
+		waitForRequests(5)
+		report := probe.Stop()
+
+		Expect(len(report.Results)).To(BeNumerically(">=", 15))
+		Expect(probe.Stop().Results).To(Equal(report.Results))
+		Expect(report.Errors()).To(BeNumerically(">=", 1))
+		Expect(report.ErrorsByStatus()).To(HaveKeyWithValue(http.StatusBadGateway, report.Errors()))
+		Expect(report.Distribution()).To(HaveKey("instance-0"))
+		Expect(report.Distribution()).To(HaveKey("instance-1"))
+		Expect(report.Results[0].Status).To(Equal(http.StatusOK))
+		Expect(report.Results[0].Body).To(Equal("instance-1"))
+		Expect(report.String()).To(ContainSubstring("failed at"))
+	})
+
+	It("reports the results so far while running", func() {
+		probe := prober.Prober{URL: server.URL, Interval: time.Millisecond}.Start()
+		Eventually(func() map[string]int {
+			return probe.Report().Distribution()
+		}).Should(And(HaveKey("instance-0"), HaveKey("instance-1")))
+
+		running := probe.Report()
+		Expect(running.Stopped).To(BeTemporally(">=", running.Started))
+		Expect(len(probe.Stop().Results)).To(BeNumerically(">=", len(running.Results)))
+	})
+
+	It("records requests that got no response", func() {
+		server.Close()
+
+		report := prober.Prober{URL: server.URL, Interval: time.Millisecond}.Start().Stop()
+		Expect(report.Results).To(HaveLen(1))
+		Expect(report.Results[0].Err).To(HaveOccurred())
+		Expect(report.ErrorsByStatus()).To(Equal(map[int]int{0: 1}))
+	})
+
+	It("sends a fixed number of requests", func() {
+		report := prober.Prober{URL: server.URL}.Send(10)
+
+		Expect(report.Results).To(HaveLen(10))
+		Expect(report.Distribution()).To(Equal(map[string]int{"instance-0": 5, "instance-1": 5}))
+		Expect(report.DistributionBy(func(body string) string { return "app" })).To(Equal(map[string]int{"app": 10}))
+	})
+
+	Describe("Report", func() {
+		start := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+		at := func(seconds int) time.Time { return start.Add(time.Duration(seconds) * time.Second) }
+
+		report := prober.Report{
+			Started: start,
+			Stopped: at(20),
+			Results: []prober.Result{
+				{At: at(0), Status: 200, Latency: time.Second, Body: "a"},
+				{At: at(2), Status: 502, Latency: time.Second},
+				{At: at(4), Err: errors.New("connection refused"), Latency: 3 * time.Second},
+				{At: at(8), Status: 200, Latency: time.Second, Body: "b"},
+				{At: at(10), Status: 200, Latency: time.Second, Body: "b"},
+			},
+		}
+
+		It("measures the longest stretch without a successful response", func() {
+			Expect(report.MaxGap()).To(Equal(9 * time.Second))
+		})
+
+		It("counts the end of the probe as the end of a gap", func() {
+			Expect(prober.Report{Started: start, Stopped: at(5)}.MaxGap()).To(Equal(5 * time.Second))
+		})
+
+		It("counts errors and successful responses per instance", func() {
+			Expect(report.Errors()).To(Equal(2))
+			Expect(report.ErrorsByStatus()).To(Equal(map[int]int{0: 1, 502: 1}))
+			Expect(report.Distribution()).To(Equal(map[string]int{"a": 1, "b": 2}))
+			Expect(report.MaxLatency()).To(Equal(3 * time.Second))
+		})
+	})
+
+	Describe("CheckWeights", func() {
+		It("accepts counts within the tolerance of the weights", func() {
+			Expect(prober.CheckWeights(map[string]int{"a": 230, "b": 770}, map[string]int{"a": 25, "b": 75}, 3)).To(Succeed())
+			Expect(prober.CheckWeights(map[string]int{"a": 100}, map[string]int{"a": 100, "b": 0}, 3)).To(Succeed())
+		})
+
+		It("rejects counts too far from the weights", func() {
+			err := prober.CheckWeights(map[string]int{"a": 500, "b": 500}, map[string]int{"a": 25, "b": 75}, 3)
+			Expect(err).To(MatchError(ContainSubstring("a: got 500 of 1000 requests, want 250.0")))
+			Expect(err).To(MatchError(ContainSubstring("b: got 500 of 1000 requests, want 750.0")))
+		})
+
+		It("rejects traffic to destinations without a weight", func() {
+			err := prober.CheckWeights(map[string]int{"a": 99, "c": 1}, map[string]int{"a": 100}, 3)
+			Expect(err).To(MatchError(ContainSubstring("a: got 99 of 100 requests, want 100")))
+			Expect(err).To(MatchError(ContainSubstring("c: got 1 of 100 requests, want 0")))
+		})
+
+		It("rejects an empty distribution", func() {
+			Expect(prober.CheckWeights(map[string]int{}, map[string]int{"a": 100}, 3)).To(MatchError(ContainSubstring("no traffic")))
+		})
+	})
+})
@@ -0,0 +1,63 @@
+package prober
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// CheckWeights reports an error unless observed, the responses counted per
+// destination, is consistent with the destinations' weights: every count
+// must lie within maxDeviations standard deviations of the count its share
+// of the total weight predicts. Destinations without a weight must not
+// have received any traffic.
+func CheckWeights(observed map[string]int, weights map[string]int, maxDeviations float64) error {
+	requests, totalWeight := 0, 0
+	for _, count := range observed {
+		requests += count
+	}
+	for _, weight := range weights {
+		totalWeight += weight
+	}
+	if requests == 0 || totalWeight == 0 {
+		return fmt.Errorf("no traffic to compare with weights %v: observed %v", weights, observed)
+	}
+
+	keys := map[string]bool{}
+	for key := range observed {
+		keys[key] = true
+	}
+	for key := range weights {
+		keys[key] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	var failures []string
+	for _, key := range sorted {
+		share := float64(weights[key]) / float64(totalWeight)
+		expected := float64(requests) * share
+		count := float64(observed[key])
+		standardDeviation := math.Sqrt(float64(requests) * share * (1 - share))
+
+		if standardDeviation == 0 {
+			if count != expected {
+				failures = append(failures, fmt.Sprintf("%s: got %d of %d requests, want %.0f", key, observed[key], requests, expected))
+			}
+			continue
+		}
+		if deviations := math.Abs(count-expected) / standardDeviation; deviations > maxDeviations {
+			failures = append(failures, fmt.Sprintf("%s: got %d of %d requests, want %.1f ± %.1f (%.1f standard deviations off)",
+				key, observed[key], requests, expected, maxDeviations*standardDeviation, deviations))
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("traffic does not match weights %v:\n%s", weights, strings.Join(failures, "\n"))
+	}
+	return nil
+}
@@ -1,7 +1,169 @@
+// Package random_name names the resources specs create so that a leaked
+// resource can be traced back to the run, parallel process and spec that
+// created it, e.g. BARA-2-APP-1f3a9c0e-5d41402a-7.
 package random_name
 
-import "github.com/cloudfoundry/cf-test-helpers/v2/generator"
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	. "github.com/cloudfoundry/capi-bara-tests/bara_suite_helpers"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/trace"
+	. "github.com/onsi/ginkgo/v2"
+)
+
+// MaxLength keeps names usable as route hosts, which are DNS labels.
+const MaxLength = 63
+
+// MaxPrefixLength leaves room in MaxLength for the rest of the name. Longer
+// prefixes are cut short.
+const MaxPrefixLength = 20
+
+const (
+	defaultPrefix = "BARA"
+	runTagLength  = 8
+	noSpec        = "00000000"
+)
+
+var (
+	nonKindCharacters = regexp.MustCompile(`[^A-Za-z0-9]+`)
+	nonRunCharacters  = regexp.MustCompile(`[^a-z0-9]+`)
+
+	sequence uint64
+
+	localRunTagOnce sync.Once
+	localRunTag     string
+)
+
+// Name is what a name encodes. Names are unique per run as long as each
+// run has its own RUN_ID: the sequence number never repeats within a
+// parallel process.
+type Name struct {
+	Prefix string
+	// Node is the Ginkgo parallel process.
+	Node int
+	Kind string
+	// Run is the first 8 characters of RUN_ID.
+	Run string
+	// Spec is trace.SpecHash of the spec's full text, or 00000000 outside
+	// of a spec.
+	Spec     string
+	Sequence uint64
+}
 
 func BARARandomName(resource string) string {
-	return generator.PrefixedRandomName("BARA", resource)
+	return NewName(resource).String()
+}
+
+// NewName names a resource of the given kind created by the running spec.
+func NewName(kind string) Name {
+	prefix := defaultPrefix
+	if Config != nil {
+		prefix = Config.GetNamePrefix()
+	}
+
+	spec := noSpec
+	if specText := CurrentSpecReport().FullText(); specText != "" {
+		spec = trace.SpecHash(specText)
+	}
+
+	return Name{
+		Prefix:   prefix,
+		Node:     GinkgoParallelProcess(),
+		Kind:     kind,
+		Run:      RunTag(),
+		Spec:     spec,
+		Sequence: atomic.AddUint64(&sequence, 1),
+	}
+}
+
+// RunTag identifies the run in names. It comes from RUN_ID, which bin/test
+// sets; without it, each parallel process makes up its own.
+func RunTag() string {
+	if tag := nonRunCharacters.ReplaceAllString(strings.ToLower(os.Getenv("RUN_ID")), ""); tag != "" {
+		if len(tag) > runTagLength {
+			tag = tag[:runTagLength]
+		}
+		return tag
+	}
+
+	localRunTagOnce.Do(func() {
+		b := make([]byte, runTagLength/2)
+		if _, err := rand.Read(b); err != nil {
+			panic(err)
+		}
+		localRunTag = hex.EncodeToString(b)
+	})
+	return localRunTag
+}
+
+// String formats the name as <prefix>-<node>-<kind>-<run>-<spec>-<sequence>,
+// with the sequence in base 36. The prefix and kind are reduced to letters,
+// digits and single hyphens, the prefix is cut to MaxPrefixLength, and the
+// kind is shortened so that the name never exceeds MaxLength.
+func (n Name) String() string {
+	kind := strings.Trim(nonKindCharacters.ReplaceAllString(n.Kind, "-"), "-")
+	if kind == "" {
+		kind = "X"
+	}
+
+	suffix := fmt.Sprintf("-%s-%s-%s", n.Run, n.Spec, strconv.FormatUint(n.Sequence, 36))
+	head := fmt.Sprintf("%s-%d-", CleanPrefix(n.Prefix), n.Node)
+	if room := MaxLength - len(head) - len(suffix); len(kind) > room {
+		kind = strings.TrimRight(kind[:max(room, 1)], "-")
+	}
+	return head + kind + suffix
+}
+
+// CleanPrefix returns prefix as String writes it into names.
+func CleanPrefix(prefix string) string {
+	prefix = strings.Trim(nonKindCharacters.ReplaceAllString(prefix, "-"), "-")
+	if len(prefix) > MaxPrefixLength {
+		prefix = strings.TrimRight(prefix[:MaxPrefixLength], "-")
+	}
+	if prefix == "" {
+		return defaultPrefix
+	}
+	return prefix
+}
+
+// Parse reverses String for a name made with the given prefix.
+func Parse(prefix, name string) (Name, error) {
+	prefix = CleanPrefix(prefix)
+	rest, found := strings.CutPrefix(name, prefix+"-")
+	if !found {
+		return Name{}, fmt.Errorf("name %q does not start with %q", name, prefix+"-")
+	}
+
+	parts := strings.Split(rest, "-")
+	if len(parts) < 5 {
+		return Name{}, fmt.Errorf("name %q has too few parts", name)
+	}
+
+	node, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return Name{}, fmt.Errorf("name %q has no parallel process number: %s", name, err)
+	}
+
+	last := len(parts) - 1
+	sequence, err := strconv.ParseUint(parts[last], 36, 64)
+	if err != nil {
+		return Name{}, fmt.Errorf("name %q has no sequence number: %s", name, err)
+	}
+
+	return Name{
+		Prefix:   prefix,
+		Node:     node,
+		Kind:     strings.Join(parts[1:last-2], "-"),
+		Run:      parts[last-2],
+		Spec:     parts[last-1],
+		Sequence: sequence,
+	}, nil
 }
@@ -0,0 +1,13 @@
+package random_name_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestRandomName(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Random Name Suite")
+}
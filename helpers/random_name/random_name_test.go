@@ -0,0 +1,85 @@
+package random_name_test
+
+import (
+	"os"
+	"strings"
+
+	. "github.com/cloudfoundry/capi-bara-tests/bara_suite_helpers"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/fake_cc"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/random_name"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/trace"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RandomName", func() {
+	BeforeEach(func() {
+		fakeCC := fake_cc.New()
+		DeferCleanup(fakeCC.Close)
+		Config = fakeCC.Config()
+
+		DeferCleanup(os.Setenv, "RUN_ID", os.Getenv("RUN_ID"))
+		Expect(os.Setenv("RUN_ID", "1F3A9C0E-77aa-4bd2")).To(Succeed())
+	})
+
+	It("encodes the prefix, process, kind, run and spec", func() {
+		name, err := random_name.Parse("BARA", random_name.BARARandomName("SVC-PLAN"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(name.Prefix).To(Equal("BARA"))
+		Expect(name.Node).To(Equal(GinkgoParallelProcess()))
+		Expect(name.Kind).To(Equal("SVC-PLAN"))
+		Expect(name.Run).To(Equal("1f3a9c0e"))
+		Expect(name.Spec).To(Equal(trace.SpecHash(CurrentSpecReport().FullText())))
+	})
+
+	It("never repeats a name", func() {
+		first := random_name.NewName("APP")
+		second := random_name.NewName("APP")
+		Expect(second.Sequence).To(BeNumerically(">", first.Sequence))
+		Expect(second.String()).NotTo(Equal(first.String()))
+	})
+
+	It("keeps names DNS-safe and within a DNS label", func() {
+		name := random_name.BARARandomName("sleepy sidecar_buildpack/" + strings.Repeat("x", 80))
+		Expect(len(name)).To(BeNumerically("<=", random_name.MaxLength))
+		Expect(name).To(MatchRegexp(`^[A-Za-z0-9]+(-[A-Za-z0-9]+)*$`))
+
+		parsed, err := random_name.Parse("BARA", name)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(parsed.Kind).To(HavePrefix("sleepy-sidecar-buildpack-xxx"))
+	})
+
+	It("cleans up and shortens a prefix that would not fit in a DNS label", func() {
+		prefix := "my_pipeline.staging." + strings.Repeat("X", 80)
+		name := random_name.Name{Prefix: prefix, Node: 999, Kind: "SERVICE-INSTANCE", Run: "abcdef01", Spec: "5d41402a", Sequence: 1<<64 - 1}
+		Expect(len(name.String())).To(BeNumerically("<=", random_name.MaxLength))
+		Expect(name.String()).To(MatchRegexp(`^[A-Za-z0-9]+(-[A-Za-z0-9]+)*$`))
+		Expect(name.String()).To(HavePrefix("my-pipeline-staging-999-"))
+
+		parsed, err := random_name.Parse(prefix, name.String())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(parsed.Prefix).To(Equal("my-pipeline-staging"))
+		Expect(parsed.Sequence).To(Equal(name.Sequence))
+	})
+
+	It("round-trips through Parse", func() {
+		name := random_name.Name{Prefix: "MY-PREFIX", Node: 12, Kind: "ORG-QUOTA", Run: "abcdef01", Spec: "5d41402a", Sequence: 1000}
+		Expect(name.String()).To(Equal("MY-PREFIX-12-ORG-QUOTA-abcdef01-5d41402a-rs"))
+
+		parsed, err := random_name.Parse("MY-PREFIX", name.String())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(parsed).To(Equal(name))
+	})
+
+	It("rejects names it did not make", func() {
+		_, err := random_name.Parse("BARA", "CATS-1-APP-abcdef01-5d41402a-1")
+		Expect(err).To(MatchError(ContainSubstring(`does not start with "BARA-"`)))
+
+		_, err = random_name.Parse("BARA", "BARA-1-ORG-0123456789abcdef")
+		Expect(err).To(MatchError(ContainSubstring("too few parts")))
+
+		_, err = random_name.Parse("BARA", "BARA-x-APP-abcdef01-5d41402a-1")
+		Expect(err).To(MatchError(ContainSubstring("no parallel process number")))
+	})
+})
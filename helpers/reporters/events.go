@@ -0,0 +1,56 @@
+package reporters
+
+import (
+	"github.com/onsi/ginkgo/v2/types"
+)
+
+// Event is the structured record emitted for one spec. Custom tags are
+// flattened alongside the fixed fields so they can be queried directly.
+type Event map[string]interface{}
+
+// NewSpecEvent builds the event for a finished spec. Failure fields are only
+// set for specs that did not pass or skip.
+func NewSpecEvent(report types.SpecReport, runID string, customTags map[string]interface{}) Event {
+	event := Event{}
+	for key, value := range customTags {
+		event[key] = value
+	}
+
+	event["name"] = report.FullText()
+	event["state"] = report.State.String()
+	event["duration_seconds"] = report.RunTime.Seconds()
+	event["parallel_process"] = report.ParallelProcess
+	if runID != "" {
+		event["run_id"] = runID
+	}
+	if labels := report.Labels(); len(labels) > 0 {
+		event["labels"] = labels
+	}
+
+	if report.Failed() {
+		event["failure_location"] = report.FailureLocation().String()
+		event["failure_message"] = report.FailureMessage()
+	}
+
+	return event
+}
+
+// SpecReporter sends one event per spec to a Sink.
+type SpecReporter struct {
+	sink       Sink
+	runID      string
+	customTags map[string]interface{}
+}
+
+func NewSpecReporter(sink Sink, runID string, customTags map[string]interface{}) *SpecReporter {
+	return &SpecReporter{sink: sink, runID: runID, customTags: customTags}
+}
+
+// ReportSpec is meant to be called from a ReportAfterEach node.
+func (r *SpecReporter) ReportSpec(report types.SpecReport) error {
+	return r.sink.Send(NewSpecEvent(report, r.runID, r.customTags))
+}
+
+func (r *SpecReporter) Close() error {
+	return r.sink.Close()
+}
@@ -0,0 +1,174 @@
+package reporters_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cloudfoundry/capi-bara-tests/helpers/config"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/reporters"
+
+	. "github.com/onsi/ginkgo/v2"
+	"github.com/onsi/ginkgo/v2/types"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Reporters", func() {
+	var (
+		passed types.SpecReport
+		failed types.SpecReport
+	)
+
+	BeforeEach(func() {
+		passed = types.SpecReport{
+			ContainerHierarchyTexts: []string{"[sidecars] sidecars"},
+			LeafNodeText:            "starts the sidecar",
+			LeafNodeLabels:          []string{"sidecars"},
+			State:                   types.SpecStatePassed,
+			RunTime:                 1500 * time.Millisecond,
+			ParallelProcess:         2,
+		}
+
+		failed = passed
+		failed.State = types.SpecStateFailed
+		failed.Failure = types.Failure{
+			Message:  "Expected true to be false",
+			Location: types.CodeLocation{FileName: "baras/sidecars.go", LineNumber: 42},
+		}
+	})
+
+	Describe("NewSpecEvent", func() {
+		It("describes a passed spec with the custom tags flattened in", func() {
+			event := reporters.NewSpecEvent(passed, "some-run", map[string]interface{}{"env": "staging"})
+
+			Expect(event).To(Equal(reporters.Event{
+				"env":              "staging",
+				"name":             "[sidecars] sidecars starts the sidecar",
+				"state":            "passed",
+				"duration_seconds": 1.5,
+				"parallel_process": 2,
+				"run_id":           "some-run",
+				"labels":           []string{"sidecars"},
+			}))
+		})
+
+		It("includes the failure location and message of a failed spec", func() {
+			event := reporters.NewSpecEvent(failed, "", nil)
+
+			Expect(event).To(HaveKeyWithValue("state", "failed"))
+			Expect(event).To(HaveKeyWithValue("failure_location", "baras/sidecars.go:42"))
+			Expect(event).To(HaveKeyWithValue("failure_message", "Expected true to be false"))
+			Expect(event).NotTo(HaveKey("run_id"))
+		})
+	})
+
+	Describe("HoneycombSink", func() {
+		var (
+			server   *httptest.Server
+			requests chan *http.Request
+			bodies   chan map[string]interface{}
+			status   int
+		)
+
+		BeforeEach(func() {
+			requests = make(chan *http.Request, 1)
+			bodies = make(chan map[string]interface{}, 1)
+			status = http.StatusOK
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var body map[string]interface{}
+				Expect(json.NewDecoder(r.Body).Decode(&body)).To(Succeed())
+				requests <- r
+				bodies <- body
+				w.WriteHeader(status)
+				io.WriteString(w, `{"error":"unknown API key"}`)
+			}))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("posts each event to the dataset with the write key", func() {
+			reporter := reporters.NewSpecReporter(reporters.NewHoneycombSink(server.URL, "some-write-key", "bara tests"), "some-run", nil)
+			Expect(reporter.ReportSpec(failed)).To(Succeed())
+
+			request := <-requests
+			Expect(request.Method).To(Equal(http.MethodPost))
+			Expect(request.URL.EscapedPath()).To(Equal("/1/events/bara%20tests"))
+			Expect(request.Header.Get("X-Honeycomb-Team")).To(Equal("some-write-key"))
+
+			body := <-bodies
+			Expect(body).To(HaveKeyWithValue("name", "[sidecars] sidecars starts the sidecar"))
+			Expect(body).To(HaveKeyWithValue("failure_message", "Expected true to be false"))
+			Expect(body).To(HaveKeyWithValue("run_id", "some-run"))
+		})
+
+		It("returns an error when the event is rejected", func() {
+			status = http.StatusUnauthorized
+			sink := reporters.NewHoneycombSink(server.URL, "wrong-key", "bara")
+
+			Expect(sink.Send(reporters.Event{"name": "spec"})).To(MatchError(`honeycomb returned 401: {"error":"unknown API key"}`))
+		})
+	})
+
+	Describe("NewSinkFromConfig", func() {
+		var artifactsDirectory string
+
+		BeforeEach(func() {
+			artifactsDirectory = filepath.Join(GinkgoT().TempDir(), "results")
+		})
+
+		newConfig := func(reporterConfig map[string]interface{}) config.BaraConfig {
+			cfg, err := config.NewLocalConfig("http://127.0.0.1:4567", "", map[string]interface{}{
+				"artifacts_directory": artifactsDirectory,
+				"reporter_config":     reporterConfig,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			return cfg
+		}
+
+		It("returns no sink when nothing is configured", func() {
+			sink, err := reporters.NewSinkFromConfig(newConfig(map[string]interface{}{}), 1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sink).To(BeNil())
+		})
+
+		It("defaults to honeycomb when a write key and dataset are set", func() {
+			sink, err := reporters.NewSinkFromConfig(newConfig(map[string]interface{}{
+				"honeycomb_write_key": "some-write-key",
+				"honeycomb_dataset":   "some-dataset",
+			}), 1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sink).To(BeAssignableToTypeOf(&reporters.HoneycombSink{}))
+		})
+
+		It("writes one JSON line per event under the artifacts directory", func() {
+			sink, err := reporters.NewSinkFromConfig(newConfig(map[string]interface{}{"sink": "jsonl"}), 3)
+			Expect(err).NotTo(HaveOccurred())
+
+			reporter := reporters.NewSpecReporter(sink, "some-run", map[string]interface{}{"env": "staging"})
+			Expect(reporter.ReportSpec(passed)).To(Succeed())
+			Expect(reporter.ReportSpec(failed)).To(Succeed())
+			Expect(reporter.Close()).To(Succeed())
+
+			file, err := os.Open(filepath.Join(artifactsDirectory, "events-BARA-3.jsonl"))
+			Expect(err).NotTo(HaveOccurred())
+			defer file.Close()
+
+			var states []string
+			scanner := bufio.NewScanner(file)
+			for scanner.Scan() {
+				var event map[string]interface{}
+				Expect(json.Unmarshal(scanner.Bytes(), &event)).To(Succeed())
+				Expect(event).To(HaveKeyWithValue("env", "staging"))
+				states = append(states, event["state"].(string))
+			}
+			Expect(states).To(Equal([]string{"passed", "failed"}))
+		})
+	})
+})
@@ -0,0 +1,136 @@
+package reporters
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry/capi-bara-tests/helpers/config"
+)
+
+const DefaultHoneycombAPIHost = "https://api.honeycomb.io"
+
+// Sink receives spec events.
+type Sink interface {
+	Send(Event) error
+	Close() error
+}
+
+// NewSinkFromConfig returns the sink selected by reporter_config, or nil if
+// no sink is configured. JSONL files are written to the artifacts directory,
+// one per parallel process.
+func NewSinkFromConfig(cfg config.BaraConfig, parallelProcess int) (Sink, error) {
+	reporterConfig := cfg.GetReporterConfig()
+	honeycombConfigured := reporterConfig.HoneyCombWriteKey != "" && reporterConfig.HoneyCombDataset != ""
+
+	switch reporterConfig.Sink {
+	case "honeycomb":
+		return NewHoneycombSink(reporterConfig.HoneyCombAPIHost, reporterConfig.HoneyCombWriteKey, reporterConfig.HoneyCombDataset), nil
+	case "jsonl":
+		path := filepath.Join(cfg.GetArtifactsDirectory(), fmt.Sprintf("events-%s-%d.jsonl", "BARA", parallelProcess))
+		return NewJSONLSink(path)
+	case "stdout":
+		return NewWriterSink(os.Stdout), nil
+	case "":
+		if honeycombConfigured {
+			return NewHoneycombSink(reporterConfig.HoneyCombAPIHost, reporterConfig.HoneyCombWriteKey, reporterConfig.HoneyCombDataset), nil
+		}
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unknown reporter sink '%s'", reporterConfig.Sink)
+}
+
+// HoneycombSink posts each event to the Honeycomb events API, or anything
+// speaking it.
+type HoneycombSink struct {
+	eventsURL string
+	writeKey  string
+	client    *http.Client
+}
+
+func NewHoneycombSink(apiHost, writeKey, dataset string) *HoneycombSink {
+	if apiHost == "" {
+		apiHost = DefaultHoneycombAPIHost
+	}
+	return &HoneycombSink{
+		eventsURL: strings.TrimSuffix(apiHost, "/") + "/1/events/" + url.PathEscape(dataset),
+		writeKey:  writeKey,
+		client:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (s *HoneycombSink) Send(event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequest(http.MethodPost, s.eventsURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("X-Honeycomb-Team", s.writeKey)
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode/100 != 2 {
+		responseBody, _ := io.ReadAll(response.Body)
+		return fmt.Errorf("honeycomb returned %d: %s", response.StatusCode, strings.TrimSpace(string(responseBody)))
+	}
+	return nil
+}
+
+func (s *HoneycombSink) Close() error {
+	return nil
+}
+
+// WriterSink writes each event as a line of JSON.
+type WriterSink struct {
+	mutex   sync.Mutex
+	encoder *json.Encoder
+	closer  io.Closer
+}
+
+// NewWriterSink writes to w, which is left open on Close.
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{encoder: json.NewEncoder(w)}
+}
+
+// NewJSONLSink appends to the file at path, creating it and its directory
+// if needed.
+func NewJSONLSink(path string) (*WriterSink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &WriterSink{encoder: json.NewEncoder(file), closer: file}, nil
+}
+
+func (s *WriterSink) Send(event Event) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.encoder.Encode(event)
+}
+
+func (s *WriterSink) Close() error {
+	if s.closer == nil {
+		return nil
+	}
+	return s.closer.Close()
+}
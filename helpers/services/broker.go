@@ -2,9 +2,6 @@ package services
 
 import (
 	"encoding/json"
-	"fmt"
-	"io/ioutil"
-	"strings"
 
 	. "github.com/onsi/gomega"
 	. "github.com/onsi/gomega/gbytes"
@@ -13,35 +10,22 @@ import (
 	"github.com/cloudfoundry/cf-test-helpers/v2/cf"
 	"github.com/cloudfoundry/cf-test-helpers/v2/helpers"
 	"github.com/cloudfoundry/cf-test-helpers/v2/workflowhelpers"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/broker"
 	bara_config "github.com/cloudfoundry/capi-bara-tests/helpers/config"
 	"github.com/cloudfoundry/capi-bara-tests/helpers/v3_helpers"
 
 	. "github.com/cloudfoundry/capi-bara-tests/bara_suite_helpers"
-	"github.com/cloudfoundry/capi-bara-tests/helpers/assets"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/cleanup"
 	"github.com/cloudfoundry/capi-bara-tests/helpers/random_name"
 )
 
-type Plan struct {
-	Name    string      `json:"name"`
-	ID      string      `json:"id"`
-	Schemas PlanSchemas `json:"schemas"`
-}
+// The credentials the pushed broker is started with and registered with.
+const (
+	brokerUsername = "username"
+	brokerPassword = "password"
+)
 
-type PlanSchemas struct {
-	ServiceInstance struct {
-		Create struct {
-			Parameters map[string]interface{} `json:"parameters"`
-		} `json:"create"`
-		Update struct {
-			Parameters map[string]interface{} `json:"parameters"`
-		} `json:"update"`
-	} `json:"service_instance"`
-	ServiceBinding struct {
-		Create struct {
-			Parameters map[string]interface{} `json:"parameters"`
-		} `json:"create"`
-	} `json:"service_binding"`
-}
+type Plan = broker.Plan
 
 type ServiceBroker struct {
 	Name       string
@@ -50,8 +34,13 @@ type ServiceBroker struct {
 	Path       string
 	TestSetup  *workflowhelpers.ReproducibleTestSuiteSetup
 	Service    struct {
-		Name            string `json:"name"`
-		ID              string `json:"id"`
+		Name string `json:"name"`
+		ID   string `json:"id"`
+		// Requires is sent in the catalog. Cloud Controller only accepts
+		// the volume mounts, route service URLs and syslog drain URLs of
+		// Behaviours from a service requiring "volume_mount",
+		// "route_forwarding" or "syslog_drain" respectively.
+		Requires        []string `json:"requires"`
 		DashboardClient struct {
 			ID          string `json:"id"`
 			Secret      string `json:"secret"`
@@ -60,51 +49,9 @@ type ServiceBroker struct {
 	}
 	SyncPlans  []Plan
 	AsyncPlans []Plan
-}
-
-type ServicesResponse struct {
-	Resources []ServiceResponse
-}
-
-type ServiceResponse struct {
-	Entity struct {
-		Label        string
-		ServicePlans []ServicePlanResponse `json:"service_plans"`
-	}
-}
-
-type ServicePlansResponse struct {
-	Resources []ServicePlanResponse
-}
-
-type ServicePlanResponse struct {
-	Entity struct {
-		Name    string
-		Public  bool
-		Schemas PlanSchemas
-	}
-	Metadata struct {
-		Url  string
-		Guid string
-	}
-}
-
-type ServiceInstance struct {
-	Metadata struct {
-		Guid string `json:"guid"`
-	}
-}
-
-type ServiceInstanceResponse struct {
-	Resources []ServiceInstance
-}
-
-type SpaceJson struct {
-	Resources []struct {
-		Metadata struct {
-			Guid string
-		}
-	}
+	// Behaviours are keyed by plan ID and sent to the broker by Configure.
+	// The asynchronous plans start out with Async set.
+	Behaviours map[string]broker.Behaviour
 }
 
 func NewServiceBroker(name, spaceGUID, domainGUID, path string, TestSetup *workflowhelpers.ReproducibleTestSuiteSetup) ServiceBroker {
@@ -116,15 +63,13 @@ func NewServiceBroker(name, spaceGUID, domainGUID, path string, TestSetup *workf
 	b.Service.Name = random_name.BARARandomName("SVC")
 	b.Service.ID = random_name.BARARandomName("SVC-ID")
 
-	b.SyncPlans = []Plan{
-		{Name: random_name.BARARandomName("SVC-PLAN"), ID: random_name.BARARandomName("SVC-PLAN-ID")},
-		{Name: random_name.BARARandomName("SVC-PLAN"), ID: random_name.BARARandomName("SVC-PLAN-ID")},
-	}
-	b.AsyncPlans = []Plan{
-		{Name: random_name.BARARandomName("SVC-PLAN"), ID: random_name.BARARandomName("SVC-PLAN-ID")},
-		{Name: random_name.BARARandomName("SVC-PLAN"), ID: random_name.BARARandomName("SVC-PLAN-ID")},
-		{Name: random_name.BARARandomName("SVC-PLAN"), ID: random_name.BARARandomName("SVC-PLAN-ID")},
+	b.SyncPlans = []Plan{newPlan("Shared fake server"), newPlan("Shared fake server")}
+	b.AsyncPlans = []Plan{newPlan("Asynchronous fake server"), newPlan("Asynchronous fake server"), newPlan("Asynchronous fake server")}
+	b.Behaviours = map[string]broker.Behaviour{}
+	for _, plan := range b.AsyncPlans {
+		b.Behaviours[plan.ID] = broker.Behaviour{Async: true}
 	}
+
 	b.Service.DashboardClient.ID = random_name.BARARandomName("DASHBOARD-ID")
 	b.Service.DashboardClient.Secret = random_name.BARARandomName("DASHBOARD-SECRET")
 	b.Service.DashboardClient.RedirectUri = random_name.BARARandomName("DASHBOARD-URI")
@@ -132,20 +77,105 @@ func NewServiceBroker(name, spaceGUID, domainGUID, path string, TestSetup *workf
 	return b
 }
 
+func newPlan(description string) Plan {
+	return Plan{
+		Name:        random_name.BARARandomName("SVC-PLAN"),
+		ID:          random_name.BARARandomName("SVC-PLAN-ID"),
+		Description: description,
+	}
+}
+
+// Push deploys the Go broker built from assets/service-broker, which
+// serves an empty catalog until Configure.
 func (b ServiceBroker) Push(config bara_config.BaraConfig) {
-	appGUID := v3_helpers.CreateApp(b.Name, b.SpaceGUID, `{}`)
+	env, err := json.Marshal(map[string]string{"BROKER_USERNAME": brokerUsername, "BROKER_PASSWORD": brokerPassword})
+	Expect(err).NotTo(HaveOccurred())
+
+	appGUID := v3_helpers.CreateApp(b.Name, b.SpaceGUID, string(env))
 	v3_helpers.CreateAndMapRoute(appGUID, b.SpaceGUID, b.DomainGUID, b.Name)
-	Expect(cf.Cf("set-health-check", b.Name, "http", "--endpoint", "/v2/catalog").Wait(Config.BrokerStartTimeoutDuration())).To(Exit(0))
 	Expect(cf.Cf(
 		"push", b.Name,
-		"-b", config.GetRubyBuildpackName(),
+		"-b", config.GetBinaryBuildpackName(),
 		"-m", DEFAULT_MEMORY_LIMIT,
 		"-p", b.Path,
 	).Wait(Config.BrokerStartTimeoutDuration())).To(Exit(0))
 }
 
+// Configure sends the broker its catalog and behaviours.
 func (b ServiceBroker) Configure() {
-	Expect(helpers.Curl(Config, helpers.AppUri(b.Name, "/config", Config), "-d", b.ToJSON()).Wait()).To(Exit(0))
+	config, err := json.Marshal(b.Config())
+	Expect(err).NotTo(HaveOccurred())
+
+	Expect(helpers.Curl(Config,
+		"--fail",
+		"-X", "PUT",
+		"-u", brokerUsername+":"+brokerPassword,
+		"-H", "Content-Type: application/json",
+		helpers.AppUri(b.Name, "/config", Config),
+		"-d", string(config),
+	).Wait()).To(Exit(0))
+}
+
+func (b ServiceBroker) Config() broker.Config {
+	return broker.Config{
+		Catalog: broker.Catalog{Services: []broker.Service{{
+			ID:                   b.Service.ID,
+			Name:                 b.Service.Name,
+			Description:          "fake service",
+			Tags:                 []string{"no-sql", "relational"},
+			Requires:             b.Service.Requires,
+			Bindable:             true,
+			InstancesRetrievable: true,
+			BindingsRetrievable:  true,
+			PlanUpdateable:       true,
+			Metadata: map[string]interface{}{
+				"displayName": "The Fake Broker",
+				"shareable":   true,
+			},
+			DashboardClient: &broker.DashboardClient{
+				ID:          b.Service.DashboardClient.ID,
+				Secret:      b.Service.DashboardClient.Secret,
+				RedirectURI: b.Service.DashboardClient.RedirectUri,
+			},
+			Plans: b.Plans(),
+		}}},
+		Behaviours: b.Behaviours,
+	}
+}
+
+// SetMaintenanceInfoVersion changes the version of the plan in the broker's
+// catalog and has Cloud Controller fetch the catalog again, after which
+// instances at another version have an upgrade available.
+func (b *ServiceBroker) SetMaintenanceInfoVersion(planID, version string) {
+	plan := b.plan(planID)
+	Expect(plan).NotTo(BeNil(), "broker %s has no plan %s", b.Name, planID)
+	plan.MaintenanceInfo = &broker.MaintenanceInfo{Version: version}
+
+	b.Configure()
+	b.Update()
+}
+
+// Requests returns the journal of requests the broker has received.
+func (b ServiceBroker) Requests() broker.Journal {
+	session := helpers.Curl(Config,
+		"--fail",
+		"-u", brokerUsername+":"+brokerPassword,
+		helpers.AppUri(b.Name, "/requests", Config),
+	).Wait()
+	Expect(session).To(Exit(0))
+
+	var journal broker.Journal
+	Expect(json.Unmarshal(session.Out.Contents(), &journal)).To(Succeed())
+	return journal
+}
+
+func (b ServiceBroker) ClearRequests() {
+	Expect(helpers.Curl(Config,
+		"--fail",
+		"-X", "DELETE",
+		"-u", brokerUsername+":"+brokerPassword,
+		helpers.AppUri(b.Name, "/requests", Config),
+	).Wait()).To(Exit(0))
 }
 
 func (b ServiceBroker) Restart() {
@@ -154,21 +184,23 @@ func (b ServiceBroker) Restart() {
 
 func (b ServiceBroker) Create() {
 	workflowhelpers.AsUser(b.TestSetup.AdminUserContext(), Config.DefaultTimeoutDuration(), func() {
-		Expect(cf.Cf("create-service-broker", b.Name, "username", "password", helpers.AppUri(b.Name, "", Config)).Wait()).To(Exit(0))
+		cleanup.Track(cleanup.ServiceBroker(b.Name))
+		Expect(cf.Cf("create-service-broker", b.Name, brokerUsername, brokerPassword, helpers.AppUri(b.Name, "", Config)).Wait()).To(Exit(0))
 		Expect(cf.Cf("service-brokers").Wait()).To(Say(b.Name))
 	})
 }
 
 func (b ServiceBroker) CreateSpaceScoped() {
 	workflowhelpers.AsUser(b.TestSetup.RegularUserContext(), Config.DefaultTimeoutDuration(), func() {
-		Expect(cf.Cf("create-service-broker", b.Name, "username", "password", helpers.AppUri(b.Name, "", Config), "--space-scoped").Wait()).To(Exit(0))
+		cleanup.Track(cleanup.ServiceBroker(b.Name))
+		Expect(cf.Cf("create-service-broker", b.Name, brokerUsername, brokerPassword, helpers.AppUri(b.Name, "", Config), "--space-scoped").Wait()).To(Exit(0))
 		Expect(cf.Cf("service-brokers").Wait()).To(Say(b.Name))
 	})
 }
 
 func (b ServiceBroker) Update() {
 	workflowhelpers.AsUser(b.TestSetup.AdminUserContext(), Config.DefaultTimeoutDuration(), func() {
-		Expect(cf.Cf("update-service-broker", b.Name, "username", "password", helpers.AppUri(b.Name, "", Config)).Wait()).To(Exit(0))
+		Expect(cf.Cf("update-service-broker", b.Name, brokerUsername, brokerPassword, helpers.AppUri(b.Name, "", Config)).Wait()).To(Exit(0))
 	})
 }
 
@@ -180,6 +212,7 @@ func (b ServiceBroker) Delete() {
 		Expect(brokers).To(Exit(0))
 		Expect(brokers.Out.Contents()).ToNot(ContainSubstring(b.Name))
 	})
+	cleanup.Forget(cleanup.ServiceBroker(b.Name))
 }
 
 func (b ServiceBroker) Destroy() {
@@ -190,54 +223,16 @@ func (b ServiceBroker) Destroy() {
 	Expect(cf.Cf("delete", b.Name, "-f", "-r").Wait()).To(Exit(0))
 }
 
-func (b ServiceBroker) ToJSON() string {
-	bytes, err := ioutil.ReadFile(assets.NewAssets().ServiceBroker + "/cats.json")
-	Expect(err).To(BeNil())
-
-	planSchema, err := json.Marshal(b.SyncPlans[0].Schemas)
-	Expect(err).To(BeNil())
-
-	replacer := strings.NewReplacer(
-		"<fake-service>", b.Service.Name,
-		"<fake-service-guid>", b.Service.ID,
-		"<sso-test>", b.Service.DashboardClient.ID,
-		"<sso-secret>", b.Service.DashboardClient.Secret,
-		"<sso-redirect-uri>", b.Service.DashboardClient.RedirectUri,
-		"<fake-plan>", b.SyncPlans[0].Name,
-		"<fake-plan-guid>", b.SyncPlans[0].ID,
-		"<fake-plan-2>", b.SyncPlans[1].Name,
-		"<fake-plan-2-guid>", b.SyncPlans[1].ID,
-		"<fake-async-plan>", b.AsyncPlans[0].Name,
-		"<fake-async-plan-guid>", b.AsyncPlans[0].ID,
-		"<fake-async-plan-2>", b.AsyncPlans[1].Name,
-		"<fake-async-plan-2-guid>", b.AsyncPlans[1].ID,
-		"<fake-async-plan-3>", b.AsyncPlans[2].Name,
-		"<fake-async-plan-3-guid>", b.AsyncPlans[2].ID,
-		"\"<fake-plan-schema>\"", string(planSchema),
-	)
-
-	return replacer.Replace(string(bytes))
-}
-
+// PublicizePlans makes every plan of the broker's offering public.
 func (b ServiceBroker) PublicizePlans() {
-	url := fmt.Sprintf("/v2/services?inline-relations-depth=1&q=label:%s", b.Service.Name)
-	var session *Session
 	workflowhelpers.AsUser(b.TestSetup.AdminUserContext(), Config.DefaultTimeoutDuration(), func() {
-		session = cf.Cf("curl", url).Wait()
-		Expect(session).To(Exit(0))
-	})
-	structure := ServicesResponse{}
-	json.Unmarshal(session.Out.Contents(), &structure)
-
-	for _, service := range structure.Resources {
-		if service.Entity.Label == b.Service.Name {
-			for _, plan := range service.Entity.ServicePlans {
-				if b.HasPlan(plan.Entity.Name) {
-					b.PublicizePlan(plan.Metadata.Url)
-				}
+		offering := v3_helpers.GetServiceOfferingByName(b.Service.Name)
+		for _, plan := range v3_helpers.GetServicePlans(offering.GUID) {
+			if b.HasPlan(plan.Name) {
+				v3_helpers.MakeServicePlanPublic(plan.GUID)
 			}
 		}
-	}
+	})
 }
 
 func (b ServiceBroker) HasPlan(planName string) bool {
@@ -249,23 +244,16 @@ func (b ServiceBroker) HasPlan(planName string) bool {
 	return false
 }
 
-func (b ServiceBroker) PublicizePlan(url string) {
-	jsonMap := make(map[string]bool)
-	jsonMap["public"] = true
-	planJson, _ := json.Marshal(jsonMap)
-	workflowhelpers.AsUser(b.TestSetup.AdminUserContext(), Config.DefaultTimeoutDuration(), func() {
-		Expect(cf.Cf("curl", url, "-X", "PUT", "-d", string(planJson)).Wait()).To(Exit(0))
-	})
-}
-
-func (b ServiceBroker) CreateServiceInstance(instanceName string) string {
-	Expect(cf.Cf("create-service", b.Service.Name, b.SyncPlans[0].Name, instanceName).Wait()).To(Exit(0))
-	url := fmt.Sprintf("/v2/service_instances?q=name:%s", instanceName)
-	serviceInstance := ServiceInstanceResponse{}
-	curl := cf.Cf("curl", url).Wait()
-	Expect(curl).To(Exit(0))
-	json.Unmarshal(curl.Out.Contents(), &serviceInstance)
-	return serviceInstance.Resources[0].Metadata.Guid
+// CreateServiceInstance provisions an instance of the first synchronous
+// plan in the space and returns its GUID.
+func (b ServiceBroker) CreateServiceInstance(instanceName, spaceGUID string) string {
+	offering := v3_helpers.GetServiceOfferingByName(b.Service.Name)
+	plan := v3_helpers.GetServicePlanByName(offering.GUID, b.SyncPlans[0].Name)
+	return v3_helpers.CreateManagedServiceInstance(v3_helpers.ManagedServiceInstanceOptions{
+		Name:            instanceName,
+		SpaceGUID:       spaceGUID,
+		ServicePlanGUID: plan.GUID,
+	}).GUID
 }
 
 func (b ServiceBroker) Plans() []Plan {
@@ -274,3 +262,16 @@ func (b ServiceBroker) Plans() []Plan {
 	plans = append(plans, b.AsyncPlans...)
 	return plans
 }
+
+// plan points into SyncPlans or AsyncPlans, so changes to it are sent to
+// the broker by the next Configure.
+func (b *ServiceBroker) plan(planID string) *Plan {
+	for _, plans := range [][]Plan{b.SyncPlans, b.AsyncPlans} {
+		for i := range plans {
+			if plans[i].ID == planID {
+				return &plans[i]
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+// Package sweeper deletes the orgs, spaces, quotas, service brokers and
+// buildpacks that aborted runs leave behind, recognised by the name prefix
+// of the config they ran with.
+package sweeper
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry/capi-bara-tests/helpers/capi_client"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/cleanup"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/random_name"
+)
+
+type Sweeper struct {
+	Client *capi_client.Client
+
+	// Prefix is the config's name prefix. Only resources named
+	// "<Prefix>-..." are swept.
+	Prefix string
+	// MinAge spares resources created less than MinAge ago, which may
+	// belong to a run still in progress.
+	MinAge time.Duration
+	// DryRun lists what would be deleted without deleting anything.
+	DryRun bool
+
+	JobTimeout         time.Duration
+	JobPollingInterval time.Duration
+
+	// Now defaults to time.Now.
+	Now func() time.Time
+}
+
+type Candidate struct {
+	Kind      string
+	Name      string
+	GUID      string
+	CreatedAt time.Time
+	// Origin is decoded from the name, or nil if the resource was not named
+	// by random_name, e.g. orgs and spaces named by cf-test-helpers.
+	Origin *random_name.Name
+
+	// Err is why the candidate could not be deleted.
+	Err error
+}
+
+type Report struct {
+	DryRun bool
+	// Candidates are listed in the order they were, or would be, deleted.
+	Candidates []Candidate
+}
+
+// kind describes how to find and delete one type of resource. Kinds are
+// swept in order: brokers first so their service instances are purged
+// before their orgs are deleted, and quotas after the orgs and spaces they
+// are applied to.
+type kind struct {
+	name   string
+	path   string
+	delete func(*capi_client.Client, Candidate) (string, error)
+}
+
+var kinds = []kind{
+	{"service broker", "/v3/service_brokers", func(client *capi_client.Client, candidate Candidate) (string, error) {
+		return cleanup.ServiceBroker(candidate.Name).Delete(client)
+	}},
+	{"space", "/v3/spaces", func(client *capi_client.Client, candidate Candidate) (string, error) {
+		return client.DeleteSpace(candidate.GUID)
+	}},
+	{"organization", "/v3/organizations", func(client *capi_client.Client, candidate Candidate) (string, error) {
+		return client.DeleteOrganization(candidate.GUID)
+	}},
+	{"space quota", "/v3/space_quotas", func(client *capi_client.Client, candidate Candidate) (string, error) {
+		return client.DeleteSpaceQuota(candidate.GUID)
+	}},
+	{"organization quota", "/v3/organization_quotas", func(client *capi_client.Client, candidate Candidate) (string, error) {
+		return client.DeleteOrgQuota(candidate.GUID)
+	}},
+	{"buildpack", "/v3/buildpacks", func(client *capi_client.Client, candidate Candidate) (string, error) {
+		return client.DeleteBuildpack(candidate.GUID)
+	}},
+}
+
+type namedResource struct {
+	capi_client.Resource
+	Name string `json:"name"`
+}
+
+// Sweep deletes every matching resource, one kind at a time. A resource
+// that cannot be deleted is recorded in the report and the sweep carries
+// on; only failing to list resources stops it.
+func (s Sweeper) Sweep() (Report, error) {
+	report := Report{DryRun: s.DryRun}
+
+	for _, k := range kinds {
+		resources, err := capi_client.List[namedResource](s.Client, k.path, capi_client.ListOptions{})
+		if err != nil {
+			return report, fmt.Errorf("listing %ss: %s", k.name, err)
+		}
+
+		for _, resource := range resources {
+			candidate := Candidate{Kind: k.name, Name: resource.Name, GUID: resource.GUID, CreatedAt: resource.CreatedAt}
+			if !s.matches(candidate) {
+				continue
+			}
+			if origin, err := random_name.Parse(s.Prefix, candidate.Name); err == nil {
+				candidate.Origin = &origin
+			}
+
+			if !s.DryRun {
+				candidate.Err = s.delete(k, candidate)
+			}
+			report.Candidates = append(report.Candidates, candidate)
+		}
+	}
+
+	return report, nil
+}
+
+func (s Sweeper) matches(candidate Candidate) bool {
+	now := time.Now
+	if s.Now != nil {
+		now = s.Now
+	}
+	return strings.HasPrefix(candidate.Name, s.Prefix+"-") && now().Sub(candidate.CreatedAt) >= s.MinAge
+}
+
+func (s Sweeper) delete(k kind, candidate Candidate) error {
+	jobPath, err := k.delete(s.Client, candidate)
+	if capi_client.IsNotFound(err) {
+		return nil
+	}
+	if err != nil || jobPath == "" {
+		return err
+	}
+
+	_, err = s.Client.WaitForJob(jobPath, capi_client.JobStateComplete, s.JobTimeout, s.JobPollingInterval)
+	return err
+}
+
+func (r Report) Failed() int {
+	failed := 0
+	for _, candidate := range r.Candidates {
+		if candidate.Err != nil {
+			failed++
+		}
+	}
+	return failed
+}
+
+// WriteSummary writes one line per candidate followed by the totals.
+func (r Report) WriteSummary(w io.Writer) {
+	for _, candidate := range r.Candidates {
+		outcome := "deleted"
+		if r.DryRun {
+			outcome = "would delete"
+		} else if candidate.Err != nil {
+			outcome = "FAILED: " + candidate.Err.Error()
+		}
+		origin := ""
+		if candidate.Origin != nil {
+			origin = fmt.Sprintf(", run %s, process %d, spec %s", candidate.Origin.Run, candidate.Origin.Node, candidate.Origin.Spec)
+		}
+		fmt.Fprintf(w, "%-18s %s (%s, created %s%s): %s\n",
+			candidate.Kind, candidate.Name, candidate.GUID, candidate.CreatedAt.Format(time.RFC3339), origin, outcome)
+	}
+
+	if r.DryRun {
+		fmt.Fprintf(w, "%d resource(s) would be deleted (dry run)\n", len(r.Candidates))
+		return
+	}
+	fmt.Fprintf(w, "%d resource(s) deleted, %d failed\n", len(r.Candidates)-r.Failed(), r.Failed())
+}
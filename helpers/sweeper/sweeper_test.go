@@ -0,0 +1,111 @@
+package sweeper_test
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/cloudfoundry/capi-bara-tests/helpers/fake_cc"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/sweeper"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Sweeper", func() {
+	var (
+		fakeCC *fake_cc.FakeCC
+		now    time.Time
+		old    time.Time
+		s      sweeper.Sweeper
+	)
+
+	BeforeEach(func() {
+		fakeCC = fake_cc.New()
+		fakeCC.PollsPerTransition = 0
+
+		now = time.Date(2021, time.June, 1, 12, 0, 0, 0, time.UTC)
+		old = now.Add(-48 * time.Hour)
+		s = sweeper.Sweeper{
+			Client:             fakeCC.Client(),
+			Prefix:             "BARA",
+			MinAge:             24 * time.Hour,
+			JobTimeout:         time.Second,
+			JobPollingInterval: time.Millisecond,
+			Now:                func() time.Time { return now },
+		}
+
+		fakeCC.AddNamed("organizations", "BARA-1-ORG-old", old)
+		fakeCC.AddNamed("organizations", "BARA-1-ORG-recent", now.Add(-time.Hour))
+		fakeCC.AddNamed("organizations", "system", old)
+		fakeCC.AddNamed("organizations", "BARAORG", old)
+		fakeCC.AddNamed("spaces", "BARA-1-SPACE-old", old)
+		fakeCC.AddNamed("organization_quotas", "BARA-ORG-QUOTA-old", old)
+		fakeCC.AddNamed("space_quotas", "BARA-SPACE-QUOTA-old", old)
+		fakeCC.AddNamed("service_brokers", "BARA-BRKR-old", old)
+		fakeCC.AddNamed("buildpacks", "BARA-2-sleepy-sidecar-buildpack-1f3a9c0e-5d41402a-z", old)
+	})
+
+	AfterEach(func() {
+		fakeCC.Close()
+	})
+
+	It("deletes old resources named with the prefix, brokers first and quotas last", func() {
+		report, err := s.Sweep()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.Failed()).To(Equal(0))
+
+		var swept []string
+		for _, candidate := range report.Candidates {
+			swept = append(swept, candidate.Kind+" "+candidate.Name)
+		}
+		Expect(swept).To(Equal([]string{
+			"service broker BARA-BRKR-old",
+			"space BARA-1-SPACE-old",
+			"organization BARA-1-ORG-old",
+			"space quota BARA-SPACE-QUOTA-old",
+			"organization quota BARA-ORG-QUOTA-old",
+			"buildpack BARA-2-sleepy-sidecar-buildpack-1f3a9c0e-5d41402a-z",
+		}))
+
+		Expect(fakeCC.Named("organizations")).To(ConsistOf("BARA-1-ORG-recent", "system", "BARAORG"))
+		Expect(fakeCC.Named("spaces")).To(BeEmpty())
+		Expect(fakeCC.Named("organization_quotas")).To(BeEmpty())
+		Expect(fakeCC.Named("space_quotas")).To(BeEmpty())
+		Expect(fakeCC.Named("service_brokers")).To(BeEmpty())
+		Expect(fakeCC.Named("buildpacks")).To(BeEmpty())
+
+		summary := &bytes.Buffer{}
+		report.WriteSummary(summary)
+		Expect(summary.String()).To(ContainSubstring("organization       BARA-1-ORG-old ("))
+		Expect(summary.String()).To(ContainSubstring(", run 1f3a9c0e, process 2, spec 5d41402a): deleted"))
+
+		Expect(report.Candidates[0].Origin).To(BeNil())
+		Expect(report.Candidates[5].Origin.Kind).To(Equal("sleepy-sidecar-buildpack"))
+		Expect(summary.String()).To(HaveSuffix("6 resource(s) deleted, 0 failed\n"))
+	})
+
+	It("only reports what it would delete in a dry run", func() {
+		s.DryRun = true
+
+		report, err := s.Sweep()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.Candidates).To(HaveLen(6))
+		Expect(fakeCC.Named("organizations")).To(HaveLen(4))
+		Expect(fakeCC.Named("buildpacks")).To(HaveLen(1))
+
+		summary := &bytes.Buffer{}
+		report.WriteSummary(summary)
+		Expect(summary.String()).To(ContainSubstring("BARA-2-sleepy-sidecar-buildpack-1f3a9c0e-5d41402a-z"))
+		Expect(summary.String()).To(ContainSubstring("would delete"))
+		Expect(summary.String()).To(HaveSuffix("6 resource(s) would be deleted (dry run)\n"))
+	})
+
+	It("sweeps recent resources when the minimum age allows it", func() {
+		s.MinAge = 0
+
+		report, err := s.Sweep()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(report.Candidates).To(HaveLen(7))
+		Expect(fakeCC.Named("organizations")).To(ConsistOf("system", "BARAORG"))
+	})
+})
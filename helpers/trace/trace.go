@@ -0,0 +1,137 @@
+// Package trace manages per-spec CF_TRACE files: naming them, redacting
+// credentials from them and pulling out the last few API exchanges for
+// failure reports.
+package trace
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const Hidden = "[PRIVATE DATA HIDDEN]"
+
+// maxSlugLength keeps trace file names well under filesystem limits; a hash
+// of the full spec text keeps truncated names unique.
+const maxSlugLength = 100
+
+var redactions = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`(?im)^(authorization:[ \t]*).+$`), "${1}" + Hidden},
+	{regexp.MustCompile(`(?i)("(?:access_token|refresh_token|id_token|token|password|client_secret)"\s*:\s*)"[^"]*"`), `${1}"` + Hidden + `"`},
+	{regexp.MustCompile(`(?i)\b(bearer)\s+[\w\-.=]+`), "${1} " + Hidden},
+}
+
+var nonSlugCharacters = regexp.MustCompile(`[^a-z0-9]+`)
+
+// SpecTraceFile returns the trace file for a spec run on parallelProcess,
+// e.g. <artifacts>/traces/BARA-2/sidecars-starts-the-sidecar-1a2b3c4d.txt.
+func SpecTraceFile(artifactsDirectory, componentName string, parallelProcess int, specText string) string {
+	return filepath.Join(
+		artifactsDirectory,
+		"traces",
+		fmt.Sprintf("%s-%d", componentName, parallelProcess),
+		SpecFileName(specText)+".txt",
+	)
+}
+
+// SpecFileName turns spec text into a short, unique file name without an
+// extension, e.g. sidecars-starts-the-sidecar-1a2b3c4d.
+func SpecFileName(specText string) string {
+	slug := strings.Trim(nonSlugCharacters.ReplaceAllString(strings.ToLower(specText), "-"), "-")
+	if len(slug) > maxSlugLength {
+		slug = strings.TrimRight(slug[:maxSlugLength], "-")
+	}
+	return slug + "-" + SpecHash(specText)
+}
+
+// SpecHash is the 8 hex digit hash that ends the spec's trace and
+// diagnostics file names and the names of the resources it creates.
+func SpecHash(specText string) string {
+	sum := sha1.Sum([]byte(specText))
+	return hex.EncodeToString(sum[:4])
+}
+
+// Redact hides Authorization headers, bearer tokens and token or password
+// fields in JSON bodies.
+func Redact(text string) string {
+	for _, redaction := range redactions {
+		text = redaction.pattern.ReplaceAllString(text, redaction.replacement)
+	}
+	return text
+}
+
+// RedactFile redacts the trace file at path in place. A missing file, e.g.
+// for a spec that never called the API, is not an error.
+func RedactFile(path string) error {
+	contents, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(Redact(string(contents))), 0644)
+}
+
+// Append writes a request and its response to the trace file at path in the
+// format the cf CLI uses, so both end up interleaved in one file.
+func Append(path, request, response string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	now := time.Now().Format(time.RFC3339)
+	_, err = fmt.Fprintf(file, "\nREQUEST: [%s]\n%s\n\nRESPONSE: [%s]\n%s\n", now, Redact(strings.TrimSpace(request)), now, Redact(strings.TrimSpace(response)))
+	return err
+}
+
+// LastExchanges returns the last n request/response pairs in the trace file
+// at path, redacted. It returns "" if the file does not exist.
+func LastExchanges(path string, n int) (string, error) {
+	contents, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	exchanges := splitExchanges(string(contents))
+	if len(exchanges) > n {
+		exchanges = exchanges[len(exchanges)-n:]
+	}
+	return Redact(strings.Join(exchanges, "\n\n")), nil
+}
+
+func splitExchanges(trace string) []string {
+	var exchanges []string
+	var current []string
+	for _, line := range strings.Split(trace, "\n") {
+		if strings.HasPrefix(line, "REQUEST: ") {
+			if len(current) > 0 {
+				exchanges = append(exchanges, strings.TrimSpace(strings.Join(current, "\n")))
+			}
+			current = []string{}
+		}
+		if current != nil {
+			current = append(current, line)
+		}
+	}
+	if len(current) > 0 {
+		exchanges = append(exchanges, strings.TrimSpace(strings.Join(current, "\n")))
+	}
+	return exchanges
+}
@@ -8,15 +8,17 @@ import (
 	"time"
 
 	. "github.com/cloudfoundry/capi-bara-tests/bara_suite_helpers"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/capi_client"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/cleanup"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/diagnostics"
 	"github.com/cloudfoundry/cf-test-helpers/v2/cf"
 	. "github.com/onsi/gomega"
 	. "github.com/onsi/gomega/gexec"
 )
 
 func ScaleApp(appGUID string, instances int) {
-	scalePath := fmt.Sprintf("/v3/apps/%s/processes/web/actions/scale", appGUID)
-	scaleBody := fmt.Sprintf(`{"instances": "%d"}`, instances)
-	Expect(cf.Cf("curl", "-f", scalePath, "-X", "POST", "-d", scaleBody).Wait()).To(Exit(0))
+	_, err := CAPIClient().ScaleAppProcess(appGUID, "web", capi_client.ScaleProcessRequest{Instances: &instances})
+	Expect(err).NotTo(HaveOccurred())
 }
 
 func WaitForAppToStop(appGUID string) {
@@ -28,14 +30,11 @@ func WaitForAppToStop(appGUID string) {
 }
 
 func CreateApp(appName, spaceGUID, environmentVariables string) string {
-	session := cf.Cf("curl", "-f", "/v3/apps", "-X", "POST", "-d", fmt.Sprintf(`{"name":"%s", "relationships": {"space": {"data": {"guid": "%s"}}}, "environment_variables":%s}`, appName, spaceGUID, environmentVariables))
-	bytes := session.Wait().Out.Contents()
-	var app struct {
-		GUID string `json:"guid"`
-	}
-	err := json.Unmarshal(bytes, &app)
-	Expect(err).NotTo(HaveOccurred())
-	return app.GUID
+	return createApp(capi_client.CreateAppRequest{
+		Name:                 appName,
+		Relationships:        capi_client.AppRelationships{Space: capi_client.NewRelationship(spaceGUID)},
+		EnvironmentVariables: parseEnvironmentVariables(environmentVariables),
+	})
 }
 
 func GetAppGUID(appName string) string {
@@ -45,49 +44,57 @@ func GetAppGUID(appName string) string {
 }
 
 func CreateDockerApp(appName, spaceGUID, environmentVariables string) string {
-	session := cf.Cf("curl", "-f", "/v3/apps", "-X", "POST", "-d", fmt.Sprintf(`{"name":"%s", "relationships": {"space": {"data": {"guid": "%s"}}}, "environment_variables":%s, "lifecycle": {"type": "docker", "data": {} } }`, appName, spaceGUID, environmentVariables))
-	bytes := session.Wait().Out.Contents()
-	var app struct {
-		GUID string `json:"guid"`
-	}
-	err := json.Unmarshal(bytes, &app)
-	Expect(err).NotTo(HaveOccurred())
-	return app.GUID
+	return createApp(capi_client.CreateAppRequest{
+		Name:                 appName,
+		Relationships:        capi_client.AppRelationships{Space: capi_client.NewRelationship(spaceGUID)},
+		EnvironmentVariables: parseEnvironmentVariables(environmentVariables),
+		Lifecycle:            &capi_client.Lifecycle{Type: "docker"},
+	})
 }
 
 func StartApp(appGUID string) {
-	startURL := fmt.Sprintf("/v3/apps/%s/actions/start", appGUID)
-	Expect(cf.Cf("curl", "-f", startURL, "-X", "POST").Wait()).To(Exit(0))
+	_, err := CAPIClient().StartApp(appGUID)
+	Expect(err).NotTo(HaveOccurred())
 }
 
 func StopApp(appGUID string) {
-	stopURL := fmt.Sprintf("/v3/apps/%s/actions/stop", appGUID)
-	Expect(cf.Cf("curl", "-f", stopURL, "-X", "POST").Wait()).To(Exit(0))
+	_, err := CAPIClient().StopApp(appGUID)
+	Expect(err).NotTo(HaveOccurred())
 }
 
 func RestartApp(appGUID string) {
-	restartURL := fmt.Sprintf("/v3/apps/%s/actions/restart", appGUID)
-	Expect(cf.Cf("curl", "-f", restartURL, "-X", "POST").Wait()).To(Exit(0))
+	_, err := CAPIClient().RestartApp(appGUID)
+	Expect(err).NotTo(HaveOccurred())
 }
 
 func DeleteApp(appGUID string) {
-	HandleAsyncRequest(fmt.Sprintf("/v3/apps/%s", appGUID), "DELETE")
+	jobPath, err := CAPIClient().DeleteApp(appGUID)
+	Expect(err).NotTo(HaveOccurred())
+	PollJob(jobPath)
+	cleanup.Forget(cleanup.App(appGUID))
 }
 
 func DownloadAppDroplet(appGuid string, dropletPath string, token string) *Session {
-	currentDroplet := cf.CfSilent("curl",
-		fmt.Sprintf("/v3/apps/%s/droplets/current", appGuid)).Wait()
-	Expect(currentDroplet).To(Exit(0),
-		fmt.Sprintf("failed getting current droplet for app %s plans", appGuid))
-
-	var droplet = struct {
-		Guid string `json:"guid"`
-	}{}
-	err := json.Unmarshal(currentDroplet.Out.Contents(), &droplet)
-	Expect(err).ToNot(HaveOccurred())
-
-	dropletDownloadUrl := fmt.Sprintf("%s%s/v3/droplets/%s/download", Config.Protocol(), Config.GetApiEndpoint(), droplet.Guid)
+	droplet, err := CAPIClient().GetAppCurrentDroplet(appGuid)
+	Expect(err).NotTo(HaveOccurred(), fmt.Sprintf("failed getting current droplet for app %s", appGuid))
+
+	dropletDownloadUrl := fmt.Sprintf("%s%s/v3/droplets/%s/download", Config.Protocol(), Config.GetApiEndpoint(), droplet.GUID)
 	curl := helpers.CurlRedact(token, Config, dropletDownloadUrl, "-o", dropletPath, "-L", "-H", fmt.Sprintf("Authorization: %s", token)).Wait()
 	Expect(curl).To(Exit(0))
 	return curl
 }
+
+func createApp(request capi_client.CreateAppRequest) string {
+	app, err := CAPIClient().CreateApp(request)
+	Expect(err).NotTo(HaveOccurred())
+	diagnostics.TrackApp(app.GUID)
+	cleanup.Track(cleanup.App(app.GUID))
+	return app.GUID
+}
+
+func parseEnvironmentVariables(environmentVariables string) map[string]string {
+	envVars := map[string]string{}
+	err := json.Unmarshal([]byte(environmentVariables), &envVars)
+	Expect(err).NotTo(HaveOccurred())
+	return envVars
+}
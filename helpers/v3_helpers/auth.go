@@ -4,9 +4,15 @@ import (
 	"strings"
 
 	"github.com/cloudfoundry/cf-test-helpers/v2/cf"
+
+	. "github.com/cloudfoundry/capi-bara-tests/bara_suite_helpers"
 )
 
 func GetAuthToken() string {
+	if token := Config.GetAuthToken(); token != "" {
+		return token
+	}
+
 	session := cf.CfRedact("bearer", "oauth-token")
 	bytes := session.Wait().Out.Contents()
 	return strings.TrimSpace(string(bytes))
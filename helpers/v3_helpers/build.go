@@ -1,16 +1,13 @@
 package v3_helpers
 
 import (
-	"encoding/json"
 	"fmt"
-	"strings"
 
-	"github.com/cloudfoundry/cf-test-helpers/v2/cf"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/capi_client"
 
 	. "github.com/cloudfoundry/capi-bara-tests/bara_suite_helpers"
+	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
-	. "github.com/onsi/gomega/gbytes"
-	. "github.com/onsi/gomega/gexec"
 )
 
 const (
@@ -19,61 +16,46 @@ const (
 )
 
 func StagePackage(packageGUID string, lifecycle string, buildpacks ...string) string {
-	buildpackString := "null"
-	if len(buildpacks) > 0 {
-		buildpackString = fmt.Sprintf(`["%s"]`, strings.Join(buildpacks, `", "`))
-	}
-
-	stageBody := fmt.Sprintf(
-		`{"lifecycle":{ "type": "%s", "data": { "buildpacks": %s } }, "package": { "guid": "%s"}}`,
-		lifecycle, buildpackString, packageGUID,
-	)
-	stageURL := "/v3/builds"
-	session := cf.Cf("curl", "-f", stageURL, "-X", "POST", "-d", stageBody)
-
-	bytes := session.Wait().Out.Contents()
-	var build struct {
-		GUID string `json:"guid"`
-	}
-	err := json.Unmarshal(bytes, &build)
+	build, err := CAPIClient().CreateBuild(capi_client.CreateBuildRequest{
+		Package: capi_client.GUIDRef{GUID: packageGUID},
+		Lifecycle: &capi_client.Lifecycle{
+			Type: lifecycle,
+			Data: capi_client.LifecycleData{Buildpacks: buildpacks},
+		},
+	})
 	Expect(err).NotTo(HaveOccurred())
 	Expect(build.GUID).NotTo(BeEmpty())
 	return build.GUID
 }
 
 func GetBuildError(buildGUID string) string {
-	buildPath := buildPath(buildGUID)
-
-	session := cf.Cf("curl", "-f", buildPath).Wait()
-	bytes := session.Wait().Out.Contents()
-
-	var build struct {
-		Error string `json:"error"`
-	}
-	err := json.Unmarshal(bytes, &build)
+	build, err := CAPIClient().GetBuild(buildGUID)
 	Expect(err).NotTo(HaveOccurred())
 
 	return build.Error
 }
 
 func WaitForBuildToStage(buildGUID string) {
-	buildPath := buildPath(buildGUID)
-	Eventually(func() *Session {
-		session := cf.Cf("curl", "-f", buildPath).Wait()
-		Expect(session).NotTo(Say(FAILED))
-		return session
-	}, Config.CfPushTimeoutDuration()).Should(Say(STAGED))
+	Eventually(func() string {
+		build, err := CAPIClient().GetBuild(buildGUID)
+		Expect(err).NotTo(HaveOccurred())
+		if build.State == FAILED {
+			Fail(fmt.Sprintf("build %s failed: %s", buildGUID, build.Error))
+		}
+		return build.State
+	}, Config.CfPushTimeoutDuration()).Should(Equal(STAGED))
 }
 
 func WaitForBuildToFail(buildGUID string) {
-	buildPath := buildPath(buildGUID)
-	Eventually(func() *Session {
-		session := cf.Cf("curl", "-f", buildPath).Wait()
-		Expect(session).NotTo(Say(STAGED))
-		return session
-	}, Config.CfPushTimeoutDuration()).Should(Say(FAILED))
+	Eventually(func() string {
+		state := getBuildState(buildGUID)
+		Expect(state).NotTo(Equal(STAGED))
+		return state
+	}, Config.CfPushTimeoutDuration()).Should(Equal(FAILED))
 }
 
-func buildPath(buildGUID string) string {
-	return fmt.Sprintf("/v3/builds/%s", buildGUID)
+func getBuildState(buildGUID string) string {
+	build, err := CAPIClient().GetBuild(buildGUID)
+	Expect(err).NotTo(HaveOccurred())
+	return build.State
 }
@@ -0,0 +1,13 @@
+package v3_helpers
+
+import (
+	. "github.com/cloudfoundry/capi-bara-tests/bara_suite_helpers"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/cleanup"
+)
+
+// DeleteTrackedResources deletes everything the current spec created through
+// these helpers that it has not deleted itself, newest first. Run it as a
+// user allowed to delete all of it, e.g. the admin.
+func DeleteTrackedResources() error {
+	return cleanup.DeleteAll(CAPIClient(), Config.AsyncServiceOperationTimeoutDuration(), jobPollingInterval)
+}
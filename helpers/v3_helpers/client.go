@@ -0,0 +1,13 @@
+package v3_helpers
+
+import (
+	"github.com/cloudfoundry/capi-bara-tests/helpers/capi_client"
+
+	. "github.com/cloudfoundry/capi-bara-tests/bara_suite_helpers"
+)
+
+// CAPIClient returns a client for the configured Cloud Controller that
+// authenticates as whichever user the cf CLI is currently logged in as.
+func CAPIClient() *capi_client.Client {
+	return capi_client.NewClientFromConfig(Config, GetAuthToken)
+}
@@ -1,81 +1,103 @@
 package v3_helpers
 
 import (
-	"crypto/tls"
-	"encoding/json"
-	"fmt"
-	"io/ioutil"
-	"net/http"
-
-	"github.com/cloudfoundry/cf-test-helpers/v2/cf"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/capi_client"
 
 	. "github.com/cloudfoundry/capi-bara-tests/bara_suite_helpers"
 	. "github.com/onsi/gomega"
-	. "github.com/onsi/gomega/gexec"
 )
 
-func CreateDeployment(appGUID string) string {
-	deploymentPath := fmt.Sprintf("/v3/deployments")
-	deploymentRequestBody := fmt.Sprintf(`{"relationships": {"app": {"data": {"guid": "%s"}}}}`, appGUID)
-	session := cf.Cf("curl", "-f", deploymentPath, "-X", "POST", "-d", deploymentRequestBody).Wait()
-	Expect(session).To(Exit(0))
-	var deployment struct {
-		GUID string `json:"guid"`
-	}
+// Deployment is the state of a deployment that specs assert on.
+type Deployment struct {
+	GUID                string
+	StatusValue         string
+	StatusReason        string
+	Strategy            string
+	MaxInFlight         int
+	DropletGUID         string
+	PreviousDropletGUID string
+	RevisionGUID        string
+	RevisionVersion     int
+	NewProcessGUIDs     []string
+}
 
-	bytes := session.Wait().Out.Contents()
-	err := json.Unmarshal(bytes, &deployment)
-	Expect(err).NotTo(HaveOccurred())
-	return deployment.GUID
+// DeploymentOptions picks what to deploy. Zero values deploy the app's
+// current droplet with Cloud Controller's default strategy.
+type DeploymentOptions struct {
+	DropletGUID string
+	// RevisionGUID rolls the app back to the revision's droplet and
+	// environment.
+	RevisionGUID string
+	Strategy     string
+	// MaxInFlight is how many new instances a rolling deployment starts at
+	// once, or 0 for Cloud Controller's default.
+	MaxInFlight int
 }
 
-func CreateDeploymentForDroplet(appGUID, dropletGUID string) string {
-	deploymentPath := fmt.Sprintf("/v3/deployments")
-	deploymentRequestBody := fmt.Sprintf(`{"droplet": {"guid": "%s"}, "relationships": {"app": {"data": {"guid": "%s"}}}}`, dropletGUID, appGUID)
-	session := cf.Cf("curl", "-f", deploymentPath, "-X", "POST", "-d", deploymentRequestBody).Wait()
-	Expect(session).To(Exit(0))
-	var deployment struct {
-		GUID string `json:"guid"`
+// Deploy creates a deployment of the app and returns it as created.
+func Deploy(appGUID string, options DeploymentOptions) Deployment {
+	request := capi_client.CreateDeploymentRequest{
+		Strategy:      options.Strategy,
+		Relationships: capi_client.DeploymentRelationships{App: capi_client.NewRelationship(appGUID)},
+	}
+	if options.DropletGUID != "" {
+		request.Droplet = &capi_client.GUIDRef{GUID: options.DropletGUID}
+	}
+	if options.RevisionGUID != "" {
+		request.Revision = &capi_client.GUIDRef{GUID: options.RevisionGUID}
 	}
+	if options.MaxInFlight != 0 {
+		request.Options = &capi_client.DeploymentOptions{MaxInFlight: &options.MaxInFlight}
+	}
+
+	deployment, err := CAPIClient().CreateDeployment(request)
+	Expect(err).NotTo(HaveOccurred())
+	return newDeployment(deployment)
+}
 
-	bytes := session.Wait().Out.Contents()
-	err := json.Unmarshal(bytes, &deployment)
+func GetDeployment(deploymentGUID string) Deployment {
+	deployment, err := CAPIClient().GetDeployment(deploymentGUID)
 	Expect(err).NotTo(HaveOccurred())
-	return deployment.GUID
+	return newDeployment(deployment)
+}
+
+func CreateDeployment(appGUID string) string {
+	return Deploy(appGUID, DeploymentOptions{}).GUID
+}
+
+func CreateDeploymentForDroplet(appGUID, dropletGUID string) string {
+	return Deploy(appGUID, DeploymentOptions{DropletGUID: dropletGUID}).GUID
 }
 
 func RollbackDeployment(appGUID, revisionGUID string) string {
-	deploymentPath := fmt.Sprintf("/v3/deployments")
-	deploymentRequestBody := fmt.Sprintf(`{"revision": { "guid": "%s" },"relationships": {"app": {"data": {"guid": "%s"}}}}`, revisionGUID, appGUID)
-	session := cf.Cf("curl", "-f", deploymentPath, "-X", "POST", "-d", deploymentRequestBody).Wait()
-	Expect(session).To(Exit(0))
-	var deployment struct {
-		GUID string `json:"guid"`
-	}
+	return Deploy(appGUID, DeploymentOptions{RevisionGUID: revisionGUID}).GUID
+}
 
-	bytes := session.Wait().Out.Contents()
-	err := json.Unmarshal(bytes, &deployment)
+// CreateCanaryDeployment deploys the app's current droplet to a single
+// canary instance, pausing until the deployment is continued or canceled.
+func CreateCanaryDeployment(appGUID string) string {
+	return Deploy(appGUID, DeploymentOptions{Strategy: capi_client.DeploymentStrategyCanary}).GUID
+}
+
+func ContinueDeployment(deploymentGUID string) {
+	err := CAPIClient().ContinueDeployment(deploymentGUID)
 	Expect(err).NotTo(HaveOccurred())
-	return deployment.GUID
+}
+
+func WaitUntilDeploymentIsPaused(deploymentGUID string) {
+	WaitUntilDeploymentReachesStatus(deploymentGUID, capi_client.DeploymentStatusValueActive, capi_client.DeploymentStatusReasonPaused)
 }
 
 func CancelDeployment(deploymentGUID string) {
-	deploymentPath := fmt.Sprintf("/v3/deployments/%s/actions/cancel", deploymentGUID)
-	session := cf.Cf("curl", "-f", deploymentPath, "-X", "POST", "-i").Wait()
-	Expect(session.Out.Contents()).To(ContainSubstring("200 OK"))
-	Expect(session).To(Exit(0))
+	err := CAPIClient().CancelDeployment(deploymentGUID)
+	Expect(err).NotTo(HaveOccurred())
 }
 
 func WaitUntilDeploymentReachesStatus(deploymentGUID, statusValue, statusReason string) {
-	deploymentPath := fmt.Sprintf("/v3/deployments/%s", deploymentGUID)
-
 	type deploymentStatus struct {
-		Value  string `json:"value"`
-		Reason string `json:"reason"`
+		Value  string
+		Reason string
 	}
-	deploymentJSON := struct {
-		Status deploymentStatus `json:"status"`
-	}{}
 
 	desiredDeploymentStatus := deploymentStatus{
 		Value:  statusValue,
@@ -83,56 +105,51 @@ func WaitUntilDeploymentReachesStatus(deploymentGUID, statusValue, statusReason
 	}
 
 	Eventually(func() deploymentStatus {
-		session := cf.Cf("curl", "-f", deploymentPath).Wait()
-		Expect(session.Wait()).To(Exit(0))
-		err := json.Unmarshal(session.Out.Contents(), &deploymentJSON)
+		deployment, err := CAPIClient().GetDeployment(deploymentGUID)
 		Expect(err).NotTo(HaveOccurred())
-		return deploymentJSON.Status
+		return deploymentStatus{Value: deployment.Status.Value, Reason: deployment.Status.Reason}
 	}, Config.LongCurlTimeoutDuration()).Should(Equal(desiredDeploymentStatus))
 }
 
-func GetRunningInstancesStats(processGUID string) int {
-	processStatsURL := fmt.Sprintf("%s%s/v3/processes/%s/stats", Config.Protocol(), Config.GetApiEndpoint(), processGUID)
-
-	client := buildHTTPClient()
-	req, err := http.NewRequest("GET", processStatsURL, nil)
-	req.Header.Add("Authorization", GetAuthToken())
-	resp, err := client.Do(req)
-	Expect(err).NotTo(HaveOccurred())
-
-	instancesJSON := struct {
-		Resources []struct {
-			Type  string `json:"type"`
-			State string `json:"state"`
-		} `json:"resources"`
-	}{}
-
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
-	Expect(err).NotTo(HaveOccurred())
-	Expect(resp.StatusCode).To(Equal(200))
-
-	err = json.Unmarshal(body, &instancesJSON)
-	Expect(err).NotTo(HaveOccurred())
-	numRunning := 0
+// MaxInstancesInFlight samples the deployment's new web processes until it
+// stops deploying and returns the most instances that were ever scaled up
+// but not yet running at once.
+func MaxInstancesInFlight(deploymentGUID string) int {
+	maxInFlight := 0
+	Eventually(func() string {
+		deployment := GetDeployment(deploymentGUID)
+		if deployment.StatusReason != capi_client.DeploymentStatusReasonDeploying {
+			return deployment.StatusReason
+		}
 
-	for _, instance := range instancesJSON.Resources {
-		if instance.State == "RUNNING" {
-			numRunning += 1
+		for _, processGUID := range deployment.NewProcessGUIDs {
+			process, err := CAPIClient().GetProcess(processGUID)
+			Expect(err).NotTo(HaveOccurred())
+			maxInFlight = max(maxInFlight, process.Instances-GetRunningInstancesStats(processGUID))
 		}
-	}
-	return numRunning
+		return deployment.StatusReason
+	}, Config.LongCurlTimeoutDuration()).ShouldNot(Equal(capi_client.DeploymentStatusReasonDeploying))
+	return maxInFlight
 }
 
-func buildHTTPClient() *http.Client {
-	var client *http.Client
-	if Config.GetSkipSSLValidation() {
-		tr := &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		}
-		client = &http.Client{Transport: tr}
-	} else {
-		client = &http.Client{}
+func newDeployment(deployment capi_client.Deployment) Deployment {
+	d := Deployment{
+		GUID:                deployment.GUID,
+		StatusValue:         deployment.Status.Value,
+		StatusReason:        deployment.Status.Reason,
+		Strategy:            deployment.Strategy,
+		DropletGUID:         deployment.Droplet.GUID,
+		PreviousDropletGUID: deployment.PreviousDroplet.GUID,
+	}
+	if deployment.Options.MaxInFlight != nil {
+		d.MaxInFlight = *deployment.Options.MaxInFlight
+	}
+	if deployment.Revision != nil {
+		d.RevisionGUID = deployment.Revision.GUID
+		d.RevisionVersion = deployment.Revision.Version
+	}
+	for _, process := range deployment.NewProcesses {
+		d.NewProcessGUIDs = append(d.NewProcessGUIDs, process.GUID)
 	}
-	return client
+	return d
 }
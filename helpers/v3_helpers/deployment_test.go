@@ -0,0 +1,213 @@
+package v3_helpers_test
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/cloudfoundry/capi-bara-tests/bara_suite_helpers"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/capi_client"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/fake_cc"
+	. "github.com/cloudfoundry/capi-bara-tests/helpers/v3_helpers"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Deployments", func() {
+	var (
+		fakeCC      *fake_cc.FakeCC
+		appGUID     string
+		packageGUID string
+	)
+
+	BeforeEach(func() {
+		fakeCC = fake_cc.New()
+		Config = fakeCC.Config()
+
+		zipPath := filepath.Join(GinkgoT().TempDir(), "app.zip")
+		Expect(os.WriteFile(zipPath, []byte("not really a zip"), 0644)).To(Succeed())
+
+		appGUID = CreateApp("some-app", "space-guid", `{"foo":"bar"}`)
+		packageGUID = CreatePackage(appGUID)
+		_, err := CAPIClient().UploadPackageBits(packageGUID, zipPath)
+		Expect(err).NotTo(HaveOccurred())
+		WaitForPackageToBeReady(packageGUID)
+
+		buildGUID := StagePackage(packageGUID, "buildpack", "ruby_buildpack")
+		WaitForBuildToStage(buildGUID)
+		AssignDropletToApp(appGUID, GetDropletFromBuild(buildGUID))
+		StartApp(appGUID)
+	})
+
+	AfterEach(func() {
+		fakeCC.Close()
+	})
+
+	Describe("WaitUntilDeploymentReachesStatus", func() {
+		It("waits for the deployment to be deployed", func() {
+			deploymentGUID := CreateDeployment(appGUID)
+
+			WaitUntilDeploymentReachesStatus(deploymentGUID, capi_client.DeploymentStatusValueFinalized, capi_client.DeploymentStatusReasonDeployed)
+
+			Expect(GetProcessGuidsForType(appGUID, "web")).To(HaveLen(1))
+			Expect(GetRevisions(appGUID)).To(HaveLen(2))
+		})
+
+		It("waits for a canceled deployment to be finalized", func() {
+			deploymentGUID := CreateDeployment(appGUID)
+			CancelDeployment(deploymentGUID)
+
+			WaitUntilDeploymentReachesStatus(deploymentGUID, capi_client.DeploymentStatusValueFinalized, capi_client.DeploymentStatusReasonCanceled)
+		})
+
+		It("sees superseded deployments finalized", func() {
+			firstDeploymentGUID := CreateDeployment(appGUID)
+			CreateDeployment(appGUID)
+
+			WaitUntilDeploymentReachesStatus(firstDeploymentGUID, capi_client.DeploymentStatusValueFinalized, capi_client.DeploymentStatusReasonSuperseded)
+		})
+	})
+
+	Describe("Deploy", func() {
+		It("returns the deployment's droplets, revision and new processes", func() {
+			previousDropletGUID := GetDeployment(CreateDeployment(appGUID)).PreviousDropletGUID
+			Expect(previousDropletGUID).NotTo(BeEmpty())
+
+			deployment := Deploy(appGUID, DeploymentOptions{Strategy: capi_client.DeploymentStrategyRolling})
+			Expect(deployment.StatusValue).To(Equal(capi_client.DeploymentStatusValueActive))
+			Expect(deployment.StatusReason).To(Equal(capi_client.DeploymentStatusReasonDeploying))
+			Expect(deployment.Strategy).To(Equal(capi_client.DeploymentStrategyRolling))
+			Expect(deployment.DropletGUID).To(Equal(previousDropletGUID))
+			Expect(deployment.PreviousDropletGUID).To(Equal(previousDropletGUID))
+			Expect(deployment.RevisionVersion).To(Equal(3))
+			Expect(deployment.NewProcessGUIDs).To(HaveLen(1))
+
+			Expect(GetDeployment(deployment.GUID).RevisionGUID).To(Equal(deployment.RevisionGUID))
+		})
+	})
+
+	Describe("ObserveRollout", func() {
+		BeforeEach(func() {
+			instances := 3
+			_, err := CAPIClient().ScaleAppProcess(appGUID, "web", capi_client.ScaleProcessRequest{Instances: &instances})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("records every change in old and new running instances until the deployment is finalized", func() {
+			deployment := Deploy(appGUID, DeploymentOptions{})
+			rollout := ObserveRollout(appGUID, deployment.GUID, time.Millisecond).Wait()
+
+			Expect(rollout).To(HaveLen(4))
+			Expect([][2]int{
+				{rollout[0].Old, rollout[0].New},
+				{rollout[1].Old, rollout[1].New},
+				{rollout[2].Old, rollout[2].New},
+				{rollout[3].Old, rollout[3].New},
+			}).To(Equal([][2]int{{3, 0}, {2, 1}, {1, 2}, {0, 3}}))
+			Expect(rollout.MinRunning()).To(Equal(3))
+			Expect(rollout.MaxRunning()).To(Equal(3))
+			Expect(GetDeployment(deployment.GUID).StatusReason).To(Equal(capi_client.DeploymentStatusReasonDeployed))
+		})
+
+		It("stops before the deployment is finalized", func() {
+			deployment := Deploy(appGUID, DeploymentOptions{})
+			observer := ObserveRollout(appGUID, deployment.GUID, time.Hour)
+
+			rollout := observer.Stop()
+			Expect(rollout).To(HaveLen(1))
+			Expect(rollout[0].Old).To(Equal(3))
+			Expect(rollout[0].New).To(Equal(0))
+			Expect(GetDeployment(deployment.GUID).StatusValue).To(Equal(capi_client.DeploymentStatusValueActive))
+		})
+	})
+
+	Describe("max_in_flight", func() {
+		BeforeEach(func() {
+			instances := 6
+			_, err := CAPIClient().ScaleAppProcess(appGUID, "web", capi_client.ScaleProcessRequest{Instances: &instances})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("starts at most max_in_flight new instances at once", func() {
+			deployment := Deploy(appGUID, DeploymentOptions{MaxInFlight: 4})
+			Expect(deployment.MaxInFlight).To(Equal(4))
+
+			Expect(MaxInstancesInFlight(deployment.GUID)).To(Equal(4))
+			Expect(GetDeployment(deployment.GUID).StatusReason).To(Equal(capi_client.DeploymentStatusReasonDeployed))
+			Expect(GetRunningInstancesStats(deployment.NewProcessGUIDs[0])).To(Equal(6))
+		})
+
+		It("defaults to one instance at a time", func() {
+			deployment := Deploy(appGUID, DeploymentOptions{})
+			Expect(deployment.MaxInFlight).To(Equal(1))
+			Expect(MaxInstancesInFlight(deployment.GUID)).To(Equal(1))
+		})
+	})
+
+	Describe("canary deployments", func() {
+		It("pauses with one canary instance until continued", func() {
+			deploymentGUID := CreateCanaryDeployment(appGUID)
+			Expect(GetDeployment(deploymentGUID).Strategy).To(Equal(capi_client.DeploymentStrategyCanary))
+
+			WaitUntilDeploymentIsPaused(deploymentGUID)
+			canaryGUID := GetDeployment(deploymentGUID).NewProcessGUIDs[0]
+			Expect(GetRunningInstancesStats(canaryGUID)).To(Equal(1))
+			Consistently(func() string { return GetDeployment(deploymentGUID).StatusReason }).Should(Equal(capi_client.DeploymentStatusReasonPaused))
+
+			ContinueDeployment(deploymentGUID)
+			WaitUntilDeploymentReachesStatus(deploymentGUID, capi_client.DeploymentStatusValueFinalized, capi_client.DeploymentStatusReasonDeployed)
+			Expect(GetProcessGuidsForType(appGUID, "web")).To(Equal([]string{canaryGUID}))
+		})
+
+		It("restores the previous droplet and web process when canceled while paused", func() {
+			originalWebGUIDs := GetProcessGuidsForType(appGUID, "web")
+			originalDropletGUID := GetDropletFromApp(appGUID)
+			buildGUID := StagePackage(packageGUID, "buildpack", "ruby_buildpack")
+			WaitForBuildToStage(buildGUID)
+
+			deployment := Deploy(appGUID, DeploymentOptions{DropletGUID: GetDropletFromBuild(buildGUID), Strategy: capi_client.DeploymentStrategyCanary})
+			Expect(deployment.PreviousDropletGUID).To(Equal(originalDropletGUID))
+			WaitUntilDeploymentIsPaused(deployment.GUID)
+			Expect(GetDropletFromApp(appGUID)).To(Equal(deployment.DropletGUID))
+
+			CancelDeployment(deployment.GUID)
+			WaitUntilDeploymentReachesStatus(deployment.GUID, capi_client.DeploymentStatusValueFinalized, capi_client.DeploymentStatusReasonCanceled)
+			Expect(GetDropletFromApp(appGUID)).To(Equal(originalDropletGUID))
+			Expect(GetProcessGuidsForType(appGUID, "web")).To(Equal(originalWebGUIDs))
+		})
+
+		It("cannot continue a deployment that is not paused", func() {
+			deploymentGUID := CreateDeployment(appGUID)
+			Expect(CAPIClient().ContinueDeployment(deploymentGUID)).To(MatchError(ContainSubstring("Cannot continue a deployment")))
+		})
+	})
+
+	Describe("RollbackDeployment", func() {
+		It("records the rollback as a new revision", func() {
+			initialRevision := GetNewestRevision(appGUID)
+			WaitUntilDeploymentReachesStatus(CreateDeployment(appGUID), capi_client.DeploymentStatusValueFinalized, capi_client.DeploymentStatusReasonDeployed)
+
+			deploymentGUID := RollbackDeployment(appGUID, initialRevision.Guid)
+			WaitUntilDeploymentReachesStatus(deploymentGUID, capi_client.DeploymentStatusValueFinalized, capi_client.DeploymentStatusReasonDeployed)
+
+			newestRevision := GetNewestRevision(appGUID)
+			Expect(newestRevision.Droplet.Guid).To(Equal(initialRevision.Droplet.Guid))
+			Expect(GetRevisionEnvVars(newestRevision.Guid).Var).To(HaveKeyWithValue("foo", "bar"))
+
+			revision, err := CAPIClient().GetRevision(newestRevision.Guid)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(revision.Description).To(Equal("Rolled back to revision 1."))
+		})
+	})
+
+	Describe("DeleteApp", func() {
+		It("polls the delete job to completion", func() {
+			fakeCC.PollsPerTransition = 0
+			DeleteApp(appGUID)
+
+			_, err := CAPIClient().GetApp(appGUID)
+			Expect(capi_client.IsNotFound(err)).To(BeTrue())
+		})
+	})
+})
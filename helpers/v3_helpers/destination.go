@@ -1,71 +1,33 @@
 package v3_helpers
 
 import (
-	"encoding/json"
-	"fmt"
-
-	"github.com/cloudfoundry/cf-test-helpers/v2/cf"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/capi_client"
 	. "github.com/onsi/gomega"
-	. "github.com/onsi/gomega/gexec"
 )
 
-type DestinationProcess struct {
-	Type string `json:"type"`
-}
+type DestinationProcess = capi_client.DestinationProcess
 
-type App struct {
-	GUID    string              `json:"guid"`
-	Process *DestinationProcess `json:"process,omitempty"`
-}
+type App = capi_client.DestinationApp
 
-type Destination struct {
-	GUID   string `json:"guid,omitempty"`
-	App    App    `json:"app"`
-	Port   int    `json:"port,omitempty"`
-	Weight int    `json:"weight,omitempty"`
-}
+type Destination = capi_client.Destination
 
-type Destinations struct {
-	Destinations []Destination `json:"destinations"`
-}
+type Destinations = capi_client.Destinations
 
 func InsertDestinations(routeGUID string, destinations []Destination) []string {
-	destinationsJSON, err := json.Marshal(Destinations{Destinations: destinations})
-	Expect(err).ToNot(HaveOccurred())
-
-	session := cf.Cf("curl", "-f",
-		fmt.Sprintf("/v3/routes/%s/destinations", routeGUID),
-		"-X", "POST", "-d", string(destinationsJSON))
-
-	Expect(session.Wait()).To(Exit(0))
-	response := session.Out.Contents()
-
-	var responseDestinations Destinations
-	err = json.Unmarshal(response, &responseDestinations)
+	responseDestinations, err := CAPIClient().InsertRouteDestinations(routeGUID, destinations)
 	Expect(err).ToNot(HaveOccurred())
 
-	listDstGUIDs := make([]string, 0, len(responseDestinations.Destinations))
-	for _, dst := range responseDestinations.Destinations {
+	listDstGUIDs := make([]string, 0, len(responseDestinations))
+	for _, dst := range responseDestinations {
 		listDstGUIDs = append(listDstGUIDs, dst.GUID)
 	}
 	return listDstGUIDs
 }
 
 func ReplaceDestinations(routeGUID string, destinations []Destination) Destinations {
-	destinationsJSON, err := json.Marshal(Destinations{Destinations: destinations})
+	responseDestinations, err := CAPIClient().ReplaceRouteDestinations(routeGUID, destinations)
 	Expect(err).ToNot(HaveOccurred())
-
-	session := cf.Cf("curl", "-f",
-		fmt.Sprintf("/v3/routes/%s/destinations", routeGUID),
-		"-X", "PATCH", "-d", string(destinationsJSON))
-
-	Expect(session.Wait()).To(Exit(0))
-	response := session.Out.Contents()
-
-	var responseDestinations Destinations
-	err = json.Unmarshal(response, &responseDestinations)
-	Expect(err).ToNot(HaveOccurred())
-	return responseDestinations
+	return Destinations{Destinations: responseDestinations}
 }
 
 func CreateAndMapRoute(appGUID, spaceGUID, domainGUID, host string) {
@@ -81,37 +43,22 @@ func CreateAndMapRouteWithPort(appGUID, spaceGUID, domainGUID, host string, port
 }
 
 func UnmapAllRoutes(appGUID string) {
-	getRoutespath := fmt.Sprintf("/v3/apps/%s/routes", appGUID)
-	routesBody := cf.Cf("curl", "-f", getRoutespath).Wait().Out.Contents()
-	routesJSON := struct {
-		Resources []struct {
-			GUID string `json:"guid"`
-		} `json:"resources"`
-	}{}
-	err := json.Unmarshal([]byte(routesBody), &routesJSON)
+	client := CAPIClient()
+	routes, err := client.ListAppRoutes(appGUID, capi_client.ListOptions{})
 	Expect(err).NotTo(HaveOccurred())
 
-	for _, routeResource := range routesJSON.Resources {
-		routeGUID := routeResource.GUID
-
-		var destinations Destinations
-
-		getDestinationspath := fmt.Sprintf("/v3/routes/%s/destinations", routeGUID)
-		destinationsBody := cf.Cf("curl", getDestinationspath).Wait().Out.Contents()
-
-		err := json.Unmarshal(destinationsBody, &destinations)
+	for _, route := range routes {
+		destinations, err := client.ListRouteDestinations(route.GUID)
 		Expect(err).NotTo(HaveOccurred())
 
 		filteredDestinations := []Destination{}
-		for _, destination := range destinations.Destinations {
+		for _, destination := range destinations {
 			if destination.App.GUID != appGUID {
 				filteredDestinations = append(filteredDestinations, destination)
 			}
 		}
 
-		filteredDestinationsJSON, err := json.Marshal(filteredDestinations)
+		_, err = client.ReplaceRouteDestinations(route.GUID, filteredDestinations)
 		Expect(err).NotTo(HaveOccurred())
-
-		Expect(cf.Cf("curl", "-f", fmt.Sprintf("/v3/routes/%s/destinations", routeGUID), "-X", "PATCH", "-d", string(filteredDestinationsJSON)).Wait()).To(Exit(0))
 	}
 }
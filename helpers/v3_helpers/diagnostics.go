@@ -0,0 +1,63 @@
+package v3_helpers
+
+import (
+	"fmt"
+
+	. "github.com/cloudfoundry/capi-bara-tests/bara_suite_helpers"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/capi_client"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/diagnostics"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// CollectDiagnostics writes a bundle describing every app the current spec
+// created, through these helpers or by pushing into the spec's space, and
+// returns the bundle's directory. It never fails the spec itself.
+func CollectDiagnostics(specText string) (string, error) {
+	client := CAPIClient()
+
+	appGUIDs := diagnostics.TrackedApps()
+	if TestSetup != nil {
+		spaceApps, err := appsInSpace(client, TestSetup.RegularUserContext().Space)
+		if err != nil {
+			return "", err
+		}
+		appGUIDs = append(appGUIDs, spaceApps...)
+	}
+
+	directory := diagnostics.BundleDirectory(Config.GetArtifactsDirectory(), "BARA", GinkgoParallelProcess(), specText)
+	collector := diagnostics.Collector{Client: client, RecentLogs: recentLogs}
+	return directory, collector.Collect(directory, appGUIDs)
+}
+
+func appsInSpace(client *capi_client.Client, spaceName string) ([]string, error) {
+	spaces, err := client.ListSpaces(capi_client.ListOptions{}.Filter("names", spaceName))
+	if err != nil || len(spaces) == 0 {
+		return nil, err
+	}
+
+	apps, err := client.ListApps(capi_client.ListOptions{}.Filter("space_guids", spaces[0].GUID))
+	if err != nil {
+		return nil, err
+	}
+
+	var guids []string
+	for _, app := range apps {
+		guids = append(guids, app.GUID)
+	}
+	return guids, nil
+}
+
+// recentLogs wraps FetchRecentLogs so that a failure to fetch logs is
+// recorded in the bundle rather than reported against the spec.
+func recentLogs(appGUID string) (string, error) {
+	var logs string
+	failures := InterceptGomegaFailures(func() {
+		logs = string(FetchRecentLogs(appGUID, Config).Out.Contents())
+	})
+	if len(failures) > 0 {
+		return "", fmt.Errorf("fetching recent logs: %s", failures[0])
+	}
+	return logs, nil
+}
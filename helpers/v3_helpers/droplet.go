@@ -1,21 +1,16 @@
 package v3_helpers
 
 import (
-	"encoding/json"
 	"fmt"
 
-	"github.com/cloudfoundry/cf-test-helpers/v2/cf"
 	. "github.com/cloudfoundry/capi-bara-tests/bara_suite_helpers"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
-	. "github.com/onsi/gomega/gbytes"
-	. "github.com/onsi/gomega/gexec"
 )
 
 func AssignDropletToApp(appGUID, dropletGUID string) {
-	appUpdatePath := fmt.Sprintf("/v3/apps/%s/relationships/current_droplet", appGUID)
-	appUpdateBody := fmt.Sprintf(`{"data": {"guid":"%s"}}`, dropletGUID)
-	Expect(cf.Cf("curl", "-f", appUpdatePath, "-X", "PATCH", "-d", appUpdateBody).Wait()).To(Exit(0))
+	err := CAPIClient().SetAppCurrentDroplet(appGUID, dropletGUID)
+	Expect(err).NotTo(HaveOccurred())
 
 	for _, process := range GetProcesses(appGUID, "") {
 		ScaleProcess(appGUID, process.Type, V3_DEFAULT_MEMORY_LIMIT)
@@ -42,27 +37,15 @@ func CreateAndAssociateNewDroplet(appGUID, assetPath string, buildpacks ...strin
 }
 
 func GetDropletFromBuild(buildGUID string) string {
-	buildPath := fmt.Sprintf("/v3/builds/%s", buildGUID)
-	session := cf.Cf("curl", "-f", buildPath).Wait()
-	var build struct {
-		Droplet struct {
-			GUID string `json:"guid"`
-		} `json:"droplet"`
-	}
-	bytes := session.Wait().Out.Contents()
-	err := json.Unmarshal(bytes, &build)
+	build, err := CAPIClient().GetBuild(buildGUID)
 	Expect(err).NotTo(HaveOccurred())
+	Expect(build.Droplet).NotTo(BeNil(), "Build response didn't contain a droplet GUID")
 	Expect(build.Droplet.GUID).NotTo(BeEmpty(), "Build response didn't contain a droplet GUID")
 	return build.Droplet.GUID
 }
 
 func GetDropletFromApp(appGUID string) string {
-	session := cf.Cf("curl", "-f", fmt.Sprintf("/v3/apps/%s/droplets/current", appGUID)).Wait()
-	var droplet struct {
-		GUID string `json:"guid"`
-	}
-	bytes := session.Wait().Out.Contents()
-	err := json.Unmarshal(bytes, &droplet)
+	droplet, err := CAPIClient().GetAppCurrentDroplet(appGUID)
 	Expect(err).NotTo(HaveOccurred())
 	return droplet.GUID
 }
@@ -78,21 +61,23 @@ type Droplet struct {
 }
 
 func GetDroplet(dropletGUID string) Droplet {
-	dropletPath := fmt.Sprintf("/v3/droplets/%s", dropletGUID)
-	session := cf.Cf("curl", "-f", dropletPath)
-	bytes := session.Wait().Out.Contents()
-
-	var droplet = Droplet{}
-	err := json.Unmarshal(bytes, &droplet)
+	droplet, err := CAPIClient().GetDroplet(dropletGUID)
 	Expect(err).NotTo(HaveOccurred())
-	return droplet
+
+	result := Droplet{
+		GUID:  droplet.GUID,
+		State: droplet.State,
+		Image: droplet.Image,
+	}
+	result.Lifecycle.Type = droplet.Lifecycle.Type
+	return result
 }
 
 func WaitForDropletToCopy(dropletGUID string) {
-	dropletPath := fmt.Sprintf("/v3/droplets/%s", dropletGUID)
-	Eventually(func() *Session {
-		session := cf.Cf("curl", "-f", dropletPath).Wait()
-		Expect(session).NotTo(Say("FAILED"))
-		return session
-	}, Config.CfPushTimeoutDuration()).Should(Say("STAGED"))
+	Eventually(func() string {
+		droplet, err := CAPIClient().GetDroplet(dropletGUID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(droplet.State).NotTo(Equal(FAILED))
+		return droplet.State
+	}, Config.CfPushTimeoutDuration()).Should(Equal(STAGED))
 }
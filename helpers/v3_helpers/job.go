@@ -1,49 +1,62 @@
 package v3_helpers
 
 import (
-	"encoding/json"
-	"regexp"
+	"bufio"
+	"bytes"
+	"strings"
+	"time"
 
-	"github.com/cloudfoundry/cf-test-helpers/v2/cf"
+	. "github.com/cloudfoundry/capi-bara-tests/bara_suite_helpers"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/capi_client"
 
+	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 )
 
+const jobPollingInterval = 1 * time.Second
+
+// GetJobPath returns the job path from the Location header of a
+// `cf curl -i` response.
 func GetJobPath(response []byte) string {
-	r, err := regexp.Compile(`Location:.*(/v3/jobs/[\w-]*)`)
-	Expect(err).ToNot(HaveOccurred())
-	return r.FindStringSubmatch(string(response))[1]
-}
+	scanner := bufio.NewScanner(bytes.NewReader(response))
+	for scanner.Scan() {
+		name, value, found := strings.Cut(scanner.Text(), ":")
+		if !found || !strings.EqualFold(strings.TrimSpace(name), "Location") {
+			continue
+		}
+
+		jobPath, err := capi_client.JobPathFromLocation(value)
+		Expect(err).NotTo(HaveOccurred())
+		return jobPath
+	}
 
-func PollJob(jobPath string) {
-	Eventually(func() string {
-		jobSession := cf.Cf("curl", "-f", jobPath)
-		return string(jobSession.Wait().Out.Contents())
-	}).Should(ContainSubstring("COMPLETE"))
+	Expect(scanner.Err()).NotTo(HaveOccurred())
+	Fail("response has no Location header:\n" + string(response))
+	return ""
 }
 
-func PollJobAsFailed(jobPath string) {
-	Eventually(func() string {
-		jobSession := cf.Cf("curl", "-f", jobPath)
-		return string(jobSession.Wait().Out.Contents())
-	}).Should(ContainSubstring("FAILED"))
+func PollJob(jobPath string) capi_client.Job {
+	return waitForJob(jobPath, capi_client.JobStateComplete)
 }
 
-type jobError struct {
-	Detail string `json:"detail"`
-	Title  string `json:"title"`
-	Code   int    `json:"code"`
+func PollJobAsFailed(jobPath string) capi_client.Job {
+	return waitForJob(jobPath, capi_client.JobStateFailed)
 }
 
-func GetJobErrors(jobPath string) []jobError {
-	session := cf.Cf("curl", "-f", jobPath).Wait()
-	var job struct {
-		GUID   string     `json:"guid"`
-		Errors []jobError `json:"errors"`
-	}
-
-	bytes := session.Wait().Out.Contents()
-	err := json.Unmarshal(bytes, &job)
+func GetJobErrors(jobPath string) []capi_client.Error {
+	job, err := CAPIClient().GetJob(jobPath)
 	Expect(err).NotTo(HaveOccurred())
 	return job.Errors
 }
+
+func GetJobWarnings(jobPath string) []capi_client.JobWarning {
+	job, err := CAPIClient().GetJob(jobPath)
+	Expect(err).NotTo(HaveOccurred())
+	return job.Warnings
+}
+
+func waitForJob(jobPath, expectedState string) capi_client.Job {
+	poll, err := CAPIClient().WaitForJob(jobPath, expectedState, Config.AsyncServiceOperationTimeoutDuration(), jobPollingInterval)
+	Expect(err).NotTo(HaveOccurred(), "job states observed: %v", poll.States)
+	return poll.Job
+}
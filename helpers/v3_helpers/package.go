@@ -1,27 +1,19 @@
 package v3_helpers
 
 import (
-	"encoding/json"
 	"fmt"
 
-	"github.com/cloudfoundry/cf-test-helpers/v2/cf"
-	"github.com/cloudfoundry/cf-test-helpers/v2/helpers"
 	. "github.com/cloudfoundry/capi-bara-tests/bara_suite_helpers"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/capi_client"
+	"github.com/cloudfoundry/cf-test-helpers/v2/helpers"
 	. "github.com/onsi/gomega"
-	. "github.com/onsi/gomega/gbytes"
 	. "github.com/onsi/gomega/gexec"
 )
 
 func CreatePackage(appGUID string) string {
-	packageCreateURL := fmt.Sprintf("/v3/packages")
-	session := cf.Cf("curl", "-f", packageCreateURL, "-X", "POST", "-d", fmt.Sprintf(`{"relationships":{"app":{"data":{"guid":"%s"}}},"type":"bits"}`, appGUID))
-	bytes := session.Wait().Out.Contents()
-	var pac struct {
-		GUID string `json:"guid"`
-	}
-	err := json.Unmarshal(bytes, &pac)
+	pkg, err := CAPIClient().CreatePackage(appGUID)
 	Expect(err).NotTo(HaveOccurred())
-	return pac.GUID
+	return pkg.GUID
 }
 
 func UploadPackage(uploadURL, packageZipPath string) {
@@ -31,10 +23,9 @@ func UploadPackage(uploadURL, packageZipPath string) {
 }
 
 func WaitForPackageToBeReady(packageGUID string) {
-	pkgURL := fmt.Sprintf("/v3/packages/%s", packageGUID)
-	Eventually(func() *Session {
-		session := cf.Cf("curl", "-f", pkgURL)
-		Expect(session.Wait()).To(Exit(0))
-		return session
-	}, Config.LongCurlTimeoutDuration()).Should(Say("READY"))
+	Eventually(func() string {
+		pkg, err := CAPIClient().GetPackage(packageGUID)
+		Expect(err).NotTo(HaveOccurred())
+		return pkg.State
+	}, Config.LongCurlTimeoutDuration()).Should(Equal(capi_client.PackageStateReady))
 }
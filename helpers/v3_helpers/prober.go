@@ -0,0 +1,40 @@
+package v3_helpers
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cloudfoundry/capi-bara-tests/helpers/prober"
+
+	. "github.com/cloudfoundry/capi-bara-tests/bara_suite_helpers"
+)
+
+const probeRequestTimeout = 5 * time.Second
+
+// ProbeApp starts requesting path on the app's default route every
+// interval. Stop the probe before the spec ends.
+func ProbeApp(appName, path string, interval time.Duration) *prober.Probe {
+	probe := routeProber(appName, path)
+	probe.Interval = interval
+	return probe.Start()
+}
+
+// SendRequests sends requests to path on the route with the given host in
+// the apps domain, one after the other.
+func SendRequests(host, path string, requests int) prober.Report {
+	return routeProber(host, path).Send(requests)
+}
+
+func routeProber(host, path string) prober.Prober {
+	return prober.Prober{
+		URL: fmt.Sprintf("%s%s.%s%s", Config.Protocol(), host, Config.GetAppsDomain(), path),
+		Client: &http.Client{
+			Timeout: probeRequestTimeout,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: Config.GetSkipSSLValidation()},
+			},
+		},
+	}
+}
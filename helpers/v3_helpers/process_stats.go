@@ -0,0 +1,96 @@
+package v3_helpers
+
+import (
+	"sort"
+	"time"
+
+	"github.com/cloudfoundry/capi-bara-tests/helpers/capi_client"
+
+	. "github.com/cloudfoundry/capi-bara-tests/bara_suite_helpers"
+	. "github.com/onsi/gomega"
+)
+
+// InstanceStats is one instance of a process as its stats report it.
+type InstanceStats = capi_client.ProcessInstanceStats
+
+func GetProcessStats(processGUID string) []InstanceStats {
+	stats, err := CAPIClient().GetProcessStats(processGUID)
+	Expect(err).NotTo(HaveOccurred())
+	return stats
+}
+
+func GetRunningInstancesStats(processGUID string) int {
+	return countInstancesInState(GetProcessStats(processGUID), capi_client.InstanceStateRunning)
+}
+
+// WaitForInstancesInState waits until exactly count instances of the
+// process are in the given state, e.g. capi_client.InstanceStateRunning.
+func WaitForInstancesInState(processGUID, state string, count int) {
+	EventuallyWithOffset(1, func() int {
+		return countInstancesInState(GetProcessStats(processGUID), state)
+	}, Config.CfPushTimeoutDuration(), time.Second).Should(Equal(count), "instances of process %s in state %s", processGUID, state)
+}
+
+// WaitForAllRoutable waits until the process has instances and the router
+// sends traffic to every one of them.
+func WaitForAllRoutable(processGUID string) {
+	EventuallyWithOffset(1, func() []int {
+		stats := GetProcessStats(processGUID)
+		if len(stats) == 0 {
+			return []int{-1}
+		}
+
+		unroutable := []int{}
+		for _, instance := range stats {
+			if !isRoutable(instance) {
+				unroutable = append(unroutable, instance.Index)
+			}
+		}
+		return unroutable
+	}, Config.CfPushTimeoutDuration(), time.Second).Should(BeEmpty(), "indexes of unroutable instances of process %s, -1 if it has none", processGUID)
+}
+
+// InstanceUptimes maps the index of each running instance of the process to
+// how long it has been up.
+func InstanceUptimes(processGUID string) map[int]time.Duration {
+	uptimes := map[int]time.Duration{}
+	for _, instance := range GetProcessStats(processGUID) {
+		if instance.State == capi_client.InstanceStateRunning {
+			uptimes[instance.Index] = time.Duration(instance.Uptime) * time.Second
+		}
+	}
+	return uptimes
+}
+
+// RestartedInstances returns the indexes of the instances in after that
+// were not running in before or have been up for less time since, in
+// increasing order.
+func RestartedInstances(before, after map[int]time.Duration) []int {
+	restarted := []int{}
+	for index, uptime := range after {
+		if previous, ok := before[index]; !ok || uptime < previous {
+			restarted = append(restarted, index)
+		}
+	}
+	sort.Ints(restarted)
+	return restarted
+}
+
+// isRoutable falls back to the instance state on Cloud Controllers that do
+// not report routability.
+func isRoutable(instance InstanceStats) bool {
+	if instance.Routable != nil {
+		return *instance.Routable
+	}
+	return instance.State == capi_client.InstanceStateRunning
+}
+
+func countInstancesInState(stats []InstanceStats, state string) int {
+	count := 0
+	for _, instance := range stats {
+		if instance.State == state {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,100 @@
+package v3_helpers_test
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/cloudfoundry/capi-bara-tests/bara_suite_helpers"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/capi_client"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/fake_cc"
+	. "github.com/cloudfoundry/capi-bara-tests/helpers/v3_helpers"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Process stats", func() {
+	var (
+		fakeCC    *fake_cc.FakeCC
+		appGUID   string
+		webGUID   string
+		instances = 3
+	)
+
+	BeforeEach(func() {
+		fakeCC = fake_cc.New()
+		fakeCC.PollsPerTransition = 0
+		Config = fakeCC.Config()
+
+		zipPath := filepath.Join(GinkgoT().TempDir(), "app.zip")
+		Expect(os.WriteFile(zipPath, []byte("not really a zip"), 0644)).To(Succeed())
+
+		appGUID = CreateApp("some-app", "space-guid", `{}`)
+		packageGUID := CreatePackage(appGUID)
+		_, err := CAPIClient().UploadPackageBits(packageGUID, zipPath)
+		Expect(err).NotTo(HaveOccurred())
+		WaitForPackageToBeReady(packageGUID)
+
+		buildGUID := StagePackage(packageGUID, "buildpack", "ruby_buildpack")
+		WaitForBuildToStage(buildGUID)
+		AssignDropletToApp(appGUID, GetDropletFromBuild(buildGUID))
+		ScaleApp(appGUID, instances)
+		webGUID = GetProcessGuidsForType(appGUID, "web")[0]
+	})
+
+	AfterEach(func() {
+		fakeCC.Close()
+	})
+
+	It("reports each instance in full", func() {
+		StartApp(appGUID)
+
+		stats := GetProcessStats(webGUID)
+		Expect(stats).To(HaveLen(instances))
+		Expect(stats[2].Index).To(Equal(2))
+		Expect(stats[2].State).To(Equal(capi_client.InstanceStateRunning))
+		Expect(stats[2].Host).NotTo(BeEmpty())
+		Expect(stats[2].Uptime).To(BeNumerically(">", 0))
+		process, err := CAPIClient().GetProcess(webGUID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*stats[2].MemQuota).To(Equal(int64(process.MemoryInMB) * 1024 * 1024))
+		Expect(stats[2].Usage.Mem).To(BeNumerically(">", 0))
+		Expect(stats[2].Usage.Time).NotTo(BeNil())
+		Expect(stats[2].InstancePorts).To(ConsistOf(capi_client.InstancePort{External: 61002, Internal: 8080}))
+		Expect(*stats[2].Routable).To(BeTrue())
+		Expect(GetRunningInstancesStats(webGUID)).To(Equal(instances))
+	})
+
+	It("waits for instances to be running and routable", func() {
+		StartApp(appGUID)
+
+		WaitForInstancesInState(webGUID, capi_client.InstanceStateRunning, instances)
+		WaitForAllRoutable(webGUID)
+	})
+
+	It("reports stopped instances as down and not routable", func() {
+		WaitForInstancesInState(webGUID, capi_client.InstanceStateDown, instances)
+		Expect(InstanceUptimes(webGUID)).To(BeEmpty())
+		Expect(*GetProcessStats(webGUID)[0].Routable).To(BeFalse())
+	})
+
+	It("maps running instances to their uptimes", func() {
+		StartApp(appGUID)
+
+		uptimes := InstanceUptimes(webGUID)
+		Expect(uptimes).To(HaveLen(instances))
+		Expect(uptimes[0]).To(BeNumerically(">", 0))
+		Expect(uptimes[0]).To(Equal(uptimes[2]))
+	})
+
+	Describe("RestartedInstances", func() {
+		It("finds the instances whose uptime went down or that were not running", func() {
+			before := map[int]time.Duration{0: time.Minute, 1: time.Minute, 2: time.Minute}
+			after := map[int]time.Duration{0: 2 * time.Minute, 1: time.Second, 3: time.Second}
+
+			Expect(RestartedInstances(before, after)).To(Equal([]int{1, 3}))
+			Expect(RestartedInstances(before, before)).To(BeEmpty())
+		})
+	})
+})
@@ -1,17 +1,13 @@
 package v3_helpers
 
 import (
-	"encoding/json"
-	"fmt"
-	"strings"
+	"strconv"
 
-	"github.com/cloudfoundry/cf-test-helpers/v2/cf"
 	"github.com/cloudfoundry/cf-test-helpers/v2/workflowhelpers"
 
 	. "github.com/cloudfoundry/capi-bara-tests/bara_suite_helpers"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/capi_client"
 	. "github.com/onsi/gomega"
-	. "github.com/onsi/gomega/gbytes"
-	. "github.com/onsi/gomega/gexec"
 )
 
 type ProcessList struct {
@@ -33,19 +29,15 @@ type Process struct {
 }
 
 func GetProcesses(appGUID, appName string) []Process {
-	processesURL := fmt.Sprintf("/v3/apps/%s/processes", appGUID)
-	session := cf.Cf("curl", processesURL)
-	bytes := session.Wait().Out.Contents()
-
-	processes := ProcessList{}
-	err := json.Unmarshal(bytes, &processes)
+	appProcesses, err := CAPIClient().ListAppProcesses(appGUID, capi_client.ListOptions{})
 	Expect(err).NotTo(HaveOccurred())
 
-	for i, _ := range processes.Processes {
-		processes.Processes[i].Name = appName
+	processes := make([]Process, 0, len(appProcesses))
+	for _, process := range appProcesses {
+		processes = append(processes, newProcess(process, appName))
 	}
 
-	return processes.Processes
+	return processes
 }
 
 func GetFirstProcessByType(processes []Process, processType string) Process {
@@ -58,78 +50,50 @@ func GetFirstProcessByType(processes []Process, processType string) Process {
 }
 
 func GetProcessByGuid(processGUID string) Process {
-	processURL := fmt.Sprintf("/v3/processes/%s", processGUID)
-	session := cf.Cf("curl", processURL)
-	bytes := session.Wait().Out.Contents()
-
-	var process Process
-	err := json.Unmarshal(bytes, &process)
+	process, err := CAPIClient().GetProcess(processGUID)
 	Expect(err).NotTo(HaveOccurred())
 
-	return process
+	return newProcess(process, "")
 }
 
 func SetCommandOnProcess(appGUID, processType, command string) {
 	process := GetFirstProcessByType(GetProcesses(appGUID, "appName"), processType)
 
-	processURL := fmt.Sprintf("/v3/processes/%s", process.Guid)
-	processJSON, _ := json.Marshal(map[string]string{"command": command})
-
-	session := cf.Cf("curl", "-f", "-v", "-X", "PATCH", processURL, "-d", string(processJSON)).Wait()
-	Expect(session).To(Say("200 OK"))
+	_, err := CAPIClient().UpdateProcess(process.Guid, capi_client.UpdateProcessRequest{Command: &command})
+	Expect(err).NotTo(HaveOccurred())
 }
 
 func SetHealthCheckTimeoutOnProcess(appGUID, processType string, healthCheckTimeout int) {
 	process := GetFirstProcessByType(GetProcesses(appGUID, "appName"), processType)
 
-	type processHealthCheck struct {
-		HealthCheck struct {
-			Data struct {
-				Timeout int `json:"timeout"`
-			} `json:"data"`
-		} `json:"health_check"`
-	}
-
-	processUpdate := &processHealthCheck{}
-	processUpdate.HealthCheck.Data.Timeout = healthCheckTimeout
-	processURL := fmt.Sprintf("/v3/processes/%s", process.Guid)
-	processJSON, _ := json.Marshal(&processUpdate)
+	healthCheck := &capi_client.HealthCheck{}
+	healthCheck.Data.Timeout = &healthCheckTimeout
 
-	session := cf.Cf("curl", "-f", "-v", "-X", "PATCH", processURL, "-d", string(processJSON)).Wait()
-	Expect(session).To(Say("200 OK"))
+	_, err := CAPIClient().UpdateProcess(process.Guid, capi_client.UpdateProcessRequest{HealthCheck: healthCheck})
+	Expect(err).NotTo(HaveOccurred())
 }
 
 func GetProcessGuidsForType(appGUID string, processType string) []string {
-	processesPath := fmt.Sprintf("/v3/apps/%s/processes?types=%s", appGUID, processType)
-	session := cf.Cf("curl", "-f", processesPath).Wait()
-
-	processesJSON := struct {
-		Resources []struct {
-			Guid string `json:"guid"`
-		} `json:"resources"`
-	}{}
-	bytes := session.Wait().Out.Contents()
-	err := json.Unmarshal(bytes, &processesJSON)
+	processes, err := CAPIClient().ListAppProcesses(appGUID, capi_client.ListOptions{}.Filter("types", processType))
 
 	guids := []string{}
-	if err != nil || len(processesJSON.Resources) == 0 {
+	if err != nil || len(processes) == 0 {
 		return guids
 	}
 
-	for _, resource := range processesJSON.Resources {
-		guids = append(guids, resource.Guid)
+	for _, process := range processes {
+		guids = append(guids, process.GUID)
 	}
 
 	return guids
 }
 
 func ScaleProcess(appGUID, processType, memoryInMb string) {
-	scalePath := fmt.Sprintf("/v3/apps/%s/processes/%s/actions/scale", appGUID, processType)
-	scaleBody := fmt.Sprintf(`{"memory_in_mb":"%s"}`, memoryInMb)
-	session := cf.Cf("curl", "-f", scalePath, "-X", "POST", "-d", scaleBody).Wait()
-	Expect(session).To(Exit(0))
-	result := session.Out.Contents()
-	Expect(strings.Contains(string(result), "errors")).To(BeFalse())
+	memory, err := strconv.Atoi(memoryInMb)
+	Expect(err).NotTo(HaveOccurred())
+
+	_, err = CAPIClient().ScaleAppProcess(appGUID, processType, capi_client.ScaleProcessRequest{MemoryInMB: &memory})
+	Expect(err).NotTo(HaveOccurred())
 }
 
 type ProcessAppUsageEvent struct {
@@ -142,26 +106,40 @@ type ProcessAppUsageEvent struct {
 	} `json:"entity"`
 }
 
-type ProcessAppUsageEvents struct {
-	Resources []ProcessAppUsageEvent `struct:"resources"`
-}
-
 func GetLastAppUseEventForProcess(processType string, state string, afterGUID string) (bool, ProcessAppUsageEvent) {
-	var response ProcessAppUsageEvents
+	var result ProcessAppUsageEvent
+	found := false
+
 	workflowhelpers.AsUser(TestSetup.AdminUserContext(), Config.DefaultTimeoutDuration(), func() {
-		afterGUIDParam := ""
+		options := capi_client.ListOptions{PerPage: 150, OrderBy: "-created_at"}
 		if afterGUID != "" {
-			afterGUIDParam = fmt.Sprintf("&after_guid=%s", afterGUID)
+			options = options.Filter("after_guid", afterGUID)
 		}
-		usageEventsUrl := fmt.Sprintf("/v2/app_usage_events?order-direction=desc&page=1&results-per-page=150%s", afterGUIDParam)
-		workflowhelpers.ApiRequest("GET", usageEventsUrl, &response, Config.DefaultTimeoutDuration())
-	})
 
-	for _, event := range response.Resources {
-		if event.Entity.ProcessType == processType && event.Entity.State == state {
-			return true, event
+		events := CAPIClient().AppUsageEvents(options)
+		for events.Next() {
+			event := events.Value()
+			if event.Process.Type == processType && event.State.Current == state {
+				result.Metadata.Guid = event.GUID
+				result.Entity.ProcessType = event.Process.Type
+				result.Entity.State = event.State.Current
+				found = true
+				return
+			}
 		}
-	}
+		Expect(events.Err()).NotTo(HaveOccurred())
+	})
 
-	return false, ProcessAppUsageEvent{}
+	return found, result
+}
+
+func newProcess(process capi_client.Process, appName string) Process {
+	result := Process{
+		Guid:    process.GUID,
+		Type:    process.Type,
+		Command: process.Command,
+		Name:    appName,
+	}
+	result.Relationships.Revision.Data.Guid = process.Relationships.Revision.GUID()
+	return result
 }
@@ -1,12 +1,9 @@
 package v3_helpers
 
 import (
-	"encoding/json"
-	"fmt"
-
-	"github.com/cloudfoundry/cf-test-helpers/v2/cf"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/capi_client"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/cleanup"
 	. "github.com/onsi/gomega"
-	. "github.com/onsi/gomega/gexec"
 )
 
 type Quota struct {
@@ -14,81 +11,71 @@ type Quota struct {
 	GUID string `json:"guid"`
 	Apps struct {
 		TotalInstances int `json:"total_instances"`
+		PerAppTasks    int `json:"per_app_tasks"`
 	} `json:"apps"`
 }
 
 func CreateOrgQuota(name string, orgGUID string, totalInstances int) Quota {
-	requestPath := fmt.Sprintf("/v3/organization_quotas")
-	requestBody := fmt.Sprintf(`{
-  "name": "%s",
-  "apps": {
-    "total_instances": %v
-  },
-  "relationships": {
-    "organizations": {
-      "data": [
-        {
-          "guid": "%s"
-        }
-      ]
-    }
-  }
-}`, name, totalInstances, orgGUID)
-	session := cf.Cf("curl", "-X", "POST", requestPath, "-d", requestBody, "-f")
-
-	var createdOrgQuota Quota
-	response := session.Wait().Out.Contents()
-	err := json.Unmarshal(response, &createdOrgQuota)
-	Expect(err).ToNot(HaveOccurred())
+	return createOrgQuota(name, orgGUID, capi_client.QuotaApps{TotalInstances: &totalInstances})
+}
 
-	return createdOrgQuota
+// CreateOrgTaskQuota creates an org quota limiting how many tasks each app
+// may have running at once.
+func CreateOrgTaskQuota(name string, orgGUID string, perAppTasks int) Quota {
+	return createOrgQuota(name, orgGUID, capi_client.QuotaApps{PerAppTasks: &perAppTasks})
 }
 
 func CreateSpaceQuota(name string, spaceGUID string, orgGUID string, totalInstances int) Quota {
-	requestPath := fmt.Sprintf("/v3/space_quotas")
-	request := fmt.Sprintf(`{
-  "name": "%s",
-  "apps": {
-    "total_instances": %v
-  },
-  "relationships": {
-    "organization": {
-      "data": {
-        "guid": "%s"
-      }
-    },
-    "spaces": {
-      "data": [
-        {
-          "guid": "%s"
-        }
-      ]
-    }
-  }
-}`, name, totalInstances, orgGUID, spaceGUID)
-	session := cf.Cf("curl", "-X", "POST", requestPath, "-d", request, "-f")
+	quota, err := CAPIClient().CreateSpaceQuota(capi_client.CreateSpaceQuotaRequest{
+		Name: name,
+		Apps: capi_client.QuotaApps{TotalInstances: &totalInstances},
+		Relationships: capi_client.SpaceQuotaRelationships{
+			Organization: capi_client.NewRelationship(orgGUID),
+			Spaces:       capi_client.ToManyRelationship{Data: []capi_client.RelationshipData{{GUID: spaceGUID}}},
+		},
+	})
+	Expect(err).ToNot(HaveOccurred())
+	cleanup.Track(cleanup.SpaceQuota(quota.GUID))
 
-	var createdSpaceQuota Quota
-	response := session.Wait().Out.Contents()
-	err := json.Unmarshal(response, &createdSpaceQuota)
+	return newQuota(quota)
+}
+
+func createOrgQuota(name string, orgGUID string, apps capi_client.QuotaApps) Quota {
+	quota, err := CAPIClient().CreateOrgQuota(capi_client.CreateOrgQuotaRequest{
+		Name: name,
+		Apps: apps,
+		Relationships: capi_client.OrgQuotaRelationships{
+			Organizations: capi_client.ToManyRelationship{Data: []capi_client.RelationshipData{{GUID: orgGUID}}},
+		},
+	})
 	Expect(err).ToNot(HaveOccurred())
+	cleanup.Track(cleanup.OrgQuota(quota.GUID))
 
-	return createdSpaceQuota
+	return newQuota(quota)
 }
 
 func SetDefaultOrgQuota(orgGUID string) {
-	session := cf.Cf("curl", "/v3/organization_quotas?names=default", "-f")
-	bytes := session.Wait().Out.Contents()
-	defaultOrgQuotaGUID := GetGuidFromResponse(bytes)
-
-	path := fmt.Sprintf("v3/organization_quotas/%s/relationships/organizations", defaultOrgQuotaGUID)
-	session = cf.Cf("curl", "-X", "POST", path, "-d", fmt.Sprintf(`{"data": [{"guid": "%s"}]}`, orgGUID), "-f", "-v")
+	quotas, err := CAPIClient().ListOrgQuotas(capi_client.ListOptions{}.Filter("names", "default"))
+	Expect(err).NotTo(HaveOccurred())
+	Expect(quotas).NotTo(BeEmpty(), "no default organization quota")
 
-	Eventually(session).Should(Exit(0))
+	Expect(CAPIClient().ApplyOrgQuota(quotas[0].GUID, orgGUID)).To(Succeed())
 }
 
 func DeleteOrgQuota(orgQuotaGUID string) {
-	path := fmt.Sprintf("v3/organization_quotas/%s", orgQuotaGUID)
-	session := cf.Cf("curl", "-X", "DELETE", path, "-f", "-v")
-	Eventually(session).Should(Exit(0))
+	jobPath, err := CAPIClient().DeleteOrgQuota(orgQuotaGUID)
+	Expect(err).NotTo(HaveOccurred())
+	PollJob(jobPath)
+	cleanup.Forget(cleanup.OrgQuota(orgQuotaGUID))
+}
+
+func newQuota(quota capi_client.Quota) Quota {
+	created := Quota{Name: quota.Name, GUID: quota.GUID}
+	if quota.Apps.TotalInstances != nil {
+		created.Apps.TotalInstances = *quota.Apps.TotalInstances
+	}
+	if quota.Apps.PerAppTasks != nil {
+		created.Apps.PerAppTasks = *quota.Apps.PerAppTasks
+	}
+	return created
 }
@@ -1,12 +1,8 @@
 package v3_helpers
 
 import (
-	"encoding/json"
-	"fmt"
-
-	"github.com/cloudfoundry/cf-test-helpers/v2/cf"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/capi_client"
 	. "github.com/onsi/gomega"
-	. "github.com/onsi/gomega/gexec"
 )
 
 type RevisionList struct {
@@ -27,7 +23,7 @@ type Revision struct {
 		Guid string `json:"guid"`
 	} `json:"droplet"`
 	Processes map[string]map[string]string `json:"processes"`
-	Sidecars  []Sidecar                    `json: "sidecars"`
+	Sidecars  []Sidecar                    `json:"sidecars"`
 }
 
 type RevisionEnvVars struct {
@@ -35,27 +31,22 @@ type RevisionEnvVars struct {
 }
 
 func GetRevisions(appGuid string) []Revision {
-	revisionsURL := fmt.Sprintf("/v3/apps/%s/revisions", appGuid)
-	session := cf.Cf("curl", revisionsURL)
-	bytes := session.Wait().Out.Contents()
-
-	revisions := RevisionList{}
-	err := json.Unmarshal(bytes, &revisions)
+	appRevisions, err := CAPIClient().ListAppRevisions(appGuid, capi_client.ListOptions{})
 	Expect(err).NotTo(HaveOccurred())
 
-	return revisions.Revisions
+	revisions := make([]Revision, 0, len(appRevisions))
+	for _, revision := range appRevisions {
+		revisions = append(revisions, newRevision(revision))
+	}
+
+	return revisions
 }
 
 func GetRevision(revisionGuid string) Revision {
-	revisionsURL := fmt.Sprintf("/v3/revisions/%s", revisionGuid)
-	session := cf.Cf("curl", revisionsURL)
-	bytes := session.Wait().Out.Contents()
-
-	revision := Revision{}
-	err := json.Unmarshal(bytes, &revision)
+	revision, err := CAPIClient().GetRevision(revisionGuid)
 	Expect(err).NotTo(HaveOccurred())
 
-	return revision
+	return newRevision(revision)
 }
 
 func GetNewestRevision(appGuid string) Revision {
@@ -64,19 +55,44 @@ func GetNewestRevision(appGuid string) Revision {
 }
 
 func GetRevisionEnvVars(revisionGuid string) RevisionEnvVars {
-	revisionsEnvVarsURL := fmt.Sprintf("/v3/revisions/%s/environment_variables", revisionGuid)
-	session := cf.Cf("curl", revisionsEnvVarsURL)
-	bytes := session.Wait().Out.Contents()
-
-	envVars := RevisionEnvVars{}
-	err := json.Unmarshal(bytes, &envVars)
+	envVars, err := CAPIClient().GetRevisionEnvironmentVariables(revisionGuid)
 	Expect(err).NotTo(HaveOccurred())
 
-	return envVars
+	revisionEnvVars := RevisionEnvVars{Var: map[string]string{}}
+	for name, value := range envVars.Var {
+		if value != nil {
+			revisionEnvVars.Var[name] = *value
+		}
+	}
+
+	return revisionEnvVars
 }
 
 func EnableRevisions(appGuid string) {
-	path := fmt.Sprintf("/v3/apps/%s/features/revisions", appGuid)
-	curl := cf.Cf("curl", "-f", path, "-X", "PATCH", "-d", `{"enabled": true}`).Wait()
-	Expect(curl).To(Exit(0))
+	err := CAPIClient().SetAppFeature(appGuid, "revisions", true)
+	Expect(err).NotTo(HaveOccurred())
+}
+
+func newRevision(revision capi_client.Revision) Revision {
+	result := Revision{
+		Guid:      revision.GUID,
+		Version:   revision.Version,
+		Processes: map[string]map[string]string{},
+	}
+	result.Droplet.Guid = revision.Droplet.GUID
+
+	for processType, process := range revision.Processes {
+		result.Processes[processType] = map[string]string{"command": process.Command}
+	}
+
+	for _, sidecar := range revision.Sidecars {
+		result.Sidecars = append(result.Sidecars, Sidecar{
+			Name:         sidecar.Name,
+			Command:      sidecar.Command,
+			ProcessTypes: sidecar.ProcessTypes,
+			MemoryInMb:   sidecar.MemoryInMB,
+		})
+	}
+
+	return result
 }
@@ -0,0 +1,194 @@
+package v3_helpers
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry/capi-bara-tests/helpers/capi_client"
+
+	. "github.com/cloudfoundry/capi-bara-tests/bara_suite_helpers"
+	. "github.com/onsi/gomega"
+)
+
+// RolloutSample counts the running instances of an app's old and new web
+// processes at one point during a deployment.
+type RolloutSample struct {
+	At  time.Time
+	Old int
+	New int
+}
+
+func (s RolloutSample) Total() int {
+	return s.Old + s.New
+}
+
+// Rollout is every change in running instances a RolloutObserver saw, in
+// order, starting with the counts when it started.
+type Rollout []RolloutSample
+
+// MinRunning is the fewest instances running at once, e.g. to assert that
+// a rolling deployment never drops below the app's instance count.
+func (r Rollout) MinRunning() int {
+	if len(r) == 0 {
+		return 0
+	}
+	lowest := r[0].Total()
+	for _, sample := range r[1:] {
+		lowest = min(lowest, sample.Total())
+	}
+	return lowest
+}
+
+// MaxRunning is the most instances running at once.
+func (r Rollout) MaxRunning() int {
+	highest := 0
+	for _, sample := range r {
+		highest = max(highest, sample.Total())
+	}
+	return highest
+}
+
+func (r Rollout) String() string {
+	lines := make([]string, 0, len(r))
+	for _, sample := range r {
+		lines = append(lines, fmt.Sprintf("%s old=%d new=%d", sample.At.Format(time.RFC3339Nano), sample.Old, sample.New))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// RolloutObserver polls a deployment in the background until it is
+// finalized, recording a sample whenever the number of running instances
+// of the old or new web processes changes.
+type RolloutObserver struct {
+	client     *capi_client.Client
+	appGUID    string
+	deployment string
+	interval   time.Duration
+
+	stop chan struct{}
+	done chan struct{}
+
+	mu      sync.Mutex
+	rollout Rollout
+	err     error
+}
+
+// ObserveRollout starts observing a deployment of the app. The observer
+// must not outlive the spec, so call Wait or Stop before it ends.
+func ObserveRollout(appGUID, deploymentGUID string, interval time.Duration) *RolloutObserver {
+	// Fetch the token once: the cf CLI is not safe to run from a goroutine
+	// while the spec is using it.
+	token := GetAuthToken()
+	o := &RolloutObserver{
+		client:     capi_client.NewClientFromConfig(Config, func() string { return token }),
+		appGUID:    appGUID,
+		deployment: deploymentGUID,
+		interval:   interval,
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	go o.observe()
+	return o
+}
+
+// Wait blocks until the deployment is finalized and returns what the
+// observer saw.
+func (o *RolloutObserver) Wait() Rollout {
+	EventuallyWithOffset(1, o.done, Config.LongCurlTimeoutDuration()).Should(BeClosed())
+	return o.result(1)
+}
+
+// Stop stops observing, whether or not the deployment is finalized, and
+// returns what the observer saw.
+func (o *RolloutObserver) Stop() Rollout {
+	select {
+	case <-o.done:
+	default:
+		close(o.stop)
+		<-o.done
+	}
+	return o.result(1)
+}
+
+func (o *RolloutObserver) result(offset int) Rollout {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	ExpectWithOffset(offset+1, o.err).NotTo(HaveOccurred())
+	return append(Rollout{}, o.rollout...)
+}
+
+func (o *RolloutObserver) observe() {
+	defer close(o.done)
+
+	ticker := time.NewTicker(o.interval)
+	defer ticker.Stop()
+
+	for {
+		finalized, err := o.sample()
+		if err != nil || finalized {
+			o.mu.Lock()
+			o.err = err
+			o.mu.Unlock()
+			return
+		}
+
+		select {
+		case <-o.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sample records the current counts if they changed and reports whether
+// the deployment is finalized. The deployment is read before the processes,
+// so the counts of the last sample are those the deployment finished with.
+func (o *RolloutObserver) sample() (bool, error) {
+	deployment, err := o.client.GetDeployment(o.deployment)
+	if err != nil {
+		return false, err
+	}
+	newProcesses := map[string]bool{}
+	for _, process := range deployment.NewProcesses {
+		newProcesses[process.GUID] = true
+	}
+
+	processes, err := o.client.ListAppProcesses(o.appGUID, capi_client.ListOptions{}.Filter("types", "web"))
+	if err != nil {
+		return false, err
+	}
+
+	sample := RolloutSample{At: time.Now()}
+	for _, process := range processes {
+		stats, err := o.client.GetProcessStats(process.GUID)
+		if capi_client.IsNotFound(err) {
+			// scaled away between listing and reading its stats
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+
+		running := 0
+		for _, instance := range stats {
+			if instance.State == capi_client.InstanceStateRunning {
+				running++
+			}
+		}
+		if newProcesses[process.GUID] {
+			sample.New += running
+		} else {
+			sample.Old += running
+		}
+	}
+
+	o.mu.Lock()
+	if len(o.rollout) == 0 || o.rollout[len(o.rollout)-1].Old != sample.Old || o.rollout[len(o.rollout)-1].New != sample.New {
+		o.rollout = append(o.rollout, sample)
+	}
+	o.mu.Unlock()
+
+	return deployment.Status.Value == capi_client.DeploymentStatusValueFinalized, nil
+}
@@ -1,58 +1,32 @@
 package v3_helpers
 
 import (
-	"encoding/json"
-	"fmt"
-
-	"github.com/cloudfoundry/cf-test-helpers/v2/cf"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/capi_client"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/cleanup"
 	. "github.com/onsi/gomega"
 )
 
 func CreateRoute(spaceGUID, domainGUID, host string) string {
-	session := cf.Cf(
-		"curl", "-f", "/v3/routes",
-		"-X", "POST",
-		"-d", fmt.Sprintf(`{
-			"host": "%s",
-			"relationships": {
-				"domain": { "data": { "guid": "%s" } },
-				"space": { "data": { "guid": "%s" } }
-			}
-		}`, host, domainGUID, spaceGUID),
-	)
-	bytes := session.Wait().Out.Contents()
-
-	var response struct {
-		GUID string `json:"guid"`
-	}
-	err := json.Unmarshal(bytes, &response)
-	Expect(err).NotTo(HaveOccurred())
-	return response.GUID
+	return CreateRouteWithPath(spaceGUID, domainGUID, host, "")
 }
 
 func CreateRouteWithPath(spaceGUID, domainGUID, host, path string) string {
-	session := cf.Cf(
-		"curl", "-f", "/v3/routes",
-		"-X", "POST",
-		"-d", fmt.Sprintf(`{
-			"host": "%s",
-			"path": "%s",
-			"relationships": {
-				"domain": { "data": { "guid": "%s" } },
-				"space": { "data": { "guid": "%s" } }
-			}
-		}`, host, path, domainGUID, spaceGUID),
-	)
-	bytes := session.Wait().Out.Contents()
-
-	var response struct {
-		GUID string `json:"guid"`
-	}
-	err := json.Unmarshal(bytes, &response)
+	route, err := CAPIClient().CreateRoute(capi_client.CreateRouteRequest{
+		Host: host,
+		Path: path,
+		Relationships: capi_client.RouteRelationships{
+			Domain: capi_client.NewRelationship(domainGUID),
+			Space:  capi_client.NewRelationship(spaceGUID),
+		},
+	})
 	Expect(err).NotTo(HaveOccurred())
-	return response.GUID
+	cleanup.Track(cleanup.Route(route.GUID))
+	return route.GUID
 }
 
 func DeleteRoute(routeGUID string) {
-	HandleAsyncRequest(fmt.Sprintf("/v3/routes/%s", routeGUID), "DELETE")
+	jobPath, err := CAPIClient().DeleteRoute(routeGUID)
+	Expect(err).NotTo(HaveOccurred())
+	PollJob(jobPath)
+	cleanup.Forget(cleanup.Route(routeGUID))
 }
@@ -0,0 +1,137 @@
+package v3_helpers
+
+import (
+	"github.com/cloudfoundry/capi-bara-tests/helpers/capi_client"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/cleanup"
+
+	. "github.com/cloudfoundry/capi-bara-tests/bara_suite_helpers"
+	. "github.com/onsi/gomega"
+)
+
+// ServiceCredentialBinding is an app binding or, with no AppGUID, a
+// service key.
+type ServiceCredentialBinding struct {
+	GUID                string
+	Name                string
+	Type                string
+	AppGUID             string
+	ServiceInstanceGUID string
+	LastOperation       LastOperation
+}
+
+// BindServiceInstanceToApp binds the app and waits until the broker has
+// returned credentials for it.
+func BindServiceInstanceToApp(instanceGUID, appGUID string, parameters map[string]interface{}) ServiceCredentialBinding {
+	app := capi_client.NewRelationship(appGUID)
+	return createServiceCredentialBinding(capi_client.CreateServiceCredentialBindingRequest{
+		Type:       capi_client.ServiceCredentialBindingTypeApp,
+		Parameters: parameters,
+		Relationships: capi_client.ServiceCredentialBindingRelationships{
+			App:             &app,
+			ServiceInstance: capi_client.NewRelationship(instanceGUID),
+		},
+	}, capi_client.ListOptions{}.Filter("app_guids", appGUID))
+}
+
+// CreateServiceKey creates a key for the managed instance and waits until
+// the broker has returned its credentials.
+func CreateServiceKey(instanceGUID, name string, parameters map[string]interface{}) ServiceCredentialBinding {
+	return createServiceCredentialBinding(capi_client.CreateServiceCredentialBindingRequest{
+		Type:          capi_client.ServiceCredentialBindingTypeKey,
+		Name:          name,
+		Parameters:    parameters,
+		Relationships: capi_client.ServiceCredentialBindingRelationships{ServiceInstance: capi_client.NewRelationship(instanceGUID)},
+	}, capi_client.ListOptions{}.Filter("names", name))
+}
+
+func GetServiceCredentialBinding(bindingGUID string) ServiceCredentialBinding {
+	binding, err := CAPIClient().GetServiceCredentialBinding(bindingGUID)
+	Expect(err).NotTo(HaveOccurred())
+	return newServiceCredentialBinding(binding)
+}
+
+// ListServiceCredentialBindings returns the app bindings and keys of the
+// instance, oldest first.
+func ListServiceCredentialBindings(instanceGUID string) []ServiceCredentialBinding {
+	bindings, err := CAPIClient().ListServiceCredentialBindings(capi_client.ListOptions{}.Filter("service_instance_guids", instanceGUID))
+	Expect(err).NotTo(HaveOccurred())
+
+	result := make([]ServiceCredentialBinding, 0, len(bindings))
+	for _, binding := range bindings {
+		result = append(result, newServiceCredentialBinding(binding))
+	}
+	return result
+}
+
+func GetServiceCredentialBindingCredentials(bindingGUID string) map[string]interface{} {
+	details, err := CAPIClient().GetServiceCredentialBindingDetails(bindingGUID)
+	Expect(err).NotTo(HaveOccurred())
+	return details.Credentials
+}
+
+func GetServiceCredentialBindingParameters(bindingGUID string) map[string]interface{} {
+	parameters, err := CAPIClient().GetServiceCredentialBindingParameters(bindingGUID)
+	Expect(err).NotTo(HaveOccurred())
+	return parameters
+}
+
+// DeleteServiceCredentialBinding deletes the binding, waiting for the
+// broker to unbind it from a managed instance.
+func DeleteServiceCredentialBinding(bindingGUID string) {
+	jobPath, err := CAPIClient().DeleteServiceCredentialBinding(bindingGUID)
+	Expect(err).NotTo(HaveOccurred())
+	if jobPath != "" {
+		PollJob(jobPath)
+	}
+	cleanup.Forget(cleanup.ServiceCredentialBinding(bindingGUID))
+}
+
+// WaitForServiceCredentialBindingLastOperation polls the binding until its
+// last operation is of the given type and in the given state.
+func WaitForServiceCredentialBindingLastOperation(bindingGUID, operationType, state string) ServiceCredentialBinding {
+	var binding ServiceCredentialBinding
+	EventuallyWithOffset(1, func() LastOperation {
+		binding = GetServiceCredentialBinding(bindingGUID)
+		return binding.LastOperation
+	}, Config.AsyncServiceOperationTimeoutDuration(), jobPollingInterval).Should(And(
+		HaveField("Type", operationType),
+		HaveField("State", state),
+	))
+	return binding
+}
+
+// createServiceCredentialBinding finds a binding Cloud Controller created
+// in a job with the given filter, which with the instance identifies it.
+func createServiceCredentialBinding(request capi_client.CreateServiceCredentialBindingRequest, identify capi_client.ListOptions) ServiceCredentialBinding {
+	binding, jobPath, err := CAPIClient().CreateServiceCredentialBinding(request)
+	Expect(err).NotTo(HaveOccurred())
+
+	if jobPath != "" {
+		bindings, err := CAPIClient().ListServiceCredentialBindings(identify.
+			Filter("service_instance_guids", request.Relationships.ServiceInstance.GUID()))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(bindings).To(HaveLen(1))
+		binding = bindings[0]
+		cleanup.Track(cleanup.ServiceCredentialBinding(binding.GUID))
+
+		PollJob(jobPath)
+		return GetServiceCredentialBinding(binding.GUID)
+	}
+
+	cleanup.Track(cleanup.ServiceCredentialBinding(binding.GUID))
+	return newServiceCredentialBinding(binding)
+}
+
+func newServiceCredentialBinding(binding capi_client.ServiceCredentialBinding) ServiceCredentialBinding {
+	result := ServiceCredentialBinding{
+		GUID:                binding.GUID,
+		Name:                binding.Name,
+		Type:                binding.Type,
+		ServiceInstanceGUID: binding.Relationships.ServiceInstance.GUID(),
+		LastOperation:       binding.LastOperation,
+	}
+	if binding.Relationships.App != nil {
+		result.AppGUID = binding.Relationships.App.GUID()
+	}
+	return result
+}
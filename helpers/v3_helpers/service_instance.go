@@ -0,0 +1,196 @@
+package v3_helpers
+
+import (
+	"github.com/cloudfoundry/capi-bara-tests/helpers/capi_client"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/cleanup"
+
+	. "github.com/cloudfoundry/capi-bara-tests/bara_suite_helpers"
+	. "github.com/onsi/gomega"
+)
+
+type LastOperation = capi_client.LastOperation
+
+type ServiceInstance struct {
+	GUID                   string
+	Name                   string
+	Type                   string
+	Tags                   []string
+	SpaceGUID              string
+	ServicePlanGUID        string
+	LastOperation          LastOperation
+	MaintenanceInfoVersion string
+	UpgradeAvailable       bool
+}
+
+type ManagedServiceInstanceOptions struct {
+	Name            string
+	SpaceGUID       string
+	ServicePlanGUID string
+	Parameters      map[string]interface{}
+	Tags            []string
+}
+
+type UserProvidedServiceInstanceOptions struct {
+	Name            string
+	SpaceGUID       string
+	Credentials     map[string]interface{}
+	SyslogDrainURL  string
+	RouteServiceURL string
+	Tags            []string
+}
+
+// CreateManagedServiceInstance provisions an instance and waits until the
+// broker has finished, however long an asynchronous plan takes.
+func CreateManagedServiceInstance(options ManagedServiceInstanceOptions) ServiceInstance {
+	instanceGUID, jobPath := StartCreatingManagedServiceInstance(options)
+	PollJob(jobPath)
+	return GetServiceInstance(instanceGUID)
+}
+
+// StartCreatingManagedServiceInstance returns the GUID of the instance and
+// the path of the job provisioning it as soon as Cloud Controller accepts
+// the request, for specs observing an asynchronous operation.
+func StartCreatingManagedServiceInstance(options ManagedServiceInstanceOptions) (string, string) {
+	plan := capi_client.NewRelationship(options.ServicePlanGUID)
+	_, jobPath, err := CAPIClient().CreateServiceInstance(capi_client.CreateServiceInstanceRequest{
+		Type:       capi_client.ServiceInstanceTypeManaged,
+		Name:       options.Name,
+		Tags:       options.Tags,
+		Parameters: options.Parameters,
+		Relationships: capi_client.ServiceInstanceRelationships{
+			Space:       capi_client.NewRelationship(options.SpaceGUID),
+			ServicePlan: &plan,
+		},
+	})
+	Expect(err).NotTo(HaveOccurred())
+	Expect(jobPath).NotTo(BeEmpty(), "expected Cloud Controller to provision the instance in a job")
+
+	instances, err := CAPIClient().ListServiceInstances(capi_client.ListOptions{}.
+		Filter("names", options.Name).
+		Filter("space_guids", options.SpaceGUID))
+	Expect(err).NotTo(HaveOccurred())
+	Expect(instances).To(HaveLen(1))
+	cleanup.Track(cleanup.ServiceInstance(instances[0].GUID))
+
+	return instances[0].GUID, jobPath
+}
+
+func CreateUserProvidedServiceInstance(options UserProvidedServiceInstanceOptions) ServiceInstance {
+	instance, _, err := CAPIClient().CreateServiceInstance(capi_client.CreateServiceInstanceRequest{
+		Type:            capi_client.ServiceInstanceTypeUserProvided,
+		Name:            options.Name,
+		Tags:            options.Tags,
+		Credentials:     options.Credentials,
+		SyslogDrainURL:  options.SyslogDrainURL,
+		RouteServiceURL: options.RouteServiceURL,
+		Relationships:   capi_client.ServiceInstanceRelationships{Space: capi_client.NewRelationship(options.SpaceGUID)},
+	})
+	Expect(err).NotTo(HaveOccurred())
+	cleanup.Track(cleanup.ServiceInstance(instance.GUID))
+	return newServiceInstance(instance)
+}
+
+func GetServiceInstance(instanceGUID string) ServiceInstance {
+	instance, err := CAPIClient().GetServiceInstance(instanceGUID)
+	Expect(err).NotTo(HaveOccurred())
+	return newServiceInstance(instance)
+}
+
+// GetServiceInstanceParameters returns the parameters the broker reports
+// for a managed instance.
+func GetServiceInstanceParameters(instanceGUID string) map[string]interface{} {
+	parameters, err := CAPIClient().GetServiceInstanceParameters(instanceGUID)
+	Expect(err).NotTo(HaveOccurred())
+	return parameters
+}
+
+func GetUserProvidedServiceInstanceCredentials(instanceGUID string) map[string]interface{} {
+	credentials, err := CAPIClient().GetServiceInstanceCredentials(instanceGUID)
+	Expect(err).NotTo(HaveOccurred())
+	return credentials
+}
+
+// UpdateServiceInstanceParameters sends the parameters to the broker and
+// waits until it has applied them.
+func UpdateServiceInstanceParameters(instanceGUID string, parameters map[string]interface{}) ServiceInstance {
+	return updateServiceInstance(instanceGUID, capi_client.UpdateServiceInstanceRequest{Parameters: parameters})
+}
+
+// UpdateServiceInstancePlan moves a managed instance to another plan and
+// waits until the broker has done so.
+func UpdateServiceInstancePlan(instanceGUID, planGUID string) ServiceInstance {
+	return updateServiceInstance(instanceGUID, capi_client.UpdateServiceInstanceRequest{
+		Relationships: &capi_client.UpdateServiceInstanceRelationships{ServicePlan: capi_client.NewRelationship(planGUID)},
+	})
+}
+
+// UpgradeServiceInstance upgrades a managed instance to its plan's
+// maintenance_info version, as `cf upgrade-service` does, and waits until
+// the broker has done so.
+func UpgradeServiceInstance(instanceGUID string) ServiceInstance {
+	PollJob(StartUpgradingServiceInstance(instanceGUID))
+	return GetServiceInstance(instanceGUID)
+}
+
+// StartUpgradingServiceInstance returns the path of the job upgrading the
+// instance, for specs expecting the broker to fail the upgrade.
+func StartUpgradingServiceInstance(instanceGUID string) string {
+	plan := GetServicePlan(GetServiceInstance(instanceGUID).ServicePlanGUID)
+	_, jobPath, err := CAPIClient().UpdateServiceInstance(instanceGUID, capi_client.UpdateServiceInstanceRequest{
+		MaintenanceInfo: &capi_client.MaintenanceInfo{Version: plan.MaintenanceInfoVersion},
+	})
+	Expect(err).NotTo(HaveOccurred())
+	return jobPath
+}
+
+// DeleteServiceInstance deletes the instance and its bindings, waiting for
+// the broker to deprovision a managed instance.
+func DeleteServiceInstance(instanceGUID string) {
+	jobPath, err := CAPIClient().DeleteServiceInstance(instanceGUID)
+	Expect(err).NotTo(HaveOccurred())
+	if jobPath != "" {
+		PollJob(jobPath)
+	}
+	cleanup.Forget(cleanup.ServiceInstance(instanceGUID))
+}
+
+// WaitForServiceInstanceLastOperation polls the instance until its last
+// operation is of the given type, e.g. "create", and in the given state,
+// e.g. "succeeded", and returns the instance as it was then.
+func WaitForServiceInstanceLastOperation(instanceGUID, operationType, state string) ServiceInstance {
+	var instance ServiceInstance
+	EventuallyWithOffset(1, func() LastOperation {
+		instance = GetServiceInstance(instanceGUID)
+		return instance.LastOperation
+	}, Config.AsyncServiceOperationTimeoutDuration(), jobPollingInterval).Should(And(
+		HaveField("Type", operationType),
+		HaveField("State", state),
+	))
+	return instance
+}
+
+func updateServiceInstance(instanceGUID string, request capi_client.UpdateServiceInstanceRequest) ServiceInstance {
+	_, jobPath, err := CAPIClient().UpdateServiceInstance(instanceGUID, request)
+	Expect(err).NotTo(HaveOccurred())
+	if jobPath != "" {
+		PollJob(jobPath)
+	}
+	return GetServiceInstance(instanceGUID)
+}
+
+func newServiceInstance(instance capi_client.ServiceInstance) ServiceInstance {
+	result := ServiceInstance{
+		GUID:                   instance.GUID,
+		Name:                   instance.Name,
+		Type:                   instance.Type,
+		Tags:                   instance.Tags,
+		SpaceGUID:              instance.Relationships.Space.GUID(),
+		LastOperation:          instance.LastOperation,
+		MaintenanceInfoVersion: instance.MaintenanceInfo.Version,
+		UpgradeAvailable:       instance.UpgradeAvailable,
+	}
+	if instance.Relationships.ServicePlan != nil {
+		result.ServicePlanGUID = instance.Relationships.ServicePlan.GUID()
+	}
+	return result
+}
@@ -0,0 +1,256 @@
+package v3_helpers_test
+
+import (
+	"time"
+
+	. "github.com/cloudfoundry/capi-bara-tests/bara_suite_helpers"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/capi_client"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/cleanup"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/fake_cc"
+	. "github.com/cloudfoundry/capi-bara-tests/helpers/v3_helpers"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Service instances", func() {
+	var (
+		fakeCC            *fake_cc.FakeCC
+		syncPlanGUID      string
+		asyncPlanGUID     string
+		versionedPlanGUID string
+	)
+
+	BeforeEach(func() {
+		fakeCC = fake_cc.New()
+		Config = fakeCC.Config()
+		cleanup.Reset()
+
+		brokerGUID := fakeCC.AddNamed("service_brokers", "some-broker", time.Now())
+		offeringGUID := fakeCC.AddServiceOffering(brokerGUID, "some-service",
+			fake_cc.ServicePlan{Name: "sync"},
+			fake_cc.ServicePlan{Name: "async", Async: true},
+			fake_cc.ServicePlan{Name: "versioned", Async: true, MaintenanceInfoVersion: "1.0.0"},
+		)
+		syncPlanGUID = GetServicePlanByName(offeringGUID, "sync").GUID
+		asyncPlanGUID = GetServicePlanByName(offeringGUID, "async").GUID
+		versionedPlanGUID = GetServicePlanByName(offeringGUID, "versioned").GUID
+	})
+
+	AfterEach(func() {
+		cleanup.Reset()
+		fakeCC.Close()
+	})
+
+	Describe("managed instances", func() {
+		It("creates an instance and reads its parameters from the broker", func() {
+			instance := CreateManagedServiceInstance(ManagedServiceInstanceOptions{
+				Name:            "some-instance",
+				SpaceGUID:       "space-guid",
+				ServicePlanGUID: syncPlanGUID,
+				Parameters:      map[string]interface{}{"size": "small"},
+				Tags:            []string{"db"},
+			})
+
+			Expect(instance.Type).To(Equal(capi_client.ServiceInstanceTypeManaged))
+			Expect(instance.SpaceGUID).To(Equal("space-guid"))
+			Expect(instance.ServicePlanGUID).To(Equal(syncPlanGUID))
+			Expect(instance.Tags).To(Equal([]string{"db"}))
+			Expect(instance.LastOperation.Type).To(Equal(capi_client.LastOperationTypeCreate))
+			Expect(instance.LastOperation.State).To(Equal(capi_client.LastOperationStateSucceeded))
+			Expect(GetServiceInstanceParameters(instance.GUID)).To(Equal(map[string]interface{}{"size": "small"}))
+			Expect(trackedResources()).To(ConsistOf(cleanup.ServiceInstance(instance.GUID).String()))
+		})
+
+		It("waits for an asynchronous plan to finish provisioning", func() {
+			instanceGUID, jobPath := StartCreatingManagedServiceInstance(ManagedServiceInstanceOptions{
+				Name:            "some-instance",
+				SpaceGUID:       "space-guid",
+				ServicePlanGUID: asyncPlanGUID,
+			})
+			Expect(jobPath).NotTo(BeEmpty())
+			Expect(GetServiceInstance(instanceGUID).LastOperation.State).To(Equal(capi_client.LastOperationStateInProgress))
+
+			instance := WaitForServiceInstanceLastOperation(instanceGUID, capi_client.LastOperationTypeCreate, capi_client.LastOperationStateSucceeded)
+			Expect(instance.GUID).To(Equal(instanceGUID))
+			Expect(PollJob(jobPath).State).To(Equal(capi_client.JobStateComplete))
+		})
+
+		It("sees a failed operation", func() {
+			fakeCC.FailServiceInstance("doomed-instance", "out of capacity")
+			instanceGUID, jobPath := StartCreatingManagedServiceInstance(ManagedServiceInstanceOptions{
+				Name:            "doomed-instance",
+				SpaceGUID:       "space-guid",
+				ServicePlanGUID: asyncPlanGUID,
+			})
+
+			instance := WaitForServiceInstanceLastOperation(instanceGUID, capi_client.LastOperationTypeCreate, capi_client.LastOperationStateFailed)
+			Expect(instance.LastOperation.Description).To(Equal("out of capacity"))
+			Expect(PollJobAsFailed(jobPath).Errors[0].Detail).To(ContainSubstring("out of capacity"))
+		})
+
+		It("updates the parameters and plan", func() {
+			instance := CreateManagedServiceInstance(ManagedServiceInstanceOptions{
+				Name:            "some-instance",
+				SpaceGUID:       "space-guid",
+				ServicePlanGUID: syncPlanGUID,
+				Parameters:      map[string]interface{}{"size": "small"},
+			})
+
+			instance = UpdateServiceInstanceParameters(instance.GUID, map[string]interface{}{"size": "large"})
+			Expect(instance.LastOperation.Type).To(Equal(capi_client.LastOperationTypeUpdate))
+			Expect(GetServiceInstanceParameters(instance.GUID)).To(Equal(map[string]interface{}{"size": "large"}))
+
+			instance = UpdateServiceInstancePlan(instance.GUID, asyncPlanGUID)
+			Expect(instance.ServicePlanGUID).To(Equal(asyncPlanGUID))
+			Expect(instance.LastOperation.State).To(Equal(capi_client.LastOperationStateSucceeded))
+		})
+
+		It("deletes the instance along with its bindings", func() {
+			instance := CreateManagedServiceInstance(ManagedServiceInstanceOptions{
+				Name:            "some-instance",
+				SpaceGUID:       "space-guid",
+				ServicePlanGUID: asyncPlanGUID,
+			})
+			CreateServiceKey(instance.GUID, "some-key", nil)
+
+			DeleteServiceInstance(instance.GUID)
+
+			_, err := CAPIClient().GetServiceInstance(instance.GUID)
+			Expect(capi_client.IsNotFound(err)).To(BeTrue())
+			bindings, err := CAPIClient().ListServiceCredentialBindings(capi_client.ListOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(bindings).To(BeEmpty())
+			Expect(cleanup.Tracked()).To(HaveLen(1), "the key is still tracked, and skipped as already deleted")
+		})
+	})
+
+	Describe("upgrades", func() {
+		var instance ServiceInstance
+
+		BeforeEach(func() {
+			instance = CreateManagedServiceInstance(ManagedServiceInstanceOptions{
+				Name:            "some-instance",
+				SpaceGUID:       "space-guid",
+				ServicePlanGUID: versionedPlanGUID,
+			})
+			Expect(instance.MaintenanceInfoVersion).To(Equal("1.0.0"))
+			Expect(instance.UpgradeAvailable).To(BeFalse())
+
+			fakeCC.SetServicePlanMaintenanceInfo(versionedPlanGUID, "2.0.0")
+			Expect(GetServicePlan(versionedPlanGUID).MaintenanceInfoVersion).To(Equal("2.0.0"))
+			Expect(GetServiceInstance(instance.GUID).UpgradeAvailable).To(BeTrue())
+		})
+
+		It("upgrades the instance to its plan's version", func() {
+			instance = UpgradeServiceInstance(instance.GUID)
+			Expect(instance.MaintenanceInfoVersion).To(Equal("2.0.0"))
+			Expect(instance.UpgradeAvailable).To(BeFalse())
+			Expect(instance.LastOperation.Type).To(Equal(capi_client.LastOperationTypeUpdate))
+			Expect(instance.LastOperation.State).To(Equal(capi_client.LastOperationStateSucceeded))
+		})
+
+		It("leaves the instance at its version when the broker fails the upgrade", func() {
+			fakeCC.FailServiceInstance("some-instance", "version rejected")
+
+			job := PollJobAsFailed(StartUpgradingServiceInstance(instance.GUID))
+			Expect(job.Errors[0].Detail).To(ContainSubstring("version rejected"))
+
+			instance = GetServiceInstance(instance.GUID)
+			Expect(instance.MaintenanceInfoVersion).To(Equal("1.0.0"))
+			Expect(instance.UpgradeAvailable).To(BeTrue())
+			Expect(instance.LastOperation.State).To(Equal(capi_client.LastOperationStateFailed))
+		})
+
+		It("is refused for a version other than the plan's", func() {
+			_, _, err := CAPIClient().UpdateServiceInstance(instance.GUID, capi_client.UpdateServiceInstanceRequest{
+				MaintenanceInfo: &capi_client.MaintenanceInfo{Version: "1.0.0"},
+			})
+			Expect(err).To(MatchError(ContainSubstring("CF-MaintenanceInfoConflict")))
+		})
+	})
+
+	Describe("user-provided instances", func() {
+		It("creates an instance with credentials", func() {
+			instance := CreateUserProvidedServiceInstance(UserProvidedServiceInstanceOptions{
+				Name:        "some-ups",
+				SpaceGUID:   "space-guid",
+				Credentials: map[string]interface{}{"uri": "postgres://example.com"},
+			})
+
+			Expect(instance.Type).To(Equal(capi_client.ServiceInstanceTypeUserProvided))
+			Expect(instance.ServicePlanGUID).To(BeEmpty())
+			Expect(GetUserProvidedServiceInstanceCredentials(instance.GUID)).To(Equal(map[string]interface{}{"uri": "postgres://example.com"}))
+
+			DeleteServiceInstance(instance.GUID)
+			Expect(cleanup.Tracked()).To(BeEmpty())
+		})
+	})
+
+	Describe("credential bindings", func() {
+		var appGUID string
+
+		BeforeEach(func() {
+			appGUID = CreateApp("some-app", "space-guid", `{}`)
+		})
+
+		It("binds a managed instance to an app and reads the credentials", func() {
+			instance := CreateManagedServiceInstance(ManagedServiceInstanceOptions{
+				Name:            "some-instance",
+				SpaceGUID:       "space-guid",
+				ServicePlanGUID: asyncPlanGUID,
+			})
+
+			binding := BindServiceInstanceToApp(instance.GUID, appGUID, map[string]interface{}{"role": "reader"})
+			Expect(binding.Type).To(Equal(capi_client.ServiceCredentialBindingTypeApp))
+			Expect(binding.AppGUID).To(Equal(appGUID))
+			Expect(binding.ServiceInstanceGUID).To(Equal(instance.GUID))
+			Expect(binding.LastOperation.State).To(Equal(capi_client.LastOperationStateSucceeded))
+
+			Expect(GetServiceCredentialBindingCredentials(binding.GUID)).To(HaveKey("password"))
+			Expect(GetServiceCredentialBindingParameters(binding.GUID)).To(Equal(map[string]interface{}{"role": "reader"}))
+			Expect(ListServiceCredentialBindings(instance.GUID)).To(Equal([]ServiceCredentialBinding{binding}))
+
+			DeleteServiceCredentialBinding(binding.GUID)
+			Expect(ListServiceCredentialBindings(instance.GUID)).To(BeEmpty())
+		})
+
+		It("creates a service key", func() {
+			instance := CreateManagedServiceInstance(ManagedServiceInstanceOptions{
+				Name:            "some-instance",
+				SpaceGUID:       "space-guid",
+				ServicePlanGUID: syncPlanGUID,
+			})
+
+			key := CreateServiceKey(instance.GUID, "some-key", nil)
+			Expect(key.Type).To(Equal(capi_client.ServiceCredentialBindingTypeKey))
+			Expect(key.Name).To(Equal("some-key"))
+			Expect(key.AppGUID).To(BeEmpty())
+			Expect(GetServiceCredentialBindingCredentials(key.GUID)).To(HaveKey("username"))
+		})
+
+		It("binds a user-provided instance straight away with its credentials", func() {
+			instance := CreateUserProvidedServiceInstance(UserProvidedServiceInstanceOptions{
+				Name:        "some-ups",
+				SpaceGUID:   "space-guid",
+				Credentials: map[string]interface{}{"uri": "postgres://example.com"},
+			})
+
+			binding := BindServiceInstanceToApp(instance.GUID, appGUID, nil)
+			Expect(GetServiceCredentialBindingCredentials(binding.GUID)).To(Equal(map[string]interface{}{"uri": "postgres://example.com"}))
+
+			DeleteServiceCredentialBinding(binding.GUID)
+			Expect(ListServiceCredentialBindings(instance.GUID)).To(BeEmpty())
+		})
+	})
+})
+
+// trackedResources describes the tracked resources by kind and ID, as their
+// delete funcs cannot be compared.
+func trackedResources() []string {
+	var result []string
+	for _, resource := range cleanup.Tracked() {
+		result = append(result, resource.String())
+	}
+	return result
+}
@@ -0,0 +1,141 @@
+package v3_helpers
+
+import (
+	"github.com/cloudfoundry/capi-bara-tests/helpers/capi_client"
+
+	. "github.com/onsi/gomega"
+)
+
+type ServiceOffering struct {
+	GUID              string
+	Name              string
+	BrokerCatalogID   string
+	ServiceBrokerGUID string
+	Available         bool
+}
+
+// ServicePlan has no MaintenanceInfoVersion if its instances cannot be
+// upgraded.
+type ServicePlan struct {
+	GUID                   string
+	Name                   string
+	BrokerCatalogID        string
+	ServiceOfferingGUID    string
+	VisibilityType         string
+	Free                   bool
+	Available              bool
+	Schemas                capi_client.ServicePlanSchemas
+	MaintenanceInfoVersion string
+}
+
+// ServicePlanVisibility is who may create instances of a plan, with the
+// organizations it is enabled for when its type is "organization".
+type ServicePlanVisibility struct {
+	Type     string
+	OrgGUIDs []string
+}
+
+func GetServiceOfferingByName(name string) ServiceOffering {
+	offerings, err := CAPIClient().ListServiceOfferings(capi_client.ListOptions{}.Filter("names", name))
+	Expect(err).NotTo(HaveOccurred())
+	Expect(offerings).To(HaveLen(1), "expected exactly one service offering named %s", name)
+	return newServiceOffering(offerings[0])
+}
+
+// GetServicePlans returns the offering's plans, oldest first.
+func GetServicePlans(offeringGUID string) []ServicePlan {
+	plans, err := CAPIClient().ListServicePlans(capi_client.ListOptions{}.Filter("service_offering_guids", offeringGUID))
+	Expect(err).NotTo(HaveOccurred())
+
+	result := make([]ServicePlan, 0, len(plans))
+	for _, plan := range plans {
+		result = append(result, newServicePlan(plan))
+	}
+	return result
+}
+
+func GetServicePlanByName(offeringGUID, planName string) ServicePlan {
+	plans, err := CAPIClient().ListServicePlans(capi_client.ListOptions{}.
+		Filter("service_offering_guids", offeringGUID).
+		Filter("names", planName))
+	Expect(err).NotTo(HaveOccurred())
+	Expect(plans).To(HaveLen(1), "expected exactly one service plan named %s", planName)
+	return newServicePlan(plans[0])
+}
+
+func GetServicePlan(planGUID string) ServicePlan {
+	plan, err := CAPIClient().GetServicePlan(planGUID)
+	Expect(err).NotTo(HaveOccurred())
+	return newServicePlan(plan)
+}
+
+func GetServicePlanVisibility(planGUID string) ServicePlanVisibility {
+	visibility, err := CAPIClient().GetServicePlanVisibility(planGUID)
+	Expect(err).NotTo(HaveOccurred())
+	return newServicePlanVisibility(visibility)
+}
+
+// SetServicePlanVisibility replaces the plan's visibility. Organizations
+// are only given for the "organization" type.
+func SetServicePlanVisibility(planGUID, visibilityType string, orgGUIDs ...string) ServicePlanVisibility {
+	visibility, err := CAPIClient().UpdateServicePlanVisibility(planGUID, newServicePlanVisibilityRequest(visibilityType, orgGUIDs))
+	Expect(err).NotTo(HaveOccurred())
+	return newServicePlanVisibility(visibility)
+}
+
+func MakeServicePlanPublic(planGUID string) {
+	SetServicePlanVisibility(planGUID, capi_client.ServicePlanVisibilityPublic)
+}
+
+// EnableServicePlanForOrgs makes the plan visible in the organizations as
+// well as those it is already enabled for.
+func EnableServicePlanForOrgs(planGUID string, orgGUIDs ...string) ServicePlanVisibility {
+	visibility, err := CAPIClient().AppendServicePlanVisibility(planGUID, newServicePlanVisibilityRequest(capi_client.ServicePlanVisibilityOrganization, orgGUIDs))
+	Expect(err).NotTo(HaveOccurred())
+	return newServicePlanVisibility(visibility)
+}
+
+func DisableServicePlanForOrg(planGUID, orgGUID string) {
+	err := CAPIClient().RemoveServicePlanVisibilityOrg(planGUID, orgGUID)
+	Expect(err).NotTo(HaveOccurred())
+}
+
+func newServicePlanVisibilityRequest(visibilityType string, orgGUIDs []string) capi_client.ServicePlanVisibility {
+	visibility := capi_client.ServicePlanVisibility{Type: visibilityType}
+	for _, orgGUID := range orgGUIDs {
+		visibility.Organizations = append(visibility.Organizations, capi_client.ServicePlanVisibilityTarget{GUID: orgGUID})
+	}
+	return visibility
+}
+
+func newServicePlanVisibility(visibility capi_client.ServicePlanVisibility) ServicePlanVisibility {
+	result := ServicePlanVisibility{Type: visibility.Type}
+	for _, org := range visibility.Organizations {
+		result.OrgGUIDs = append(result.OrgGUIDs, org.GUID)
+	}
+	return result
+}
+
+func newServiceOffering(offering capi_client.ServiceOffering) ServiceOffering {
+	return ServiceOffering{
+		GUID:              offering.GUID,
+		Name:              offering.Name,
+		BrokerCatalogID:   offering.BrokerCatalog.ID,
+		ServiceBrokerGUID: offering.Relationships.ServiceBroker.GUID(),
+		Available:         offering.Available,
+	}
+}
+
+func newServicePlan(plan capi_client.ServicePlan) ServicePlan {
+	return ServicePlan{
+		GUID:                   plan.GUID,
+		Name:                   plan.Name,
+		BrokerCatalogID:        plan.BrokerCatalog.ID,
+		ServiceOfferingGUID:    plan.Relationships.ServiceOffering.GUID(),
+		VisibilityType:         plan.VisibilityType,
+		Free:                   plan.Free,
+		Available:              plan.Available,
+		Schemas:                plan.Schemas,
+		MaintenanceInfoVersion: plan.MaintenanceInfo.Version,
+	}
+}
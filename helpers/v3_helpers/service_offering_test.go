@@ -0,0 +1,87 @@
+package v3_helpers_test
+
+import (
+	"time"
+
+	. "github.com/cloudfoundry/capi-bara-tests/bara_suite_helpers"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/capi_client"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/fake_cc"
+	. "github.com/cloudfoundry/capi-bara-tests/helpers/v3_helpers"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Service offerings", func() {
+	var (
+		fakeCC       *fake_cc.FakeCC
+		brokerGUID   string
+		offeringGUID string
+	)
+
+	BeforeEach(func() {
+		fakeCC = fake_cc.New()
+		Config = fakeCC.Config()
+
+		brokerGUID = fakeCC.AddNamed("service_brokers", "some-broker", time.Now())
+		offeringGUID = fakeCC.AddServiceOffering(brokerGUID, "some-service",
+			fake_cc.ServicePlan{Name: "small"},
+			fake_cc.ServicePlan{Name: "large", Async: true},
+		)
+		fakeCC.AddServiceOffering(brokerGUID, "other-service", fake_cc.ServicePlan{Name: "small"})
+	})
+
+	AfterEach(func() {
+		fakeCC.Close()
+	})
+
+	It("finds an offering and its plans by name", func() {
+		offering := GetServiceOfferingByName("some-service")
+		Expect(offering.GUID).To(Equal(offeringGUID))
+		Expect(offering.ServiceBrokerGUID).To(Equal(brokerGUID))
+		Expect(offering.BrokerCatalogID).NotTo(BeEmpty())
+
+		plans := GetServicePlans(offeringGUID)
+		Expect(plans).To(HaveLen(2))
+		Expect(plans[0].Name).To(Equal("small"))
+		Expect(plans[1].Name).To(Equal("large"))
+
+		plan := GetServicePlanByName(offeringGUID, "small")
+		Expect(plan.ServiceOfferingGUID).To(Equal(offeringGUID))
+		Expect(plan.VisibilityType).To(Equal(capi_client.ServicePlanVisibilityAdmin))
+		Expect(GetServicePlan(plan.GUID)).To(Equal(plan))
+	})
+
+	Describe("visibility", func() {
+		var planGUID string
+
+		BeforeEach(func() {
+			planGUID = GetServicePlanByName(offeringGUID, "small").GUID
+		})
+
+		It("makes a plan public", func() {
+			MakeServicePlanPublic(planGUID)
+
+			Expect(GetServicePlanVisibility(planGUID)).To(Equal(ServicePlanVisibility{Type: capi_client.ServicePlanVisibilityPublic}))
+			Expect(GetServicePlan(planGUID).VisibilityType).To(Equal(capi_client.ServicePlanVisibilityPublic))
+		})
+
+		It("enables and disables a plan per organization", func() {
+			SetServicePlanVisibility(planGUID, capi_client.ServicePlanVisibilityOrganization, "org-1")
+			visibility := EnableServicePlanForOrgs(planGUID, "org-2", "org-3")
+			Expect(visibility.OrgGUIDs).To(Equal([]string{"org-1", "org-2", "org-3"}))
+
+			DisableServicePlanForOrg(planGUID, "org-2")
+			Expect(GetServicePlanVisibility(planGUID)).To(Equal(ServicePlanVisibility{
+				Type:     capi_client.ServicePlanVisibilityOrganization,
+				OrgGUIDs: []string{"org-1", "org-3"},
+			}))
+		})
+
+		It("replaces the organizations when setting the visibility", func() {
+			SetServicePlanVisibility(planGUID, capi_client.ServicePlanVisibilityOrganization, "org-1")
+			visibility := SetServicePlanVisibility(planGUID, capi_client.ServicePlanVisibilityOrganization, "org-2")
+			Expect(visibility.OrgGUIDs).To(Equal([]string{"org-2"}))
+		})
+	})
+})
@@ -0,0 +1,138 @@
+package v3_helpers
+
+import (
+	"github.com/cloudfoundry/capi-bara-tests/helpers/capi_client"
+
+	. "github.com/cloudfoundry/capi-bara-tests/bara_suite_helpers"
+	. "github.com/onsi/gomega"
+)
+
+// Task is the state of a task that specs assert on.
+type Task struct {
+	GUID                         string
+	SequenceID                   int
+	Name                         string
+	Command                      string
+	State                        string
+	FailureReason                string
+	MemoryInMB                   int
+	DiskInMB                     int
+	LogRateLimitInBytesPerSecond int
+	DropletGUID                  string
+}
+
+// TaskOptions describes a task to run. Zero values leave the field to Cloud
+// Controller, which takes it from the template process if there is one.
+type TaskOptions struct {
+	Name                         string
+	Command                      string
+	MemoryInMB                   int
+	DiskInMB                     int
+	LogRateLimitInBytesPerSecond int
+	// DropletGUID runs the task on a droplet other than the app's current
+	// one.
+	DropletGUID string
+	// TemplateProcessGUID makes the task inherit the process's command and
+	// limits.
+	TemplateProcessGUID string
+}
+
+func CreateTask(appGUID string, options TaskOptions) Task {
+	task, err := CAPIClient().CreateTask(appGUID, newCreateTaskRequest(options))
+	Expect(err).NotTo(HaveOccurred())
+	return newTask(task)
+}
+
+// CreateTaskExpectingError asserts that Cloud Controller refuses to create
+// the task and returns its reason.
+func CreateTaskExpectingError(appGUID string, options TaskOptions) string {
+	_, err := CAPIClient().CreateTask(appGUID, newCreateTaskRequest(options))
+	Expect(err).To(HaveOccurred())
+	return err.Error()
+}
+
+func GetTask(taskGUID string) Task {
+	task, err := CAPIClient().GetTask(taskGUID)
+	Expect(err).NotTo(HaveOccurred())
+	return newTask(task)
+}
+
+// ListAppTasks returns the app's tasks, oldest first, in any of the given
+// states or in any state if none are given.
+func ListAppTasks(appGUID string, states ...string) []Task {
+	options := capi_client.ListOptions{}
+	if len(states) > 0 {
+		options = options.Filter("states", states...)
+	}
+	tasks, err := CAPIClient().ListAppTasks(appGUID, options)
+	Expect(err).NotTo(HaveOccurred())
+
+	result := make([]Task, 0, len(tasks))
+	for _, task := range tasks {
+		result = append(result, newTask(task))
+	}
+	return result
+}
+
+func CancelTask(taskGUID string) {
+	_, err := CAPIClient().CancelTask(taskGUID)
+	Expect(err).NotTo(HaveOccurred())
+}
+
+// WaitForTaskState polls the task until it reaches the state and returns
+// it as it was then.
+func WaitForTaskState(taskGUID, state string) Task {
+	var task Task
+	EventuallyWithOffset(1, func() string {
+		task = GetTask(taskGUID)
+		return task.State
+	}, Config.LongCurlTimeoutDuration()).Should(Equal(state))
+	return task
+}
+
+func WaitForTaskToSucceed(taskGUID string) Task {
+	return WaitForTaskState(taskGUID, capi_client.TaskStateSucceeded)
+}
+
+func WaitForTaskToFail(taskGUID string) Task {
+	return WaitForTaskState(taskGUID, capi_client.TaskStateFailed)
+}
+
+func newCreateTaskRequest(options TaskOptions) capi_client.CreateTaskRequest {
+	request := capi_client.CreateTaskRequest{
+		Name:        options.Name,
+		Command:     options.Command,
+		DropletGUID: options.DropletGUID,
+	}
+	if options.MemoryInMB != 0 {
+		request.MemoryInMB = &options.MemoryInMB
+	}
+	if options.DiskInMB != 0 {
+		request.DiskInMB = &options.DiskInMB
+	}
+	if options.LogRateLimitInBytesPerSecond != 0 {
+		request.LogRateLimitInBytesPerSecond = &options.LogRateLimitInBytesPerSecond
+	}
+	if options.TemplateProcessGUID != "" {
+		request.Template = &capi_client.TaskTemplate{Process: capi_client.GUIDRef{GUID: options.TemplateProcessGUID}}
+	}
+	return request
+}
+
+func newTask(task capi_client.Task) Task {
+	t := Task{
+		GUID:                         task.GUID,
+		SequenceID:                   task.SequenceID,
+		Name:                         task.Name,
+		Command:                      task.Command,
+		State:                        task.State,
+		MemoryInMB:                   task.MemoryInMB,
+		DiskInMB:                     task.DiskInMB,
+		LogRateLimitInBytesPerSecond: task.LogRateLimitInBytesPerSecond,
+		DropletGUID:                  task.DropletGUID,
+	}
+	if task.Result.FailureReason != nil {
+		t.FailureReason = *task.Result.FailureReason
+	}
+	return t
+}
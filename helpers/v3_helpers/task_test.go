@@ -0,0 +1,137 @@
+package v3_helpers_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/cloudfoundry/capi-bara-tests/bara_suite_helpers"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/capi_client"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/fake_cc"
+	. "github.com/cloudfoundry/capi-bara-tests/helpers/v3_helpers"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Tasks", func() {
+	var (
+		fakeCC      *fake_cc.FakeCC
+		appGUID     string
+		packageGUID string
+		dropletGUID string
+	)
+
+	stageDroplet := func() string {
+		buildGUID := StagePackage(packageGUID, "buildpack", "ruby_buildpack")
+		WaitForBuildToStage(buildGUID)
+		return GetDropletFromBuild(buildGUID)
+	}
+
+	BeforeEach(func() {
+		fakeCC = fake_cc.New()
+		Config = fakeCC.Config()
+
+		zipPath := filepath.Join(GinkgoT().TempDir(), "app.zip")
+		Expect(os.WriteFile(zipPath, []byte("not really a zip"), 0644)).To(Succeed())
+
+		appGUID = CreateApp("some-app", "space-guid", `{}`)
+		packageGUID = CreatePackage(appGUID)
+		_, err := CAPIClient().UploadPackageBits(packageGUID, zipPath)
+		Expect(err).NotTo(HaveOccurred())
+		WaitForPackageToBeReady(packageGUID)
+
+		dropletGUID = stageDroplet()
+		AssignDropletToApp(appGUID, dropletGUID)
+	})
+
+	AfterEach(func() {
+		fakeCC.Close()
+	})
+
+	It("creates a task with the requested command and limits", func() {
+		task := CreateTask(appGUID, TaskOptions{
+			Name:                         "migrate",
+			Command:                      "rake db:migrate",
+			MemoryInMB:                   256,
+			DiskInMB:                     512,
+			LogRateLimitInBytesPerSecond: 1024,
+		})
+
+		Expect(task.GUID).NotTo(BeEmpty())
+		Expect(task.SequenceID).To(Equal(1))
+		Expect(task.Name).To(Equal("migrate"))
+		Expect(task.Command).To(Equal("rake db:migrate"))
+		Expect(task.State).To(Equal(capi_client.TaskStateRunning))
+		Expect(task.MemoryInMB).To(Equal(256))
+		Expect(task.DiskInMB).To(Equal(512))
+		Expect(task.LogRateLimitInBytesPerSecond).To(Equal(1024))
+		Expect(task.DropletGUID).To(Equal(dropletGUID))
+	})
+
+	It("takes the command and limits from a template process", func() {
+		webGUID := GetProcessGuidsForType(appGUID, "web")[0]
+		ScaleProcess(appGUID, "web", "768")
+
+		task := CreateTask(appGUID, TaskOptions{TemplateProcessGUID: webGUID})
+		Expect(task.Command).To(Equal("bundle exec rackup"))
+		Expect(task.MemoryInMB).To(Equal(768))
+
+		task = CreateTask(appGUID, TaskOptions{Command: "echo hi", TemplateProcessGUID: webGUID})
+		Expect(task.Command).To(Equal("echo hi"))
+	})
+
+	It("runs the task on the droplet it is given", func() {
+		newDropletGUID := stageDroplet()
+		AssignDropletToApp(appGUID, newDropletGUID)
+
+		task := CreateTask(appGUID, TaskOptions{Command: "echo hi", DropletGUID: dropletGUID})
+		Expect(task.DropletGUID).To(Equal(dropletGUID))
+		Expect(CreateTask(appGUID, TaskOptions{Command: "echo hi"}).DropletGUID).To(Equal(newDropletGUID))
+	})
+
+	It("waits for a task to succeed", func() {
+		task := CreateTask(appGUID, TaskOptions{Command: "echo hi"})
+
+		task = WaitForTaskToSucceed(task.GUID)
+		Expect(task.State).To(Equal(capi_client.TaskStateSucceeded))
+		Expect(task.FailureReason).To(BeEmpty())
+	})
+
+	It("waits for a task to fail and reports why", func() {
+		fakeCC.FailTask("exit 1", "APP/TASK/some-task: Exited with status 1")
+		task := CreateTask(appGUID, TaskOptions{Command: "exit 1"})
+
+		task = WaitForTaskToFail(task.GUID)
+		Expect(task.FailureReason).To(Equal("APP/TASK/some-task: Exited with status 1"))
+	})
+
+	It("cancels a task", func() {
+		task := CreateTask(appGUID, TaskOptions{Command: "sleep 300"})
+
+		CancelTask(task.GUID)
+		Expect(GetTask(task.GUID).State).To(Equal(capi_client.TaskStateCanceling))
+
+		task = WaitForTaskToFail(task.GUID)
+		Expect(task.FailureReason).To(Equal("task was cancelled"))
+	})
+
+	It("lists the app's tasks, optionally by state", func() {
+		first := CreateTask(appGUID, TaskOptions{Command: "echo one"})
+		second := CreateTask(appGUID, TaskOptions{Command: "sleep 300"})
+		WaitForTaskToSucceed(first.GUID)
+
+		tasks := ListAppTasks(appGUID)
+		Expect(tasks).To(HaveLen(2))
+		Expect(tasks[0].GUID).To(Equal(first.GUID))
+		Expect(tasks[1].SequenceID).To(Equal(2))
+
+		running := ListAppTasks(appGUID, capi_client.TaskStateRunning, capi_client.TaskStatePending)
+		Expect(running).To(HaveLen(1))
+		Expect(running[0].GUID).To(Equal(second.GUID))
+	})
+
+	It("returns why Cloud Controller refused a task", func() {
+		reason := CreateTaskExpectingError(appGUID, TaskOptions{DropletGUID: "unknown-droplet", Command: "echo hi"})
+		Expect(reason).To(ContainSubstring("Droplet must belong to the app."))
+	})
+})
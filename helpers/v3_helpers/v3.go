@@ -5,12 +5,13 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/cloudfoundry/capi-bara-tests/helpers/capi_client"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/cleanup"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/config"
 	"github.com/cloudfoundry/cf-test-helpers/v2/cf"
 	"github.com/cloudfoundry/cf-test-helpers/v2/helpers"
-	"github.com/cloudfoundry/capi-bara-tests/helpers/config"
 
 	. "github.com/cloudfoundry/capi-bara-tests/bara_suite_helpers"
-	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	. "github.com/onsi/gomega/gexec"
 )
@@ -21,55 +22,47 @@ const (
 )
 
 func CreateSidecar(name string, processTypes []string, command string, memoryLimit int, appGuid string) string {
-	sidecarEndpoint := fmt.Sprintf("/v3/apps/%s/sidecars", appGuid)
-	sidecarOneJSON, err := json.Marshal(
-		Sidecar{
-			name,
-			command,
-			processTypes,
-			memoryLimit,
-		},
-	)
-	Expect(err).NotTo(HaveOccurred())
-	session := cf.Cf("curl", "-f", sidecarEndpoint, "-X", "POST", "-d", string(sidecarOneJSON))
-	Eventually(session).Should(Exit(0))
-
-	var sidecarData struct {
-		Guid string `json:"guid"`
-	}
-	err = json.Unmarshal(session.Out.Contents(), &sidecarData)
+	sidecar, err := CAPIClient().CreateSidecar(appGuid, capi_client.CreateSidecarRequest{
+		Name:         name,
+		Command:      command,
+		ProcessTypes: processTypes,
+		MemoryInMB:   memoryLimit,
+	})
 	Expect(err).NotTo(HaveOccurred())
-	return sidecarData.Guid
+	cleanup.Track(cleanup.Sidecar(sidecar.GUID))
+	return sidecar.GUID
 }
 
 func GetAppSidecars(appGuid string) []Sidecar {
-	sidecarEndpoint := fmt.Sprintf("/v3/apps/%s/sidecars", appGuid)
-
-	session := cf.Cf("curl", "-f", sidecarEndpoint)
-	Eventually(session).Should(Exit(0))
+	appSidecars, err := CAPIClient().ListAppSidecars(appGuid, capi_client.ListOptions{})
+	Expect(err).NotTo(HaveOccurred())
 
-	type sidecarListResponse struct {
-		Resources []Sidecar `json:"resources"`
+	sidecars := make([]Sidecar, 0, len(appSidecars))
+	for _, sidecar := range appSidecars {
+		sidecars = append(sidecars, Sidecar{
+			Name:         sidecar.Name,
+			Command:      sidecar.Command,
+			ProcessTypes: sidecar.ProcessTypes,
+			MemoryInMb:   sidecar.MemoryInMB,
+		})
 	}
-	sidecarList := sidecarListResponse{}
-	err := json.Unmarshal(session.Out.Contents(), &sidecarList)
-	Expect(err).NotTo(HaveOccurred())
 
-	return sidecarList.Resources
+	return sidecars
 }
 
 func UpdateEnvironmentVariables(appGUID, envVars string) {
-	appUpdatePath := fmt.Sprintf("/v3/apps/%s/environment_variables", appGUID)
-	appUpdateBody := fmt.Sprintf(`{"var": %s}`, envVars)
-	Expect(cf.Cf("curl", "-f", appUpdatePath, "-X", "PATCH", "-d", appUpdateBody).Wait()).To(Exit(0))
+	var vars map[string]*string
+	err := json.Unmarshal([]byte(envVars), &vars)
+	Expect(err).NotTo(HaveOccurred())
+
+	_, err = CAPIClient().UpdateAppEnvironmentVariables(appGUID, vars)
+	Expect(err).NotTo(HaveOccurred())
 }
 
 func HandleAsyncRequest(path string, method string) {
-	session := cf.Cf("curl", "-f", path, "-X", method, "-i")
-	bytes := session.Wait().Out.Contents()
-	Expect(string(bytes)).To(ContainSubstring("202 Accepted"))
+	jobPath, err := CAPIClient().DoAsync(method, path, nil)
+	Expect(err).NotTo(HaveOccurred())
 
-	jobPath := GetJobPath(bytes)
 	PollJob(jobPath)
 }
 
@@ -81,46 +74,32 @@ func FetchRecentLogs(appGuid string, config config.BaraConfig) *Session {
 	return session
 }
 
-func GetGuidFromResponse(response []byte) string {
-	type resource struct {
-		Guid string `json:"guid"`
-	}
-	var GetResponse struct {
-		Resources []resource `json:"resources"`
-	}
-
-	err := json.Unmarshal(response, &GetResponse)
-	Expect(err).ToNot(HaveOccurred())
-
-	if len(GetResponse.Resources) == 0 {
-		Fail("No guid found for response")
-	}
-
-	return GetResponse.Resources[0].Guid
-}
-
 func GetSpaceGuidFromName(name string) string {
-	session := cf.Cf("curl", "-f", fmt.Sprintf("/v3/spaces?names=%s", name))
-	bytes := session.Wait().Out.Contents()
-	return GetGuidFromResponse(bytes)
+	spaces, err := CAPIClient().ListSpaces(capi_client.ListOptions{}.Filter("names", name))
+	Expect(err).NotTo(HaveOccurred())
+	Expect(spaces).NotTo(BeEmpty(), "no space named %s", name)
+	return spaces[0].GUID
 }
 
 func GetOrgGUIDFromName(name string) string {
-	session := cf.Cf("curl", "-f", fmt.Sprintf("/v3/organizations?names=%s", name))
-	bytes := session.Wait().Out.Contents()
-	return GetGuidFromResponse(bytes)
+	orgs, err := CAPIClient().ListOrganizations(capi_client.ListOptions{}.Filter("names", name))
+	Expect(err).NotTo(HaveOccurred())
+	Expect(orgs).NotTo(BeEmpty(), "no organization named %s", name)
+	return orgs[0].GUID
 }
 
 func GetDomainGUIDFromName(name string) string {
-	session := cf.Cf("curl", "-f", fmt.Sprintf("/v3/domains?names=%s", name))
-	bytes := session.Wait().Out.Contents()
-	return GetGuidFromResponse(bytes)
+	domains, err := CAPIClient().ListDomains(capi_client.ListOptions{}.Filter("names", name))
+	Expect(err).NotTo(HaveOccurred())
+	Expect(domains).NotTo(BeEmpty(), "no domain named %s", name)
+	return domains[0].GUID
 }
 
 func GetRouteGUIDFromAppGuid(appGuid string) string {
-	session := cf.Cf("curl", "-f", fmt.Sprintf("/v3/apps/%s/routes", appGuid))
-	bytes := session.Wait().Out.Contents()
-	return GetGuidFromResponse(bytes)
+	routes, err := CAPIClient().ListAppRoutes(appGuid, capi_client.ListOptions{})
+	Expect(err).NotTo(HaveOccurred())
+	Expect(routes).NotTo(BeEmpty(), "app %s has no routes", appGuid)
+	return routes[0].GUID
 }
 
 //private
@@ -0,0 +1,13 @@
+package v3_helpers_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestV3Helpers(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "V3 Helpers Suite")
+}
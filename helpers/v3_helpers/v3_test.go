@@ -0,0 +1,58 @@
+package v3_helpers_test
+
+import (
+	"time"
+
+	. "github.com/cloudfoundry/capi-bara-tests/bara_suite_helpers"
+	"github.com/cloudfoundry/capi-bara-tests/helpers/fake_cc"
+	. "github.com/cloudfoundry/capi-bara-tests/helpers/v3_helpers"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Lookups by name", func() {
+	var (
+		fakeCC *fake_cc.FakeCC
+		now    time.Time
+	)
+
+	BeforeEach(func() {
+		fakeCC = fake_cc.New()
+		Config = fakeCC.Config()
+		now = time.Now()
+	})
+
+	AfterEach(func() {
+		fakeCC.Close()
+	})
+
+	It("finds spaces, organizations and domains by name", func() {
+		fakeCC.AddNamed("spaces", "other-space", now)
+		spaceGUID := fakeCC.AddNamed("spaces", "some-space", now)
+		orgGUID := fakeCC.AddNamed("organizations", "some-org", now)
+		domainGUID := fakeCC.AddNamed("domains", "example.com", now)
+
+		Expect(GetSpaceGuidFromName("some-space")).To(Equal(spaceGUID))
+		Expect(GetOrgGUIDFromName("some-org")).To(Equal(orgGUID))
+		Expect(GetDomainGUIDFromName("example.com")).To(Equal(domainGUID))
+	})
+
+	It("fails when nothing has the name", func() {
+		err := InterceptGomegaFailure(func() {
+			GetOrgGUIDFromName("missing-org")
+		})
+
+		Expect(err).To(MatchError(ContainSubstring("no organization named missing-org")))
+	})
+
+	It("applies the default quota to an organization", func() {
+		fakeCC.AddNamed("organization_quotas", "other-quota", now)
+		quotaGUID := fakeCC.AddNamed("organization_quotas", "default", now)
+		orgGUID := fakeCC.AddNamed("organizations", "some-org", now)
+
+		SetDefaultOrgQuota(orgGUID)
+
+		Expect(fakeCC.AppliedOrgQuota(orgGUID)).To(Equal(quotaGUID))
+	})
+})
@@ -8,6 +8,16 @@ func (e *Errors) Add(err error) {
 	e.errors = append(e.errors, err)
 }
 
+// AddAll appends every error in other.
+func (e *Errors) AddAll(other Errors) {
+	e.errors = append(e.errors, other.errors...)
+}
+
+// All returns the aggregated errors, so callers can inspect their types.
+func (errs Errors) All() []error {
+	return errs.errors
+}
+
 func (errs Errors) Error() string {
 	result := ""
 	for i, e := range errs.errors {